@@ -42,14 +42,90 @@ type RunHistoryPolicy struct {
 	KeepLastRuns *int `json:"runs,omitempty"`
 }
 
+// DriftDetection configures when layers are periodically planned and checked
+// for drift. Schedule is a cron expression and takes precedence over Interval,
+// a duration such as "1h". The controller timer is used when both are empty.
+type DriftDetection struct {
+	Enabled  *bool  `json:"enabled,omitempty"`
+	Schedule string `json:"schedule,omitempty"`
+	Interval string `json:"interval,omitempty"`
+}
+
+const (
+	DestructiveChangesAllow           string = "allow"
+	DestructiveChangesRequireApproval string = "requireApproval"
+	DestructiveChangesDeny            string = "deny"
+)
+
 type RemediationStrategy struct {
 	AutoApply                *bool                      `json:"autoApply,omitempty"`
 	ApplyWithoutPlanArtifact *bool                      `json:"applyWithoutPlanArtifact,omitempty"`
 	OnError                  OnErrorRemediationStrategy `json:"onError,omitempty"`
-}
+	// What to do with a plan deleting or replacing resources: allow applies
+	// it as any other plan, requireApproval waits for a manual approval even
+	// if autoApply is enabled, deny never applies it
+	// +kubebuilder:validation:Enum=allow;requireApproval;deny
+	DestructiveChanges string `json:"destructiveChanges,omitempty"`
+	// Resource types whose deletion is always allowed or always denied,
+	// whatever destructiveChanges is set to
+	DestructiveResourceTypes DestructiveResourceTypes `json:"destructiveResourceTypes,omitempty"`
+	// Maximum duration of a run attempt, such as "1h". The runner of an
+	// attempt running for longer is interrupted and the attempt is retried.
+	// The controller timer is used when empty.
+	RunTimeout string `json:"runTimeout,omitempty"`
+}
+
+// DestructiveResourceTypes lists resource types, or glob patterns such as
+// aws_db_*, overriding the destructive changes strategy
+type DestructiveResourceTypes struct {
+	// Resource types which can always be deleted or replaced, e.g. null_resource
+	Allow []string `json:"allow,omitempty"`
+	// Resource types which can never be deleted or replaced
+	Deny []string `json:"deny,omitempty"`
+}
+
+// Classes of the failures of the runs, detected by the runner from the output
+// of the commands or by the controller from the status of the runner pod
+const (
+	FailureClassStateLocked    string = "StateLocked"
+	FailureClassCredentials    string = "Credentials"
+	FailureClassThrottled      string = "Throttled"
+	FailureClassValidation     string = "Validation"
+	FailureClassOutOfResources string = "OutOfResources"
+	FailureClassUnknown        string = "Unknown"
+)
 
+// OnErrorRemediationStrategy configures how the failed runs are retried. The
+// retry policies of the classified failures take precedence over maxRetries.
 type OnErrorRemediationStrategy struct {
+	// Number of retries of a failed run, for the failures of a class without
+	// its own retry policy
 	MaxRetries *int `json:"maxRetries,omitempty"`
+	// Retry policy of the runs failing because the terraform state is
+	// locked by another process
+	StateLocked RetryPolicy `json:"stateLocked,omitempty"`
+	// Retry policy of the runs failing on missing or expired credentials,
+	// not retried by default
+	Credentials RetryPolicy `json:"credentials,omitempty"`
+	// Retry policy of the runs failing because the provider API throttled
+	// their requests
+	Throttled RetryPolicy `json:"throttled,omitempty"`
+	// Retry policy of the runs failing on an invalid configuration, not
+	// retried by default
+	Validation RetryPolicy `json:"validation,omitempty"`
+	// Retry policy of the runs whose runner pod ran out of memory or
+	// disk
+	OutOfResources RetryPolicy `json:"outOfResources,omitempty"`
+}
+
+// RetryPolicy configures how the runs failing with a class of failures are
+// retried
+type RetryPolicy struct {
+	MaxRetries *int `json:"maxRetries,omitempty"`
+	// Initial delay before retrying, such as "1m", which increases
+	// exponentially with the number of retries. The failure grace period of
+	// the controller is used when empty.
+	Backoff string `json:"backoff,omitempty"`
 }
 
 type TerraformConfig struct {
@@ -65,6 +141,40 @@ type OpenTofuConfig struct {
 type TerragruntConfig struct {
 	Version string `json:"version,omitempty"`
 	Enabled *bool  `json:"enabled,omitempty"`
+	// Run the layer as a terragrunt stack with run-all, one plan per module
+	Stack *bool `json:"stack,omitempty"`
+}
+
+// TerraformVariable is an input variable of the layers, rendered by the runner
+// in a generated *.auto.tfvars file
+type TerraformVariable struct {
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z_][a-zA-Z0-9_-]*$`
+	Name string `json:"name"`
+	// Value of the variable, as a string unless hcl is true
+	Value string `json:"value,omitempty"`
+	// Parse the value as an HCL expression, e.g. a number, a list or a map
+	HCL bool `json:"hcl,omitempty"`
+	// Read the value from a ConfigMap or a Secret in the namespace of the layer
+	ValueFrom *TerraformVariableSource `json:"valueFrom,omitempty"`
+	// Mask the value in the logs and plans stored in the datastore, values
+	// read from a Secret are always masked
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="has(self.configMapKeyRef) != has(self.secretKeyRef)",message="Exactly one of configMapKeyRef or secretKeyRef must be set"
+type TerraformVariableSource struct {
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	SecretKeyRef    *corev1.SecretKeySelector    `json:"secretKeyRef,omitempty"`
+}
+
+// TerraformVarFile is a file of variable definitions, either from the
+// repository or from a ConfigMap
+// +kubebuilder:validation:XValidation:rule="has(self.path) != has(self.configMapKeyRef)",message="Exactly one of path or configMapKeyRef must be set"
+type TerraformVarFile struct {
+	// Path of the file in the repository, relative to the path of the layer
+	Path string `json:"path,omitempty"`
+	// Read the file from a ConfigMap in the namespace of the layer
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
 }
 
 func GetTerraformEnabled(repository *TerraformRepository, layer *TerraformLayer) bool {
@@ -93,10 +203,54 @@ func GetTerragruntEnabled(repository *TerraformRepository, layer *TerraformLayer
 	return chooseBool(repository.Spec.TerragruntConfig.Enabled, layer.Spec.TerragruntConfig.Enabled, false)
 }
 
+func GetTerragruntStackEnabled(repository *TerraformRepository, layer *TerraformLayer) bool {
+	if !GetTerragruntEnabled(repository, layer) {
+		return false
+	}
+	return chooseBool(repository.Spec.TerragruntConfig.Stack, layer.Spec.TerragruntConfig.Stack, false)
+}
+
 func GetTerragruntVersion(repository *TerraformRepository, layer *TerraformLayer) string {
 	return chooseString(repository.Spec.TerragruntConfig.Version, layer.Spec.TerragruntConfig.Version)
 }
 
+// GetVariables returns the variables of the repository and of the layer, a
+// variable of the layer overriding the variable of the repository with the
+// same name
+func GetVariables(repository *TerraformRepository, layer *TerraformLayer) []TerraformVariable {
+	variables := []TerraformVariable{}
+	index := map[string]int{}
+	for _, variable := range append(append([]TerraformVariable{}, repository.Spec.Variables...), layer.Spec.Variables...) {
+		if i, ok := index[variable.Name]; ok {
+			variables[i] = variable
+			continue
+		}
+		index[variable.Name] = len(variables)
+		variables = append(variables, variable)
+	}
+	return variables
+}
+
+// GetVarFiles returns the var files of the repository followed by the ones of
+// the layer, which take precedence
+func GetVarFiles(repository *TerraformRepository, layer *TerraformLayer) []TerraformVarFile {
+	return append(append([]TerraformVarFile{}, repository.Spec.VarFiles...), layer.Spec.VarFiles...)
+}
+
+// Redaction configures the values masked in the logs and plans stored in the
+// datastore, in addition to the values of the sensitive variables
+type Redaction struct {
+	// Regular expressions matching the values to mask. When an expression has
+	// groups, only the text matched by the groups is masked.
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// GetRedactionPatterns returns the redaction patterns of the repository
+// followed by the ones of the layer
+func GetRedactionPatterns(repository *TerraformRepository, layer *TerraformLayer) []string {
+	return append(append([]string{}, repository.Spec.Redaction.Patterns...), layer.Spec.Redaction.Patterns...)
+}
+
 func GetOverrideRunnerSpec(repository *TerraformRepository, layer *TerraformLayer) OverrideRunnerSpec {
 	return OverrideRunnerSpec{
 		Tolerations:  overrideTolerations(repository.Spec.OverrideRunnerSpec.Tolerations, layer.Spec.OverrideRunnerSpec.Tolerations),
@@ -130,6 +284,21 @@ func GetRunHistoryPolicy(repository *TerraformRepository, layer *TerraformLayer)
 	}
 }
 
+func GetDriftDetection(repository *TerraformRepository, layer *TerraformLayer) DriftDetection {
+	enabled := chooseBool(repository.Spec.DriftDetection.Enabled, layer.Spec.DriftDetection.Enabled, true)
+	driftDetection := DriftDetection{
+		Enabled:  &enabled,
+		Schedule: repository.Spec.DriftDetection.Schedule,
+		Interval: repository.Spec.DriftDetection.Interval,
+	}
+	// A schedule or an interval set on the layer replaces the one of the repository
+	if layer.Spec.DriftDetection.Schedule != "" || layer.Spec.DriftDetection.Interval != "" {
+		driftDetection.Schedule = layer.Spec.DriftDetection.Schedule
+		driftDetection.Interval = layer.Spec.DriftDetection.Interval
+	}
+	return driftDetection
+}
+
 func GetApplyWithoutPlanArtifactEnabled(repository *TerraformRepository, layer *TerraformLayer) bool {
 	return chooseBool(repository.Spec.RemediationStrategy.ApplyWithoutPlanArtifact, layer.Spec.RemediationStrategy.ApplyWithoutPlanArtifact, false)
 }
@@ -138,6 +307,60 @@ func GetAutoApplyEnabled(repo *TerraformRepository, layer *TerraformLayer) bool
 	return chooseBool(repo.Spec.RemediationStrategy.AutoApply, layer.Spec.RemediationStrategy.AutoApply, false)
 }
 
+func GetDestructiveChanges(repo *TerraformRepository, layer *TerraformLayer) string {
+	mode := chooseString(repo.Spec.RemediationStrategy.DestructiveChanges, layer.Spec.RemediationStrategy.DestructiveChanges)
+	if mode == "" {
+		return DestructiveChangesAllow
+	}
+	return mode
+}
+
+// GetRetryPolicy returns the retry policy of a class of failures. A nil
+// MaxRetries means that the default retry limit applies.
+func GetRetryPolicy(repo *TerraformRepository, layer *TerraformLayer, class string) RetryPolicy {
+	classPolicy := func(strategy OnErrorRemediationStrategy) RetryPolicy {
+		switch class {
+		case FailureClassStateLocked:
+			return strategy.StateLocked
+		case FailureClassCredentials:
+			return strategy.Credentials
+		case FailureClassThrottled:
+			return strategy.Throttled
+		case FailureClassValidation:
+			return strategy.Validation
+		case FailureClassOutOfResources:
+			return strategy.OutOfResources
+		}
+		return RetryPolicy{}
+	}
+	repoPolicy := classPolicy(repo.Spec.RemediationStrategy.OnError)
+	layerPolicy := classPolicy(layer.Spec.RemediationStrategy.OnError)
+	var maxRetries *int
+	if class == FailureClassValidation || class == FailureClassCredentials {
+		// Retrying does not fix a configuration or credentials error
+		maxRetries = chooseInt(repoPolicy.MaxRetries, layerPolicy.MaxRetries, 0)
+	} else if layerPolicy.MaxRetries != nil {
+		maxRetries = layerPolicy.MaxRetries
+	} else {
+		maxRetries = repoPolicy.MaxRetries
+	}
+	return RetryPolicy{
+		MaxRetries: maxRetries,
+		Backoff:    chooseString(repoPolicy.Backoff, layerPolicy.Backoff),
+	}
+}
+
+func GetRunTimeout(repo *TerraformRepository, layer *TerraformLayer) string {
+	return chooseString(repo.Spec.RemediationStrategy.RunTimeout, layer.Spec.RemediationStrategy.RunTimeout)
+}
+
+func GetDestructiveResourceTypes(repo *TerraformRepository, layer *TerraformLayer) DestructiveResourceTypes {
+	return DestructiveResourceTypes{
+		Allow: ChooseSlice(repo.Spec.RemediationStrategy.DestructiveResourceTypes.Allow, layer.Spec.RemediationStrategy.DestructiveResourceTypes.Allow),
+		Deny:  ChooseSlice(repo.Spec.RemediationStrategy.DestructiveResourceTypes.Deny, layer.Spec.RemediationStrategy.DestructiveResourceTypes.Deny),
+	}
+}
+
 func isEnabled(enabled *bool) bool {
 	return enabled != nil && *enabled
 }
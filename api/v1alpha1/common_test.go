@@ -2285,6 +2285,170 @@ func TestGetHistoryPolicy(t *testing.T) {
 	}
 }
 
+func TestGetDriftDetection(t *testing.T) {
+	tt := []struct {
+		name                   string
+		repository             *configv1alpha1.TerraformRepository
+		layer                  *configv1alpha1.TerraformLayer
+		expectedDriftDetection configv1alpha1.DriftDetection
+	}{
+		{
+			"NoDriftDetection",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{},
+			configv1alpha1.DriftDetection{
+				Enabled: &[]bool{true}[0],
+			},
+		},
+		{
+			"OnlyRepositoryDriftDetection",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					DriftDetection: configv1alpha1.DriftDetection{
+						Schedule: "0 * * * *",
+					},
+				},
+			},
+			&configv1alpha1.TerraformLayer{},
+			configv1alpha1.DriftDetection{
+				Enabled:  &[]bool{true}[0],
+				Schedule: "0 * * * *",
+			},
+		},
+		{
+			"OverrideRepositoryScheduleWithLayerInterval",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					DriftDetection: configv1alpha1.DriftDetection{
+						Schedule: "0 * * * *",
+					},
+				},
+			},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					DriftDetection: configv1alpha1.DriftDetection{
+						Interval: "6h",
+					},
+				},
+			},
+			configv1alpha1.DriftDetection{
+				Enabled:  &[]bool{true}[0],
+				Interval: "6h",
+			},
+		},
+		{
+			"DisabledOnLayer",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					DriftDetection: configv1alpha1.DriftDetection{
+						Enabled:  &[]bool{true}[0],
+						Schedule: "0 * * * *",
+					},
+				},
+			},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					DriftDetection: configv1alpha1.DriftDetection{
+						Enabled: &[]bool{false}[0],
+					},
+				},
+			},
+			configv1alpha1.DriftDetection{
+				Enabled:  &[]bool{false}[0],
+				Schedule: "0 * * * *",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := configv1alpha1.GetDriftDetection(tc.repository, tc.layer)
+			if !reflect.DeepEqual(tc.expectedDriftDetection, result) {
+				t.Errorf("different drift detection computed: expected %+v got %+v", tc.expectedDriftDetection, result)
+			}
+		})
+	}
+}
+
+func TestGetRetryPolicy(t *testing.T) {
+	tt := []struct {
+		name           string
+		repository     *configv1alpha1.TerraformRepository
+		layer          *configv1alpha1.TerraformLayer
+		class          string
+		expectedPolicy configv1alpha1.RetryPolicy
+	}{
+		{
+			"ValidationNotRetriedByDefault",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{},
+			configv1alpha1.FailureClassValidation,
+			configv1alpha1.RetryPolicy{MaxRetries: &[]int{0}[0]},
+		},
+		{
+			"ThrottledUsesDefaultRetries",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{},
+			configv1alpha1.FailureClassThrottled,
+			configv1alpha1.RetryPolicy{},
+		},
+		{
+			"OverrideRepositoryPolicyWithLayer",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					RemediationStrategy: configv1alpha1.RemediationStrategy{
+						OnError: configv1alpha1.OnErrorRemediationStrategy{
+							Throttled: configv1alpha1.RetryPolicy{MaxRetries: &[]int{10}[0], Backoff: "1m"},
+						},
+					},
+				},
+			},
+			&configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					RemediationStrategy: configv1alpha1.RemediationStrategy{
+						OnError: configv1alpha1.OnErrorRemediationStrategy{
+							Throttled: configv1alpha1.RetryPolicy{MaxRetries: &[]int{20}[0]},
+						},
+					},
+				},
+			},
+			configv1alpha1.FailureClassThrottled,
+			configv1alpha1.RetryPolicy{MaxRetries: &[]int{20}[0], Backoff: "1m"},
+		},
+		{
+			"CredentialsRetriedOnRepository",
+			&configv1alpha1.TerraformRepository{
+				Spec: configv1alpha1.TerraformRepositorySpec{
+					RemediationStrategy: configv1alpha1.RemediationStrategy{
+						OnError: configv1alpha1.OnErrorRemediationStrategy{
+							Credentials: configv1alpha1.RetryPolicy{MaxRetries: &[]int{2}[0]},
+						},
+					},
+				},
+			},
+			&configv1alpha1.TerraformLayer{},
+			configv1alpha1.FailureClassCredentials,
+			configv1alpha1.RetryPolicy{MaxRetries: &[]int{2}[0]},
+		},
+		{
+			"UnknownClass",
+			&configv1alpha1.TerraformRepository{},
+			&configv1alpha1.TerraformLayer{},
+			configv1alpha1.FailureClassUnknown,
+			configv1alpha1.RetryPolicy{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := configv1alpha1.GetRetryPolicy(tc.repository, tc.layer, tc.class)
+			if !reflect.DeepEqual(tc.expectedPolicy, result) {
+				t.Errorf("different retry policy computed: expected %+v got %+v", tc.expectedPolicy, result)
+			}
+		})
+	}
+}
+
 func TestMergeInitContainers(t *testing.T) {
 	tt := []struct {
 		name            string
@@ -2553,3 +2717,46 @@ func TestChooseSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestGetVariables(t *testing.T) {
+	repository := &configv1alpha1.TerraformRepository{
+		Spec: configv1alpha1.TerraformRepositorySpec{
+			Variables: []configv1alpha1.TerraformVariable{
+				{Name: "region", Value: "eu-west-3"},
+				{Name: "environment", Value: "staging"},
+			},
+			VarFiles: []configv1alpha1.TerraformVarFile{
+				{Path: "../common.tfvars"},
+			},
+		},
+	}
+	layer := &configv1alpha1.TerraformLayer{
+		Spec: configv1alpha1.TerraformLayerSpec{
+			Variables: []configv1alpha1.TerraformVariable{
+				{Name: "environment", Value: "production"},
+				{Name: "instance_count", Value: "3", HCL: true},
+			},
+			VarFiles: []configv1alpha1.TerraformVarFile{
+				{Path: "production.tfvars"},
+			},
+		},
+	}
+	expectedVariables := []configv1alpha1.TerraformVariable{
+		{Name: "region", Value: "eu-west-3"},
+		{Name: "environment", Value: "production"},
+		{Name: "instance_count", Value: "3", HCL: true},
+	}
+	if result := configv1alpha1.GetVariables(repository, layer); !reflect.DeepEqual(expectedVariables, result) {
+		t.Errorf("different variables computed: expected %+v got %+v", expectedVariables, result)
+	}
+	expectedVarFiles := []configv1alpha1.TerraformVarFile{
+		{Path: "../common.tfvars"},
+		{Path: "production.tfvars"},
+	}
+	if result := configv1alpha1.GetVarFiles(repository, layer); !reflect.DeepEqual(expectedVarFiles, result) {
+		t.Errorf("different var files computed: expected %+v got %+v", expectedVarFiles, result)
+	}
+	if result := configv1alpha1.GetVariables(&configv1alpha1.TerraformRepository{}, &configv1alpha1.TerraformLayer{}); len(result) != 0 {
+		t.Errorf("expected no variables, got %+v", result)
+	}
+}
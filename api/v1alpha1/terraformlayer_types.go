@@ -26,20 +26,78 @@ import (
 // TerraformLayerSpec defines the desired state of TerraformLayer
 // +kubebuilder:validation:XValidation:rule="!(has(self.terraform) && has(self.opentofu) && has(self.terraform.enabled) && has(self.opentofu.enabled) && self.terraform.enabled == true && self.opentofu.enabled == true)",message="Both terraform.enabled and opentofu.enabled cannot be true at the same time"
 // +kubebuilder:validation:XValidation:rule="!(has(self.terraform) && has(self.opentofu) && has(self.terraform.enabled) && has(self.opentofu.enabled) && self.terraform.enabled == false && self.opentofu.enabled == false)",message="Both terraform.enabled and opentofu.enabled cannot be false at the same time"
+// +kubebuilder:validation:XValidation:rule="!(has(self.deletionPolicy) && self.deletionPolicy == 'Destroy' && has(self.terragrunt) && has(self.terragrunt.stack) && self.terragrunt.stack == true)",message="The Destroy deletion policy is not supported for terragrunt stacks"
 type TerraformLayerSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	Path                 string                   `json:"path,omitempty"`
-	Branch               string                   `json:"branch,omitempty"`
-	AdditionalTargetRefs []string                 `json:"additionalTargetRefs,omitempty"`
-	TerraformConfig      TerraformConfig          `json:"terraform,omitempty"`
-	OpenTofuConfig       OpenTofuConfig           `json:"opentofu,omitempty"`
-	TerragruntConfig     TerragruntConfig         `json:"terragrunt,omitempty"`
-	Repository           TerraformLayerRepository `json:"repository,omitempty"`
-	RemediationStrategy  RemediationStrategy      `json:"remediationStrategy,omitempty"`
-	OverrideRunnerSpec   OverrideRunnerSpec       `json:"overrideRunnerSpec,omitempty"`
-	RunHistoryPolicy     RunHistoryPolicy         `json:"runHistoryPolicy,omitempty"`
+	Path                 string                    `json:"path,omitempty"`
+	Branch               string                    `json:"branch,omitempty"`
+	AdditionalTargetRefs []string                  `json:"additionalTargetRefs,omitempty"`
+	TerraformConfig      TerraformConfig           `json:"terraform,omitempty"`
+	OpenTofuConfig       OpenTofuConfig            `json:"opentofu,omitempty"`
+	TerragruntConfig     TerragruntConfig          `json:"terragrunt,omitempty"`
+	Repository           TerraformLayerRepository  `json:"repository,omitempty"`
+	RemediationStrategy  RemediationStrategy       `json:"remediationStrategy,omitempty"`
+	OverrideRunnerSpec   OverrideRunnerSpec        `json:"overrideRunnerSpec,omitempty"`
+	RunHistoryPolicy     RunHistoryPolicy          `json:"runHistoryPolicy,omitempty"`
+	DriftDetection       DriftDetection            `json:"driftDetection,omitempty"`
+	DependsOn            []TerraformLayerReference `json:"dependsOn,omitempty"`
+	// Terraform workspace the layer is planned and applied in, the default workspace if empty
+	Workspace string                 `json:"workspace,omitempty"`
+	Variables []TerraformVariable    `json:"variables,omitempty"`
+	VarFiles  []TerraformVarFile     `json:"varFiles,omitempty"`
+	Redaction Redaction              `json:"redaction,omitempty"`
+	Outputs   *TerraformLayerOutputs `json:"outputs,omitempty"`
+	// Outputs of other layers used as input variables of the layer
+	InputsFrom []TerraformLayerInput `json:"inputsFrom,omitempty"`
+	// +kubebuilder:validation:Enum=Destroy;Orphan
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// Stops all the plans, applies and drift checks of the layer
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// DeletionPolicy defines what happens to the infrastructure managed by a
+// layer when the layer is deleted
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyOrphan leaves the infrastructure untouched (default)
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	// DeletionPolicyDestroy destroys the infrastructure before the layer is removed
+	DeletionPolicyDestroy DeletionPolicy = "Destroy"
+)
+
+// TerraformLayerOutputs describes the Secret or ConfigMap the outputs of the
+// layer are written to after each apply
+type TerraformLayerOutputs struct {
+	// Kind of the resource the outputs are written to, sensitive outputs are
+	// only written to Secrets
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	// +kubebuilder:default=Secret
+	Kind string `json:"kind,omitempty"`
+	// Name of the resource, in the namespace of the layer
+	Name string `json:"name"`
+	// Names of the outputs to write, all the outputs if empty
+	Names []string `json:"names,omitempty"`
+}
+
+// TerraformLayerInput sets input variables of the layer from the outputs of
+// another layer, as stored by its last successful apply
+type TerraformLayerInput struct {
+	Layer TerraformLayerReference `json:"layer"`
+	// +kubebuilder:validation:MinItems=1
+	Outputs []TerraformLayerInputOutput `json:"outputs"`
+}
+
+// TerraformLayerInputOutput maps an output of the upstream layer to an input
+// variable of the layer
+type TerraformLayerInputOutput struct {
+	// Name of the output of the upstream layer
+	Name string `json:"name"`
+	// Name of the input variable, the name of the output if empty
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z_][a-zA-Z0-9_-]*$`
+	Variable string `json:"variable,omitempty"`
 }
 
 type TerraformLayerRepository struct {
@@ -47,13 +105,41 @@ type TerraformLayerRepository struct {
 	Namespace string `json:"namespace,omitempty"`
 }
 
+// TerraformLayerReference references another TerraformLayer.
+// If the namespace is omitted, the namespace of the referencing layer is used.
+type TerraformLayerReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
 // TerraformLayerStatus defines the observed state of TerraformLayer
 type TerraformLayerStatus struct {
-	Conditions []metav1.Condition  `json:"conditions,omitempty"`
-	State      string              `json:"state,omitempty"`
-	LastResult string              `json:"lastResult,omitempty"`
-	LastRun    TerraformLayerRun   `json:"lastRun,omitempty"`
-	LatestRuns []TerraformLayerRun `json:"latestRuns,omitempty"`
+	Conditions      []metav1.Condition            `json:"conditions,omitempty"`
+	State           string                        `json:"state,omitempty"`
+	LastResult      string                        `json:"lastResult,omitempty"`
+	LastRun         TerraformLayerRun             `json:"lastRun,omitempty"`
+	LatestRuns      []TerraformLayerRun           `json:"latestRuns,omitempty"`
+	PendingApproval TerraformLayerPendingApproval `json:"pendingApproval,omitempty"`
+	HasDrifted      bool                          `json:"hasDrifted,omitempty"`
+	Outputs         TerraformLayerOutputsStatus   `json:"outputs,omitempty"`
+	Policy          TerraformLayerPolicyStatus    `json:"policy,omitempty"`
+}
+
+// TerraformLayerOutputsStatus is the last revision of the layer whose outputs
+// have been published
+type TerraformLayerOutputsStatus struct {
+	Commit string `json:"commit,omitempty"`
+	Run    string `json:"run,omitempty"`
+}
+
+// TerraformLayerPolicyStatus is the result of the evaluation of the policies
+// against the last plan of the layer
+type TerraformLayerPolicyStatus struct {
+	Run    string `json:"run,omitempty"`
+	Sum    string `json:"sum,omitempty"`
+	Result string `json:"result,omitempty"`
+	// Digest of the policies the plan has been evaluated against
+	Policies string `json:"policies,omitempty"`
 }
 
 type TerraformLayerRun struct {
@@ -63,6 +149,13 @@ type TerraformLayerRun struct {
 	Action string      `json:"action,omitempty"`
 }
 
+type TerraformLayerPendingApproval struct {
+	Run     string `json:"run,omitempty"`
+	Attempt string `json:"attempt,omitempty"`
+	Sum     string `json:"sum,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:shortName=layers;layer;tfls;tfl;
 // +kubebuilder:subresource:status
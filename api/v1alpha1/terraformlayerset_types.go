@@ -0,0 +1,128 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TerraformLayerSetSpec defines the desired state of TerraformLayerSet
+type TerraformLayerSetSpec struct {
+	Repository TerraformLayerRepository   `json:"repository,omitempty"`
+	Branch     string                     `json:"branch,omitempty"`
+	Generator  TerraformLayerSetGenerator `json:"generator,omitempty"`
+	Template   TerraformLayerSetTemplate  `json:"template,omitempty"`
+	// What happens to the generated layers whose path is no longer matched,
+	// Orphan by default
+	// +kubebuilder:validation:Enum=Orphan;Delete
+	PrunePolicy PrunePolicy `json:"prunePolicy,omitempty"`
+}
+
+// PrunePolicy defines what happens to the layers of a TerraformLayerSet
+// whose path is no longer matched by its generator
+type PrunePolicy string
+
+const (
+	// PrunePolicyOrphan releases the layers, which are no longer managed by the set (default)
+	PrunePolicyOrphan PrunePolicy = "Orphan"
+	// PrunePolicyDelete deletes the layers, their deletion policy then applies
+	PrunePolicyDelete PrunePolicy = "Delete"
+)
+
+// TerraformLayerSetGenerator selects the directories of the repository
+// a layer is generated for.
+// +kubebuilder:validation:XValidation:rule="has(self.directories) || has(self.containsFile)",message="At least one of directories or containsFile must be set"
+type TerraformLayerSetGenerator struct {
+	// Glob patterns matched against the directories of the repository, e.g. envs/*/*
+	Directories []string `json:"directories,omitempty"`
+	// Glob pattern of a file the directories must contain, e.g. terragrunt.hcl
+	ContainsFile string `json:"containsFile,omitempty"`
+	// Glob patterns of directories that must not get a layer
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// TerraformLayerSetTemplate is the template of the generated layers. Their
+// path, branch and repository are set by the TerraformLayerSet.
+type TerraformLayerSetTemplate struct {
+	Metadata TerraformLayerSetTemplateMetadata `json:"metadata,omitempty"`
+	Spec     TerraformLayerSpec                `json:"spec,omitempty"`
+}
+
+type TerraformLayerSetTemplateMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// TerraformLayerSetStatus defines the observed state of TerraformLayerSet
+type TerraformLayerSetStatus struct {
+	State              string                   `json:"state,omitempty"`
+	Revision           string                   `json:"revision,omitempty"`
+	ObservedGeneration int64                    `json:"observedGeneration,omitempty"`
+	Layers             []TerraformLayerSetLayer `json:"layers,omitempty"`
+}
+
+// TerraformLayerSetLayer is a layer generated by a TerraformLayerSet
+type TerraformLayerSetLayer struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=layersets;layerset;tflset;
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Repository",type=string,JSONPath=`.spec.repository.name`
+// +kubebuilder:printcolumn:name="Branch",type=string,JSONPath=`.spec.branch`
+// +kubebuilder:printcolumn:name="Revision",type=string,JSONPath=`.status.revision`
+// TerraformLayerSet is the Schema for the terraformlayersets API
+type TerraformLayerSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TerraformLayerSetSpec   `json:"spec,omitempty"`
+	Status TerraformLayerSetStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// TerraformLayerSetList contains a list of TerraformLayerSet
+type TerraformLayerSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TerraformLayerSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TerraformLayerSet{}, &TerraformLayerSetList{})
+}
+
+// Workaround needed for envtest which does not populate the TypeMeta structure
+// See https://github.com/kubernetes-sigs/controller-runtime/issues/1870
+func (set *TerraformLayerSet) GetAPIVersion() string {
+	if set.APIVersion == "" {
+		return "config.terraform.padok.cloud/v1alpha1"
+	}
+	return set.APIVersion
+}
+
+// Same as above
+func (set *TerraformLayerSet) GetKind() string {
+	if set.Kind == "" {
+		return "TerraformLayerSet"
+	}
+	return set.Kind
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TerraformPolicySpec defines a Rego policy evaluated against the JSON plan
+// of the layers of its namespace. The policy denies a plan with the messages
+// of its `deny` rule and warns with the messages of its `warn` rule.
+// +kubebuilder:validation:XValidation:rule="has(self.rego) != has(self.configMapKeyRef)",message="Exactly one of rego or configMapKeyRef must be set"
+type TerraformPolicySpec struct {
+	// Rego module of the policy
+	Rego string `json:"rego,omitempty"`
+	// Key of a ConfigMap holding the Rego module of the policy
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	// Enforce blocks the apply of a plan denied by the policy, Warn only
+	// reports the denials
+	// +kubebuilder:validation:Enum=Enforce;Warn
+	// +kubebuilder:default=Enforce
+	Mode string `json:"mode,omitempty"`
+	// Layers the policy applies to, all the layers of the namespace if empty
+	LayerSelector *metav1.LabelSelector `json:"layerSelector,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=tfpolicy
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`
+// TerraformPolicy is the Schema for the terraformpolicies API
+type TerraformPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TerraformPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// TerraformPolicyList contains a list of TerraformPolicy
+type TerraformPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TerraformPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TerraformPolicy{}, &TerraformPolicyList{})
+}
@@ -37,8 +37,14 @@ type TerraformRepositorySpec struct {
 	RemediationStrategy     RemediationStrategy           `json:"remediationStrategy,omitempty"`
 	OverrideRunnerSpec      OverrideRunnerSpec            `json:"overrideRunnerSpec,omitempty"`
 	RunHistoryPolicy        RunHistoryPolicy              `json:"runHistoryPolicy,omitempty"`
+	DriftDetection          DriftDetection                `json:"driftDetection,omitempty"`
 	MaxConcurrentRunnerPods int                           `json:"maxConcurrentRunnerPods,omitempty"`
 	SyncWindows             []SyncWindow                  `json:"syncWindows,omitempty"`
+	Variables               []TerraformVariable           `json:"variables,omitempty"`
+	VarFiles                []TerraformVarFile            `json:"varFiles,omitempty"`
+	Redaction               Redaction                     `json:"redaction,omitempty"`
+	// Stops the syncs of the repository and suspends all its layers
+	Suspend bool `json:"suspend,omitempty"`
 }
 type TerraformRepositoryRepository struct {
 	Url string `json:"url,omitempty"`
@@ -25,9 +25,17 @@ import (
 
 // TerraformRunSpec defines the desired state of TerraformRun
 type TerraformRunSpec struct {
+	// +kubebuilder:validation:Enum=plan;apply;destroy;drift-check;import;state-mv;state-rm;force-unlock
 	Action   string            `json:"action,omitempty"`
 	Artifact Artifact          `json:"artifact,omitempty"`
 	Layer    TerraformRunLayer `json:"layer,omitempty"`
+	Targets  []string          `json:"targets,omitempty"`
+	Replace  []string          `json:"replace,omitempty"`
+	// Arguments of a state maintenance action: the address and the ID of the
+	// resource to import, the source and destination addresses of a state
+	// mv, the addresses to remove from the state or the ID of the lock to
+	// release
+	Args []string `json:"args,omitempty"`
 }
 
 type Artifact struct {
@@ -55,6 +63,15 @@ type Attempt struct {
 	PodName      string `json:"podName"`
 	Number       int    `json:"number"`
 	LogsUploaded bool   `json:"logsUploaded,omitempty"`
+	// Why the attempt has been interrupted or is blocked, such as TimedOut
+	// or the reason of a runner pod stuck in Pending
+	Reason string `json:"reason,omitempty"`
+	// Details about the reason
+	Message string `json:"message,omitempty"`
+	// Class of the failure of the attempt, such as Validation or Throttled
+	FailureClass string `json:"failureClass,omitempty"`
+	// Node the runner pod has been scheduled on
+	NodeName string `json:"nodeName,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -90,6 +90,51 @@ func (in ExtraArgs) DeepCopy() ExtraArgs {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DestructiveResourceTypes) DeepCopyInto(out *DestructiveResourceTypes) {
+	*out = *in
+	if in.Allow != nil {
+		in, out := &in.Allow, &out.Allow
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Deny != nil {
+		in, out := &in.Deny, &out.Deny
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DestructiveResourceTypes.
+func (in *DestructiveResourceTypes) DeepCopy() *DestructiveResourceTypes {
+	if in == nil {
+		return nil
+	}
+	out := new(DestructiveResourceTypes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftDetection) DeepCopyInto(out *DriftDetection) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftDetection.
+func (in *DriftDetection) DeepCopy() *DriftDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetadataOverride) DeepCopyInto(out *MetadataOverride) {
 	*out = *in
@@ -127,6 +172,11 @@ func (in *OnErrorRemediationStrategy) DeepCopyInto(out *OnErrorRemediationStrate
 		*out = new(int)
 		**out = **in
 	}
+	in.StateLocked.DeepCopyInto(&out.StateLocked)
+	in.Credentials.DeepCopyInto(&out.Credentials)
+	in.Throttled.DeepCopyInto(&out.Throttled)
+	in.Validation.DeepCopyInto(&out.Validation)
+	in.OutOfResources.DeepCopyInto(&out.OutOfResources)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnErrorRemediationStrategy.
@@ -260,6 +310,26 @@ func (in *OverrideRunnerSpec) DeepCopy() *OverrideRunnerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Redaction) DeepCopyInto(out *Redaction) {
+	*out = *in
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Redaction.
+func (in *Redaction) DeepCopy() *Redaction {
+	if in == nil {
+		return nil
+	}
+	out := new(Redaction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemediationStrategy) DeepCopyInto(out *RemediationStrategy) {
 	*out = *in
@@ -274,6 +344,7 @@ func (in *RemediationStrategy) DeepCopyInto(out *RemediationStrategy) {
 		**out = **in
 	}
 	in.OnError.DeepCopyInto(&out.OnError)
+	in.DestructiveResourceTypes.DeepCopyInto(&out.DestructiveResourceTypes)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationStrategy.
@@ -286,6 +357,26 @@ func (in *RemediationStrategy) DeepCopy() *RemediationStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RunHistoryPolicy) DeepCopyInto(out *RunHistoryPolicy) {
 	*out = *in
@@ -378,6 +469,42 @@ func (in *TerraformLayer) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerInput) DeepCopyInto(out *TerraformLayerInput) {
+	*out = *in
+	out.Layer = in.Layer
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]TerraformLayerInputOutput, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerInput.
+func (in *TerraformLayerInput) DeepCopy() *TerraformLayerInput {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerInput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerInputOutput) DeepCopyInto(out *TerraformLayerInputOutput) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerInputOutput.
+func (in *TerraformLayerInputOutput) DeepCopy() *TerraformLayerInputOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerInputOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TerraformLayerList) DeepCopyInto(out *TerraformLayerList) {
 	*out = *in
@@ -410,6 +537,86 @@ func (in *TerraformLayerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerOutputs) DeepCopyInto(out *TerraformLayerOutputs) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerOutputs.
+func (in *TerraformLayerOutputs) DeepCopy() *TerraformLayerOutputs {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerOutputs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerOutputsStatus) DeepCopyInto(out *TerraformLayerOutputsStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerOutputsStatus.
+func (in *TerraformLayerOutputsStatus) DeepCopy() *TerraformLayerOutputsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerOutputsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerPendingApproval) DeepCopyInto(out *TerraformLayerPendingApproval) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerPendingApproval.
+func (in *TerraformLayerPendingApproval) DeepCopy() *TerraformLayerPendingApproval {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerPendingApproval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerPolicyStatus) DeepCopyInto(out *TerraformLayerPolicyStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerPolicyStatus.
+func (in *TerraformLayerPolicyStatus) DeepCopy() *TerraformLayerPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerReference) DeepCopyInto(out *TerraformLayerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerReference.
+func (in *TerraformLayerReference) DeepCopy() *TerraformLayerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TerraformLayerRepository) DeepCopyInto(out *TerraformLayerRepository) {
 	*out = *in
@@ -441,6 +648,189 @@ func (in *TerraformLayerRun) DeepCopy() *TerraformLayerRun {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerSet) DeepCopyInto(out *TerraformLayerSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerSet.
+func (in *TerraformLayerSet) DeepCopy() *TerraformLayerSet {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TerraformLayerSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerSetGenerator) DeepCopyInto(out *TerraformLayerSetGenerator) {
+	*out = *in
+	if in.Directories != nil {
+		in, out := &in.Directories, &out.Directories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerSetGenerator.
+func (in *TerraformLayerSetGenerator) DeepCopy() *TerraformLayerSetGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerSetGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerSetLayer) DeepCopyInto(out *TerraformLayerSetLayer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerSetLayer.
+func (in *TerraformLayerSetLayer) DeepCopy() *TerraformLayerSetLayer {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerSetLayer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerSetList) DeepCopyInto(out *TerraformLayerSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TerraformLayerSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerSetList.
+func (in *TerraformLayerSetList) DeepCopy() *TerraformLayerSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TerraformLayerSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerSetSpec) DeepCopyInto(out *TerraformLayerSetSpec) {
+	*out = *in
+	out.Repository = in.Repository
+	in.Generator.DeepCopyInto(&out.Generator)
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerSetSpec.
+func (in *TerraformLayerSetSpec) DeepCopy() *TerraformLayerSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerSetStatus) DeepCopyInto(out *TerraformLayerSetStatus) {
+	*out = *in
+	if in.Layers != nil {
+		in, out := &in.Layers, &out.Layers
+		*out = make([]TerraformLayerSetLayer, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerSetStatus.
+func (in *TerraformLayerSetStatus) DeepCopy() *TerraformLayerSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerSetTemplate) DeepCopyInto(out *TerraformLayerSetTemplate) {
+	*out = *in
+	in.Metadata.DeepCopyInto(&out.Metadata)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerSetTemplate.
+func (in *TerraformLayerSetTemplate) DeepCopy() *TerraformLayerSetTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerSetTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformLayerSetTemplateMetadata) DeepCopyInto(out *TerraformLayerSetTemplateMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerSetTemplateMetadata.
+func (in *TerraformLayerSetTemplateMetadata) DeepCopy() *TerraformLayerSetTemplateMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformLayerSetTemplateMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TerraformLayerSpec) DeepCopyInto(out *TerraformLayerSpec) {
 	*out = *in
@@ -456,6 +846,39 @@ func (in *TerraformLayerSpec) DeepCopyInto(out *TerraformLayerSpec) {
 	in.RemediationStrategy.DeepCopyInto(&out.RemediationStrategy)
 	in.OverrideRunnerSpec.DeepCopyInto(&out.OverrideRunnerSpec)
 	in.RunHistoryPolicy.DeepCopyInto(&out.RunHistoryPolicy)
+	in.DriftDetection.DeepCopyInto(&out.DriftDetection)
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]TerraformLayerReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]TerraformVariable, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VarFiles != nil {
+		in, out := &in.VarFiles, &out.VarFiles
+		*out = make([]TerraformVarFile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Redaction.DeepCopyInto(&out.Redaction)
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = new(TerraformLayerOutputs)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InputsFrom != nil {
+		in, out := &in.InputsFrom, &out.InputsFrom
+		*out = make([]TerraformLayerInput, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerSpec.
@@ -486,6 +909,9 @@ func (in *TerraformLayerStatus) DeepCopyInto(out *TerraformLayerStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	out.PendingApproval = in.PendingApproval
+	out.Outputs = in.Outputs
+	out.Policy = in.Policy
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformLayerStatus.
@@ -498,6 +924,89 @@ func (in *TerraformLayerStatus) DeepCopy() *TerraformLayerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformPolicy) DeepCopyInto(out *TerraformPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformPolicy.
+func (in *TerraformPolicy) DeepCopy() *TerraformPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TerraformPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformPolicyList) DeepCopyInto(out *TerraformPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TerraformPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformPolicyList.
+func (in *TerraformPolicyList) DeepCopy() *TerraformPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TerraformPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformPolicySpec) DeepCopyInto(out *TerraformPolicySpec) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LayerSelector != nil {
+		in, out := &in.LayerSelector, &out.LayerSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformPolicySpec.
+func (in *TerraformPolicySpec) DeepCopy() *TerraformPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TerraformPullRequest) DeepCopyInto(out *TerraformPullRequest) {
 	*out = *in
@@ -679,6 +1188,7 @@ func (in *TerraformRepositorySpec) DeepCopyInto(out *TerraformRepositorySpec) {
 	in.RemediationStrategy.DeepCopyInto(&out.RemediationStrategy)
 	in.OverrideRunnerSpec.DeepCopyInto(&out.OverrideRunnerSpec)
 	in.RunHistoryPolicy.DeepCopyInto(&out.RunHistoryPolicy)
+	in.DriftDetection.DeepCopyInto(&out.DriftDetection)
 	if in.SyncWindows != nil {
 		in, out := &in.SyncWindows, &out.SyncWindows
 		*out = make([]SyncWindow, len(*in))
@@ -686,6 +1196,21 @@ func (in *TerraformRepositorySpec) DeepCopyInto(out *TerraformRepositorySpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]TerraformVariable, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VarFiles != nil {
+		in, out := &in.VarFiles, &out.VarFiles
+		*out = make([]TerraformVarFile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Redaction.DeepCopyInto(&out.Redaction)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformRepositorySpec.
@@ -730,7 +1255,7 @@ func (in *TerraformRun) DeepCopyInto(out *TerraformRun) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -804,6 +1329,21 @@ func (in *TerraformRunSpec) DeepCopyInto(out *TerraformRunSpec) {
 	*out = *in
 	out.Artifact = in.Artifact
 	out.Layer = in.Layer
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Replace != nil {
+		in, out := &in.Replace, &out.Replace
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformRunSpec.
@@ -843,6 +1383,71 @@ func (in *TerraformRunStatus) DeepCopy() *TerraformRunStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformVarFile) DeepCopyInto(out *TerraformVarFile) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformVarFile.
+func (in *TerraformVarFile) DeepCopy() *TerraformVarFile {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformVarFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformVariable) DeepCopyInto(out *TerraformVariable) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(TerraformVariableSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformVariable.
+func (in *TerraformVariable) DeepCopy() *TerraformVariable {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformVariable)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TerraformVariableSource) DeepCopyInto(out *TerraformVariableSource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerraformVariableSource.
+func (in *TerraformVariableSource) DeepCopy() *TerraformVariableSource {
+	if in == nil {
+		return nil
+	}
+	out := new(TerraformVariableSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TerragruntConfig) DeepCopyInto(out *TerragruntConfig) {
 	*out = *in
@@ -851,6 +1456,11 @@ func (in *TerragruntConfig) DeepCopyInto(out *TerragruntConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Stack != nil {
+		in, out := &in.Stack, &out.Stack
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerragruntConfig.
@@ -29,16 +29,20 @@ func buildControllersStartCmd(app *burrito.App) *cobra.Command {
 	defaultFailureGracePeriod, _ := time.ParseDuration("15s")
 	defaultRepositorySyncTimer, _ := time.ParseDuration("5m")
 	defaultCredentialsTTL, _ := time.ParseDuration("2m")
+	defaultPendingTimeout, _ := time.ParseDuration("10m")
 
 	cmd.Flags().StringSliceVar(&app.Config.Controller.Namespaces, "namespaces", []string{"burrito-system"}, "list of namespaces to watch")
-	cmd.Flags().StringArrayVar(&app.Config.Controller.Types, "types", []string{"layer", "repository", "run", "pullrequest"}, "list of controllers to start")
+	cmd.Flags().StringArrayVar(&app.Config.Controller.Types, "types", []string{"layer", "layerset", "repository", "run", "pullrequest"}, "list of controllers to start")
 	cmd.Flags().DurationVar(&app.Config.Controller.Timers.DriftDetection, "drift-detection-period", defaultDriftDetectionTimer, "period between two plans. Must end with s, m or h.")
 	cmd.Flags().DurationVar(&app.Config.Controller.Timers.RepositorySync, "repository-sync-period", defaultRepositorySyncTimer, "period between two repository sync. Must end with s, m or h.")
 	cmd.Flags().DurationVar(&app.Config.Controller.Timers.CredentialsTTL, "credentials-ttl", defaultCredentialsTTL, "default TTL for git providers credentials in controller's memory. Must end with s, m or h.")
 	cmd.Flags().DurationVar(&app.Config.Controller.Timers.OnError, "on-error-period", defaultOnErrorTimer, "period between two runners launch when an error occurred in the controllers. Must end with s, m or h.")
 	cmd.Flags().DurationVar(&app.Config.Controller.Timers.WaitAction, "wait-action-period", defaultWaitActionTimer, "period between two runners when a layer is locked. Must end with s, m or h.")
 	cmd.Flags().DurationVar(&app.Config.Controller.Timers.FailureGracePeriod, "failure-grace-period", defaultFailureGracePeriod, "initial time before retry, goes exponential function of number failure. Must end with s, m or h.")
+	cmd.Flags().DurationVar(&app.Config.Controller.Timers.RunTimeout, "run-timeout", 0, "maximum duration of a run attempt before its runner is interrupted (can be overriden in CRDs), 0 to disable. Must end with s, m or h.")
+	cmd.Flags().DurationVar(&app.Config.Controller.Timers.PendingTimeout, "pending-timeout", defaultPendingTimeout, "maximum duration a runner pod can be stuck in Pending before it is deleted, 0 to disable. Must end with s, m or h.")
 	cmd.Flags().IntVar(&app.Config.Controller.TerraformMaxRetries, "terraform-max-retries", 5, "default number of retries for terraform actions (can be overriden in CRDs)")
+	cmd.Flags().IntVar(&app.Config.Controller.MaxRunDisruptions, "max-run-disruptions", 3, "number of times the runner pods of a run can be disrupted before a disruption counts as a failed attempt")
 	cmd.Flags().IntVar(&app.Config.Controller.MaxConcurrentReconciles, "max-concurrent-reconciles", 1, "maximum number of concurrent reconciles")
 	cmd.Flags().IntVar(&app.Config.Controller.MaxConcurrentRunnerPods, "max-concurrent-runner-pods", 0, "maximum number of concurrent runner pods")
 	cmd.Flags().BoolVar(&app.Config.Controller.LeaderElection.Enabled, "leader-election", true, "whether leader election is enabled or not, default to true")
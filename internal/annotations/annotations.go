@@ -2,6 +2,8 @@ package annotations
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -17,6 +19,35 @@ const (
 	LastPlanSum    string = "runner.terraform.padok.cloud/plan-sum"
 	LastPlanRun    string = "runner.terraform.padok.cloud/plan-run"
 	Lock           string = "runner.terraform.padok.cloud/lock"
+	// Runner pod whose command must be interrupted, set on a run whose attempt has timed out
+	InterruptRunner string = "runner.terraform.padok.cloud/interrupt"
+	// Date at which the runner has been asked to interrupt its command, its pod is deleted if it is still running after its termination grace period
+	InterruptDate string = "runner.terraform.padok.cloud/interrupt-date"
+	// How the deletions and replacements of the last plan must be handled
+	LastPlanDestructiveChanges string = "runner.terraform.padok.cloud/plan-destructive-changes"
+	// Last targeted plan, kept apart from the last plan of the whole layer until it is approved and applied
+	LastTargetedPlanRun                string = "runner.terraform.padok.cloud/targeted-plan-run"
+	LastTargetedPlanSum                string = "runner.terraform.padok.cloud/targeted-plan-sum"
+	LastTargetedPlanCommit             string = "runner.terraform.padok.cloud/targeted-plan-commit"
+	LastTargetedPlanDestructiveChanges string = "runner.terraform.padok.cloud/targeted-plan-destructive-changes"
+	// JSON lists of the resources the last targeted plan has been restricted to
+	LastPlanTargets string = "runner.terraform.padok.cloud/plan-targets"
+	LastPlanReplace string = "runner.terraform.padok.cloud/plan-replace"
+
+	LastDriftCheckDate   string = "runner.terraform.padok.cloud/drift-check-date"
+	LastDriftCheckRun    string = "runner.terraform.padok.cloud/drift-check-run"
+	LastDriftCheckResult string = "runner.terraform.padok.cloud/drift-check-result"
+
+	LastOutputsCommit string = "runner.terraform.padok.cloud/outputs-commit"
+	LastOutputsRun    string = "runner.terraform.padok.cloud/outputs-run"
+	// Run and attempt of the last apply which changed the outputs stored in the datastore
+	LastOutputsChange string = "runner.terraform.padok.cloud/outputs-change"
+	// Why the outputs of the last apply could not be published, unset once they are
+	LastOutputsError string = "runner.terraform.padok.cloud/outputs-error"
+	// Outputs change and layer generation last written to the Secret or ConfigMap of the layer
+	LastOutputsPublished string = "runner.terraform.padok.cloud/outputs-published"
+	// Outputs changes of the upstream layers used by the last plan, see ComputeInputs
+	LastPlanInputs string = "runner.terraform.padok.cloud/plan-inputs"
 
 	LastBranchCommit       string = "webhook.terraform.padok.cloud/branch-commit"
 	LastBranchCommitDate   string = "webhook.terraform.padok.cloud/branch-commit-date"
@@ -28,13 +59,45 @@ const (
 	AdditionnalTriggerPaths string = "config.terraform.padok.cloud/additionnal-trigger-paths"
 
 	SyncNow        string = "api.terraform.padok.cloud/sync-now"
+	ApprovePlan    string = "api.terraform.padok.cloud/approve-plan"
+	RejectPlan     string = "api.terraform.padok.cloud/reject-plan"
+	TriggeredBy    string = "api.terraform.padok.cloud/triggered-by"
+	PinnedRevision string = "api.terraform.padok.cloud/pinned-revision"
+	SuspendedBy    string = "api.terraform.padok.cloud/suspended-by"
+	SuspendReason  string = "api.terraform.padok.cloud/suspend-reason"
+	CancelRun      string = "api.terraform.padok.cloud/cancel"
 	AllowedTenants string = "credentials.terraform.padok.cloud/allowed-tenants"
+	// Targeted plan approved for apply, targeted plans are never applied without it
+	ApproveTargetedPlan string = "api.terraform.padok.cloud/approve-targeted-plan"
 )
 
+// TargetedPlan lists the annotations describing the last targeted plan of a
+// layer, removed once the plan has been applied or discarded
+var TargetedPlan = []string{
+	LastTargetedPlanRun,
+	LastTargetedPlanSum,
+	LastTargetedPlanCommit,
+	LastTargetedPlanDestructiveChanges,
+	LastPlanTargets,
+	LastPlanReplace,
+	ApproveTargetedPlan,
+}
+
 func ComputeKeyForSyncBranchNow(branch string) string {
 	return SyncBranchNow + strings.ReplaceAll(branch, "/", "--")
 }
 
+// ComputeInputs returns the value of the LastPlanInputs annotation of a layer
+// from the layers it uses the outputs of
+func ComputeInputs(upstreams []client.Object) string {
+	inputs := []string{}
+	for _, upstream := range upstreams {
+		inputs = append(inputs, fmt.Sprintf("%s/%s=%s", upstream.GetNamespace(), upstream.GetName(), upstream.GetAnnotations()[LastOutputsChange]))
+	}
+	sort.Strings(inputs)
+	return strings.Join(inputs, ",")
+}
+
 func Add(ctx context.Context, c client.Client, obj client.Object, annotations map[string]string) error {
 	newObj := obj.DeepCopyObject().(client.Object)
 	patch := client.MergeFrom(newObj)
@@ -51,10 +114,17 @@ func Add(ctx context.Context, c client.Client, obj client.Object, annotations ma
 }
 
 func Remove(ctx context.Context, c client.Client, obj client.Object, annotation string) error {
+	return RemoveAll(ctx, c, obj, annotation)
+}
+
+// RemoveAll removes the given annotations from the object in a single patch
+func RemoveAll(ctx context.Context, c client.Client, obj client.Object, keys ...string) error {
 	newObj := obj.DeepCopyObject().(client.Object)
 	patch := client.MergeFrom(newObj)
 	annotations := obj.GetAnnotations()
-	delete(annotations, annotation)
+	for _, key := range keys {
+		delete(annotations, key)
+	}
 	obj.SetAnnotations(annotations)
 	return c.Patch(ctx, obj, patch)
 }
@@ -60,6 +60,7 @@ type ControllerConfig struct {
 	Timers                  ControllerTimers            `mapstructure:"timers"`
 	DefaultSyncWindows      []configv1alpha1.SyncWindow `mapstructure:"defaultSyncWindows"`
 	TerraformMaxRetries     int                         `mapstructure:"terraformMaxRetries"`
+	MaxRunDisruptions       int                         `mapstructure:"maxRunDisruptions"`
 	Types                   []string                    `mapstructure:"types"`
 	LeaderElection          LeaderElectionConfig        `mapstructure:"leaderElection"`
 	MetricsBindAddress      string                      `mapstructure:"metricsBindAddress"`
@@ -82,12 +83,15 @@ type ControllerTimers struct {
 	FailureGracePeriod time.Duration `mapstructure:"failureGracePeriod"`
 	RepositorySync     time.Duration `mapstructure:"repositorySync"`
 	CredentialsTTL     time.Duration `mapstructure:"credentialsTTL"`
+	RunTimeout         time.Duration `mapstructure:"runTimeout"`
+	PendingTimeout     time.Duration `mapstructure:"pendingTimeout"`
 }
 
 type RunnerConfig struct {
 	Action                     string      `mapstructure:"action"`
 	Layer                      Layer       `mapstructure:"layer"`
 	Run                        string      `mapstructure:"run"`
+	Pod                        string      `mapstructure:"pod"`
 	SSHKnownHostsConfigMapName string      `mapstructure:"sshKnownHostsConfigMapName"`
 	Image                      ImageConfig `mapstructure:"image"`
 	RunnerBinaryPath           string      `mapstructure:"runnerBinaryPath"`
@@ -224,6 +228,7 @@ func TestConfig() *Config {
 	return &Config{
 		Controller: ControllerConfig{
 			TerraformMaxRetries:     5,
+			MaxRunDisruptions:       3,
 			MaxConcurrentReconciles: 1,
 			MaxConcurrentRunnerPods: 0,
 			Timers: ControllerTimers{
@@ -233,6 +238,8 @@ func TestConfig() *Config {
 				OnError:            1 * time.Minute,
 				RepositorySync:     5 * time.Minute,
 				CredentialsTTL:     5 * time.Second,
+				RunTimeout:         2 * time.Hour,
+				PendingTimeout:     10 * time.Minute,
 			},
 		},
 		Runner: RunnerConfig{
@@ -38,6 +38,7 @@ import (
 	logrusr "github.com/bombsimon/logrusr/v4"
 	"github.com/padok-team/burrito/internal/controllers/metrics"
 	"github.com/padok-team/burrito/internal/controllers/terraformlayer"
+	"github.com/padok-team/burrito/internal/controllers/terraformlayerset"
 	"github.com/padok-team/burrito/internal/controllers/terraformpullrequest"
 	"github.com/padok-team/burrito/internal/controllers/terraformrepository"
 	"github.com/padok-team/burrito/internal/controllers/terraformrun"
@@ -98,6 +99,13 @@ func (c *Controllers) Exec() {
 		Cache: cache.Options{
 			DefaultNamespaces: cacheNamespaces,
 		},
+		// ConfigMaps are read directly so that getting them only requires the
+		// get permission granted in the namespace of each tenant
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				DisableFor: []client.Object{&corev1.ConfigMap{}},
+			},
+		},
 	}
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
@@ -147,6 +155,17 @@ func (c *Controllers) Exec() {
 				log.Fatalf("unable to create layer controller: %s", err)
 			}
 			log.Infof("layer controller started successfully")
+		case "layerset":
+			if err = (&terraformlayerset.Reconciler{
+				Client:    mgr.GetClient(),
+				Scheme:    mgr.GetScheme(),
+				Config:    c.config,
+				Recorder:  mgr.GetEventRecorderFor("Burrito"),
+				Datastore: datastoreClient,
+			}).SetupWithManager(mgr); err != nil {
+				log.Fatalf("unable to create layerset controller: %s", err)
+			}
+			log.Infof("layerset controller started successfully")
 		case "repository":
 			if err = (&terraformrepository.Reconciler{
 				Client:      mgr.GetClient(),
@@ -12,7 +12,7 @@ func GetLayerStatus(layer configv1alpha1.TerraformLayer) string {
 	switch {
 	case len(layer.Status.Conditions) == 0:
 		state = "disabled"
-	case layer.Status.State == "ApplyNeeded":
+	case layer.Status.State == "ApplyNeeded" || layer.Status.State == "ApprovalPending":
 		if layer.Status.LastResult == "Plan: 0 to create, 0 to update, 0 to delete" {
 			state = "success"
 		} else {
@@ -20,6 +20,8 @@ func GetLayerStatus(layer configv1alpha1.TerraformLayer) string {
 		}
 	case layer.Status.State == "PlanNeeded":
 		state = "warning"
+	case layer.Status.State == "Suspended":
+		state = "disabled"
 	}
 
 	if layer.Annotations != nil {
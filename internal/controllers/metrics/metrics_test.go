@@ -101,6 +101,26 @@ var _ = Describe("Metrics", func() {
 			})
 		})
 
+		Context("when layer is in ApprovalPending state with changes", func() {
+			It("should return warning", func() {
+				layer := configv1alpha1.TerraformLayer{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{
+							"runner.terraform.padok.cloud/plan-sum": "abc123",
+						},
+					},
+					Status: configv1alpha1.TerraformLayerStatus{
+						Conditions: []metav1.Condition{
+							{Type: "Ready", Status: metav1.ConditionTrue},
+						},
+						State:      "ApprovalPending",
+						LastResult: "Plan: 1 to create, 0 to update, 0 to delete",
+					},
+				}
+				Expect(metrics.GetLayerStatus(layer)).To(Equal("warning"))
+			})
+		})
+
 		Context("when layer is in PlanNeeded state", func() {
 			It("should return warning", func() {
 				layer := configv1alpha1.TerraformLayer{
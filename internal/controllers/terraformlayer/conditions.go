@@ -10,9 +10,12 @@ import (
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"github.com/padok-team/burrito/internal/annotations"
 	terraformrun "github.com/padok-team/burrito/internal/controllers/terraformrun"
+	"github.com/padok-team/burrito/internal/policy"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type lastRunRetryInfo struct {
@@ -50,7 +53,7 @@ func (r *Reconciler) IsRunning(t *configv1alpha1.TerraformLayer) (metav1.Conditi
 		condition.Status = metav1.ConditionTrue
 		return condition, true
 	}
-	if run.Status.State != "Succeeded" && run.Status.State != "Failed" {
+	if run.Status.State != "Succeeded" && run.Status.State != "Failed" && run.Status.State != "Cancelled" {
 		condition.Reason = "RunStillRunning"
 		condition.Message = "The last run is still running"
 		condition.Status = metav1.ConditionTrue
@@ -62,7 +65,47 @@ func (r *Reconciler) IsRunning(t *configv1alpha1.TerraformLayer) (metav1.Conditi
 	return condition, false
 }
 
-func (r *Reconciler) IsLastPlanTooOld(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+// IsLastRunCancelled checks whether the last run of the layer has been
+// cancelled on the revision the layer runs on. The layer is then left
+// untouched until a new commit is received or a sync is triggered.
+func (r *Reconciler) IsLastRunCancelled(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsLastRunCancelled",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	if t.Status.LastRun.Name == "" {
+		condition.Reason = "NoRunHasRunYet"
+		condition.Message = "No run has run on this layer yet"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	run := configv1alpha1.TerraformRun{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{
+		Namespace: t.Namespace,
+		Name:      t.Status.LastRun.Name,
+	}, &run)
+	if err != nil {
+		condition.Reason = "RunRetrievalError"
+		condition.Message = "Could not fetch the last run, considering it has not been cancelled"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	revision, _ := GetRevision(t)
+	if run.Status.State != "Cancelled" || run.Spec.Layer.Revision != revision {
+		condition.Reason = "LastRunNotCancelled"
+		condition.Message = "The last run on the current revision has not been cancelled"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "LastRunCancelled"
+	condition.Message = fmt.Sprintf("The last run %s has been cancelled by %s", run.Name, run.Annotations[annotations.CancelRun])
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
+func (r *Reconciler) IsLastPlanTooOld(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (metav1.Condition, bool) {
 	condition := metav1.Condition{
 		Type:               "IsLastPlanTooOld",
 		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
@@ -76,6 +119,12 @@ func (r *Reconciler) IsLastPlanTooOld(t *configv1alpha1.TerraformLayer) (metav1.
 		condition.Status = metav1.ConditionTrue
 		return condition, true
 	}
+	if !*configv1alpha1.GetDriftDetection(repo, t).Enabled {
+		condition.Reason = "DriftDetectionDisabled"
+		condition.Message = "Drift detection is disabled on this layer, it is only planned on new commits"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
 	lastPlanDate, err := time.Parse(time.UnixDate, value)
 	if err != nil {
 		condition.Reason = "ParseError"
@@ -83,16 +132,103 @@ func (r *Reconciler) IsLastPlanTooOld(t *configv1alpha1.TerraformLayer) (metav1.
 		condition.Status = metav1.ConditionFalse
 		return condition, false
 	}
-	nextPlanDate := lastPlanDate.Add(r.Config.Controller.Timers.DriftDetection)
+	nextPlanDate, err := r.getNextDriftDetection(t, repo, lastPlanDate)
+	if err != nil {
+		condition.Reason = "InvalidDriftDetection"
+		condition.Message = fmt.Sprintf("Burrito could not compute the next plan date, considering plan is recent to lock the behavior: %s", err)
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
 	now := r.Clock.Now()
 	if nextPlanDate.After(now) {
 		condition.Reason = "PlanIsRecent"
-		condition.Message = fmt.Sprintf("The plan has been made less than %s ago.", r.Config.Controller.Timers.DriftDetection)
+		condition.Message = fmt.Sprintf("The next plan is scheduled on %s.", nextPlanDate.Format(time.UnixDate))
 		condition.Status = metav1.ConditionFalse
 		return condition, false
 	}
 	condition.Reason = "PlanIsTooOld"
-	condition.Message = fmt.Sprintf("The plan has been made more than %s ago.", r.Config.Controller.Timers.DriftDetection)
+	condition.Message = fmt.Sprintf("The plan was due on %s.", nextPlanDate.Format(time.UnixDate))
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
+func (r *Reconciler) IsLastDriftCheckTooOld(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsLastDriftCheckTooOld",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	if !*configv1alpha1.GetDriftDetection(repo, t).Enabled {
+		condition.Reason = "DriftDetectionDisabled"
+		condition.Message = "Drift detection is disabled on this layer"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	if configv1alpha1.GetTerragruntStackEnabled(repo, t) {
+		condition.Reason = "DriftCheckNotSupported"
+		condition.Message = "Drift checks are not supported for terragrunt stacks, the layer is only planned"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	value, ok := t.Annotations[annotations.LastDriftCheckDate]
+	if !ok {
+		condition.Reason = "NoDriftCheckHasRunYet"
+		condition.Message = "No drift check has run on this layer yet"
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	lastDriftCheckDate, err := time.Parse(time.UnixDate, value)
+	if err != nil {
+		condition.Reason = "ParseError"
+		condition.Message = "Burrito could not parse the time from the annotation, this is likely a bug, considering drift check is recent to lock the behavior"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	nextDriftCheckDate, err := r.getNextDriftDetection(t, repo, lastDriftCheckDate)
+	if err != nil {
+		condition.Reason = "InvalidDriftDetection"
+		condition.Message = fmt.Sprintf("Burrito could not compute the next drift check date, considering drift check is recent to lock the behavior: %s", err)
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	now := r.Clock.Now()
+	if nextDriftCheckDate.After(now) {
+		condition.Reason = "DriftCheckIsRecent"
+		condition.Message = fmt.Sprintf("The next drift check is scheduled on %s.", nextDriftCheckDate.Format(time.UnixDate))
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "DriftCheckIsTooOld"
+	condition.Message = fmt.Sprintf("The drift check was due on %s.", nextDriftCheckDate.Format(time.UnixDate))
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
+// HasDrifted reports the result of the last refresh-only drift check, which
+// only detects changes made outside of Terraform, not unapplied code changes.
+func (r *Reconciler) HasDrifted(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "HasDrifted",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	value, ok := t.Annotations[annotations.LastDriftCheckResult]
+	if !ok {
+		condition.Reason = "NoDriftCheckHasRunYet"
+		condition.Message = "No drift check has run on this layer yet"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	if value != "true" {
+		condition.Reason = "NoDriftDetected"
+		condition.Message = fmt.Sprintf("Drift check %s found no resources changed outside of Terraform", t.Annotations[annotations.LastDriftCheckRun])
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "DriftDetected"
+	condition.Message = fmt.Sprintf("Drift check %s found resources changed outside of Terraform", t.Annotations[annotations.LastDriftCheckRun])
 	condition.Status = metav1.ConditionTrue
 	return condition, true
 }
@@ -137,6 +273,18 @@ func (r *Reconciler) IsLastRelevantCommitPlanned(t *configv1alpha1.TerraformLaye
 		condition.Status = metav1.ConditionTrue
 		return condition, true
 	}
+	if pinnedRevision, ok := t.Annotations[annotations.PinnedRevision]; ok {
+		if lastPlannedCommit == pinnedRevision {
+			condition.Reason = "PinnedRevisionPlanned"
+			condition.Message = fmt.Sprintf("The layer is pinned to revision %s, which has already been planned", pinnedRevision)
+			condition.Status = metav1.ConditionTrue
+			return condition, true
+		}
+		condition.Reason = "PinnedRevisionNotPlanned"
+		condition.Message = fmt.Sprintf("The layer is pinned to revision %s, which has not been planned yet", pinnedRevision)
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
 	lastBranchCommit, ok := t.Annotations[annotations.LastBranchCommit]
 	if !ok {
 		condition.Reason = "NoCommitReceived"
@@ -190,6 +338,82 @@ func (r *Reconciler) IsLastRelevantCommitPlanned(t *configv1alpha1.TerraformLaye
 	return condition, false
 }
 
+// IsRevisionPinned reports whether the layer has been rolled back to an older
+// revision, in which case the new commits of its branch are not planned
+func (r *Reconciler) IsRevisionPinned(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsRevisionPinned",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	pinnedRevision, ok := t.Annotations[annotations.PinnedRevision]
+	if !ok {
+		condition.Reason = "NoRevisionPinned"
+		condition.Message = "The layer runs on the last commit of its branch"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "RevisionPinned"
+	condition.Message = fmt.Sprintf("The layer has been rolled back to revision %s, new commits are not planned until the pin is removed", pinnedRevision)
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
+// AreOutputsPublished reports whether the outputs of the last apply have been
+// published. It does not change the state of the layer, the apply itself
+// succeeded.
+func (r *Reconciler) AreOutputsPublished(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "AreOutputsPublished",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	if message, ok := t.Annotations[annotations.LastOutputsError]; ok {
+		condition.Reason = "OutputsPublicationFailed"
+		condition.Message = message
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	if _, ok := t.Annotations[annotations.LastApplySum]; !ok {
+		condition.Reason = "NoApplyHasRunYet"
+		condition.Message = "No apply has run on this layer yet"
+		return condition, false
+	}
+	condition.Reason = "OutputsPublished"
+	condition.Message = "The outputs of the last apply have been published"
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
+// IsSuspended reports whether the layer or its repository is suspended, in
+// which case no run is created for the layer
+func (r *Reconciler) IsSuspended(t *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsSuspended",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	switch {
+	case t.Spec.Suspend:
+		condition.Reason = "LayerSuspended"
+		condition.Message = "The layer is suspended"
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	case repo.Spec.Suspend:
+		condition.Reason = "RepositorySuspended"
+		condition.Message = fmt.Sprintf("The repository %s of the layer is suspended", repo.Name)
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	condition.Reason = "NotSuspended"
+	condition.Message = "The layer is not suspended"
+	condition.Status = metav1.ConditionFalse
+	return condition, false
+}
+
 func (r *Reconciler) IsApplyUpToDate(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
 	condition := metav1.Condition{
 		Type:               "IsApplyUpToDate",
@@ -304,7 +528,7 @@ func (r *Reconciler) HasLastRunReachedRetryLimit(layer *configv1alpha1.Terraform
 		condition.Status = metav1.ConditionFalse
 		return condition, lastRunRetryInfo{action: run.Spec.Action}
 	}
-	maxRetries := terraformrun.GetMaxRetries(r.Config.Controller.TerraformMaxRetries, repo, layer)
+	maxRetries := terraformrun.GetRunMaxRetries(r.Config.Controller.TerraformMaxRetries, &run, repo, layer)
 	if run.Status.Retries < maxRetries {
 		condition.Reason = "RetryLimitNotReached"
 		condition.Message = "The last run has not reached the retry limit"
@@ -312,6 +536,9 @@ func (r *Reconciler) HasLastRunReachedRetryLimit(layer *configv1alpha1.Terraform
 		return condition, lastRunRetryInfo{action: run.Spec.Action}
 	}
 	currentRevision := layer.Annotations[annotations.LastRelevantCommit]
+	if pinnedRevision, ok := layer.Annotations[annotations.PinnedRevision]; ok {
+		currentRevision = pinnedRevision
+	}
 	if currentRevision != "" && run.Spec.Layer.Revision != currentRevision {
 		condition.Reason = "NewRevisionAvailable"
 		condition.Message = "The last run reached retry limit but a new revision is available"
@@ -324,6 +551,238 @@ func (r *Reconciler) HasLastRunReachedRetryLimit(layer *configv1alpha1.Terraform
 	return condition, lastRunRetryInfo{reachedLimit: true, action: run.Spec.Action}
 }
 
+func (r *Reconciler) IsLastPlanRejected(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsLastPlanRejected",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	rejectedPlan, ok := t.Annotations[annotations.RejectPlan]
+	if !ok {
+		condition.Reason = "NoPlanRejected"
+		condition.Message = "No plan has been rejected on this layer"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	if rejectedPlan != t.Annotations[annotations.LastPlanRun] {
+		condition.Reason = "RejectedPlanReplaced"
+		condition.Message = fmt.Sprintf("Plan %s has been rejected but a newer plan is available", rejectedPlan)
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "LastPlanRejected"
+	condition.Message = fmt.Sprintf("Plan %s has been rejected, waiting for a new plan", rejectedPlan)
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
+// IsTargetedPlanApproved returns whether the last targeted plan of the layer
+// has been approved. Targeted plans are only applied once approved.
+func (r *Reconciler) IsTargetedPlanApproved(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsTargetedPlanApproved",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	targetedPlan, ok := t.Annotations[annotations.LastTargetedPlanRun]
+	if !ok {
+		condition.Reason = "NoTargetedPlan"
+		condition.Message = "No targeted plan is waiting to be applied on this layer"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	if t.Annotations[annotations.ApproveTargetedPlan] != targetedPlan {
+		condition.Reason = "TargetedPlanNotApproved"
+		condition.Message = fmt.Sprintf("Targeted plan %s is waiting for a manual approval", targetedPlan)
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "TargetedPlanApproved"
+	condition.Message = fmt.Sprintf("Targeted plan %s has been approved, it will be applied", targetedPlan)
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
+// IsLastPlanDenied returns whether the policies deny the apply of the last
+// plan. The verdict is computed by the controller and kept in the status of
+// the layer, a plan whose policies have not been evaluated cannot be applied.
+func (r *Reconciler) IsLastPlanDenied(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsLastPlanDenied",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	planRun, ok := t.Annotations[annotations.LastPlanRun]
+	if !ok {
+		condition.Reason = "NoPlanHasRunYet"
+		condition.Message = "No plan has run yet"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	if t.Status.Policy.Run != planRun || t.Status.Policy.Sum != t.Annotations[annotations.LastPlanSum] {
+		condition.Reason = "PolicyNotEvaluated"
+		condition.Message = fmt.Sprintf("Policies have not been evaluated against plan %s yet", planRun)
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	switch t.Status.Policy.Result {
+	case policy.StatusDenied:
+		condition.Reason = "PolicyDenied"
+		condition.Message = fmt.Sprintf("Plan %s has been denied by a policy, waiting for a new plan", planRun)
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	case policy.StatusWarned:
+		condition.Reason = "PolicyWarned"
+		condition.Message = "Policies reported warnings on the last plan"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	case policy.StatusPassed:
+		condition.Reason = "PolicyPassed"
+		condition.Message = "The last plan complies with all the policies"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "NoPolicy"
+	condition.Message = "No policy applies to the layer"
+	condition.Status = metav1.ConditionFalse
+	return condition, false
+}
+
+// IsLastPlanDestructive returns how the deletions and replacements of the
+// last plan must be handled, as checked by the runner
+func (r *Reconciler) IsLastPlanDestructive(t *configv1alpha1.TerraformLayer) (metav1.Condition, string) {
+	condition := metav1.Condition{
+		Type:               "IsLastPlanDestructive",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	destructiveChanges := t.Annotations[annotations.LastPlanDestructiveChanges]
+	switch destructiveChanges {
+	case runnerutils.DestructiveChangesDenied:
+		condition.Reason = "DestructiveChangesDenied"
+		condition.Message = fmt.Sprintf("Plan %s deletes or replaces resources and cannot be applied, waiting for a new plan", t.Annotations[annotations.LastPlanRun])
+		condition.Status = metav1.ConditionTrue
+	case runnerutils.DestructiveChangesApprovalRequired:
+		condition.Reason = "DestructiveChangesRequireApproval"
+		condition.Message = fmt.Sprintf("Plan %s deletes or replaces resources and must be manually approved", t.Annotations[annotations.LastPlanRun])
+		condition.Status = metav1.ConditionTrue
+	case runnerutils.DestructiveChangesAllowed:
+		condition.Reason = "DestructiveChangesAllowed"
+		condition.Message = "The last plan deletes or replaces resources, which is allowed"
+		condition.Status = metav1.ConditionFalse
+	default:
+		condition.Reason = "NoDestructiveChanges"
+		condition.Message = "The last plan does not delete or replace any resource"
+		condition.Status = metav1.ConditionFalse
+		destructiveChanges = runnerutils.DestructiveChangesNone
+	}
+	return condition, destructiveChanges
+}
+
+func (r *Reconciler) AreDependenciesReady(t *configv1alpha1.TerraformLayer) (metav1.Condition, dependenciesInfo) {
+	condition := metav1.Condition{
+		Type:               "DependenciesReady",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	dependencies := getDependencies(t)
+	if len(dependencies) == 0 {
+		condition.Reason = "NoDependencies"
+		condition.Message = "This layer does not depend on other layers"
+		condition.Status = metav1.ConditionTrue
+		return condition, dependenciesInfo{ready: true}
+	}
+	cycle, err := r.findDependencyCycle(context.TODO(), t)
+	if err != nil {
+		condition.Reason = "DependencyRetrievalError"
+		condition.Message = fmt.Sprintf("Could not fetch the dependencies of this layer: %s", err)
+		condition.Status = metav1.ConditionFalse
+		return condition, dependenciesInfo{}
+	}
+	if cycle != nil {
+		condition.Reason = "DependencyCycle"
+		condition.Message = fmt.Sprintf("Dependency cycle detected: %s", strings.Join(cycle, " -> "))
+		condition.Status = metav1.ConditionFalse
+		return condition, dependenciesInfo{cycle: cycle}
+	}
+	notReady := []string{}
+	for _, dependency := range dependencies {
+		key := getDependencyKey(t, dependency)
+		upstream := &configv1alpha1.TerraformLayer{}
+		err := r.Client.Get(context.TODO(), key, upstream)
+		if err != nil {
+			condition.Reason = "DependencyRetrievalError"
+			condition.Message = fmt.Sprintf("Could not fetch dependency %s: %s", key, err)
+			condition.Status = metav1.ConditionFalse
+			return condition, dependenciesInfo{}
+		}
+		if ready, reason := isUpstreamReady(t, upstream); !ready {
+			notReady = append(notReady, fmt.Sprintf("%s %s", key, reason))
+		}
+	}
+	if len(notReady) > 0 {
+		condition.Reason = "DependenciesNotReady"
+		condition.Message = fmt.Sprintf("Waiting for dependencies: %s", strings.Join(notReady, ", "))
+		condition.Status = metav1.ConditionFalse
+		return condition, dependenciesInfo{}
+	}
+	condition.Reason = "DependenciesReady"
+	condition.Message = "All dependencies have been applied"
+	condition.Status = metav1.ConditionTrue
+	return condition, dependenciesInfo{ready: true}
+}
+
+func (r *Reconciler) AreInputsUpToDate(t *configv1alpha1.TerraformLayer) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "AreInputsUpToDate",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	if len(t.Spec.InputsFrom) == 0 {
+		condition.Reason = "NoInputs"
+		condition.Message = "This layer does not use the outputs of other layers"
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	plannedInputs, ok := t.Annotations[annotations.LastPlanInputs]
+	if !ok {
+		condition.Reason = "NoPlanHasRunYet"
+		condition.Message = "No plan has run with the inputs of this layer yet"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	upstreams := []client.Object{}
+	for _, input := range t.Spec.InputsFrom {
+		key := getDependencyKey(t, input.Layer)
+		upstream := &configv1alpha1.TerraformLayer{}
+		err := r.Client.Get(context.TODO(), key, upstream)
+		if err != nil {
+			// The upstream layers are dependencies of the layer, the plan
+			// waits for them to be ready again
+			condition.Reason = "InputRetrievalError"
+			condition.Message = fmt.Sprintf("Could not fetch upstream layer %s, the inputs of the last plan cannot be checked: %s", key, err)
+			return condition, false
+		}
+		upstreams = append(upstreams, upstream)
+	}
+	if annotations.ComputeInputs(upstreams) != plannedInputs {
+		condition.Reason = "InputsChanged"
+		condition.Message = "The outputs of an upstream layer have changed since the last plan"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "InputsUpToDate"
+	condition.Message = "The last plan used the latest outputs of the upstream layers"
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
 func LayerFilesHaveChanged(layer configv1alpha1.TerraformLayer, changedFiles []string) bool {
 	if len(changedFiles) == 0 {
 		return true
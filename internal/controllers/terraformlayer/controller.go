@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/padok-team/burrito/internal/annotations"
 	"github.com/padok-team/burrito/internal/burrito/config"
 	"github.com/padok-team/burrito/internal/controllers/metrics"
 	datastore "github.com/padok-team/burrito/internal/datastore/client"
@@ -35,8 +36,11 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 )
@@ -51,6 +55,10 @@ func (c RealClock) Now() time.Time {
 	return time.Now()
 }
 
+// DestroyFinalizer is set on layers with the Destroy deletion policy, it is
+// removed once the infrastructure of the layer has been destroyed
+const DestroyFinalizer = "config.terraform.padok.cloud/destroy"
+
 // Reconciler reconciles a TerraformLayer object
 type Reconciler struct {
 	client.Client
@@ -64,6 +72,7 @@ type Reconciler struct {
 //+kubebuilder:rbac:groups=config.terraform.padok.cloud,resources=terraformlayers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=config.terraform.padok.cloud,resources=terraformlayers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=config.terraform.padok.cloud,resources=terraformlayers/finalizers,verbs=update
+//+kubebuilder:rbac:groups=config.terraform.padok.cloud,resources=terraformpolicies,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -83,6 +92,20 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		log.Errorf("failed to get TerraformLayer: %s", err)
 		return ctrl.Result{}, err
 	}
+	if !layer.DeletionTimestamp.IsZero() && !controllerutil.ContainsFinalizer(layer, DestroyFinalizer) {
+		log.Infof("layer %s is being deleted, ignoring", layer.Name)
+		return ctrl.Result{}, nil
+	}
+	err = r.reconcileFinalizer(ctx, layer)
+	if err != nil {
+		r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Could not update layer finalizers: %s", err)
+		log.Errorf("could not update finalizers of layer %s: %s", layer.Name, err)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, err
+	}
+	if !layer.DeletionTimestamp.IsZero() && !controllerutil.ContainsFinalizer(layer, DestroyFinalizer) {
+		log.Infof("layer %s is being deleted with the %s deletion policy, infrastructure is left untouched", layer.Name, configv1alpha1.DeletionPolicyOrphan)
+		return ctrl.Result{}, nil
+	}
 	locked, err := lock.IsLayerLocked(ctx, r.Client, layer)
 	if err != nil {
 		log.Errorf("failed to get Lease Resource: %s", err)
@@ -111,6 +134,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", err.Error())
 		return ctrl.Result{}, err
 	}
+	err = r.publishOutputs(ctx, layer)
+	if err != nil {
+		r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Could not publish outputs: %s", err)
+		log.Errorf("could not publish outputs of layer %s: %s", layer.Name, err)
+	}
+	err = r.evaluatePolicies(ctx, layer, repository)
+	if err != nil {
+		r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Could not evaluate policies: %s", err)
+		log.Errorf("could not evaluate policies of layer %s: %s", layer.Name, err)
+	}
+	// Handlers may refresh the layer, dropping the verdict from its status
+	policyStatus := layer.Status.Policy
 	state, conditions := r.GetState(ctx, layer, repository)
 	lastResult := []byte("Layer has never been planned")
 	if layer.Status.LastRun.Name != "" {
@@ -122,13 +157,27 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		}
 	}
 	result, run := state.getHandler()(ctx, r, layer, repository)
+	if !layer.DeletionTimestamp.IsZero() && !controllerutil.ContainsFinalizer(layer, DestroyFinalizer) {
+		log.Infof("layer %s has been destroyed, finished reconciliation", layer.Name)
+		return result, nil
+	}
 	lastRun := layer.Status.LastRun
 	runHistory := layer.Status.LatestRuns
+	keepLastRuns := *configv1alpha1.GetRunHistoryPolicy(repository, layer).KeepLastRuns
 	if run != nil {
 		lastRun = getRun(*run)
-		runHistory = updateLatestRuns(runHistory, *run, *configv1alpha1.GetRunHistoryPolicy(repository, layer).KeepLastRuns)
+		runHistory = updateLatestRuns(runHistory, *run, keepLastRuns)
+	}
+	runHistory, err = r.addTriggeredRuns(ctx, layer, runHistory, keepLastRuns)
+	if err != nil {
+		log.Warningf("failed to get runs triggered from the API for layer %s: %s", layer.Name, err)
 	}
-	layer.Status = configv1alpha1.TerraformLayerStatus{Conditions: conditions, State: getStateString(state), LastResult: string(lastResult), LastRun: lastRun, LatestRuns: runHistory}
+	pendingApproval := configv1alpha1.TerraformLayerPendingApproval{}
+	if _, ok := state.(*ApprovalPending); ok && run == nil {
+		pendingApproval = getPendingApproval(layer)
+	}
+	_, hasDrifted := r.HasDrifted(layer)
+	layer.Status = configv1alpha1.TerraformLayerStatus{Conditions: conditions, State: getStateString(state), LastResult: string(lastResult), LastRun: lastRun, LatestRuns: runHistory, PendingApproval: pendingApproval, HasDrifted: hasDrifted, Outputs: getOutputsStatus(layer), Policy: policyStatus}
 	err = r.Client.Status().Update(ctx, layer)
 	if err != nil {
 		r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Could not update layer status")
@@ -145,6 +194,15 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	return result, nil
 }
 
+// getOutputsStatus returns the last revision whose outputs have been
+// written to the Secret or ConfigMap of the layer
+func getOutputsStatus(layer *configv1alpha1.TerraformLayer) configv1alpha1.TerraformLayerOutputsStatus {
+	return configv1alpha1.TerraformLayerOutputsStatus{
+		Commit: layer.Annotations[annotations.LastOutputsCommit],
+		Run:    layer.Annotations[annotations.LastOutputsRun],
+	}
+}
+
 func (r *Reconciler) cleanupRuns(ctx context.Context, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) error {
 	historyPolicy := configv1alpha1.GetRunHistoryPolicy(repository, layer)
 	runs, err := r.getAllRuns(ctx, layer)
@@ -178,10 +236,47 @@ func (r *Reconciler) cleanupRuns(ctx context.Context, layer *configv1alpha1.Terr
 	return nil
 }
 
+// addTriggeredRuns adds the runs triggered from the API to the run history of
+// the layer, so that they are not cleaned up as old runs. They are not
+// considered as the last run of the layer since they may be targeted.
+func (r *Reconciler) addTriggeredRuns(ctx context.Context, layer *configv1alpha1.TerraformLayer, runHistory []configv1alpha1.TerraformLayerRun, keep int) ([]configv1alpha1.TerraformLayerRun, error) {
+	runs, err := r.getAllRuns(ctx, layer)
+	if err != nil {
+		return runHistory, err
+	}
+	inHistory := map[string]bool{}
+	for _, run := range runHistory {
+		inHistory[run.Name] = true
+	}
+	for _, run := range runs {
+		if _, ok := run.Annotations[annotations.TriggeredBy]; !ok || inHistory[run.Name] {
+			continue
+		}
+		runHistory = updateLatestRuns(runHistory, *run, keep)
+	}
+	return runHistory, nil
+}
+
+// reconcileFinalizer makes sure that only layers with the Destroy deletion
+// policy have the destroy finalizer
+func (r *Reconciler) reconcileFinalizer(ctx context.Context, layer *configv1alpha1.TerraformLayer) error {
+	destroy := layer.Spec.DeletionPolicy == configv1alpha1.DeletionPolicyDestroy
+	hasFinalizer := controllerutil.ContainsFinalizer(layer, DestroyFinalizer)
+	switch {
+	case destroy && !hasFinalizer && layer.DeletionTimestamp.IsZero():
+		controllerutil.AddFinalizer(layer, DestroyFinalizer)
+	case !destroy && hasFinalizer:
+		controllerutil.RemoveFinalizer(layer, DestroyFinalizer)
+	default:
+		return nil
+	}
+	return r.Client.Update(ctx, layer)
+}
+
 func getRun(run configv1alpha1.TerraformRun) configv1alpha1.TerraformLayerRun {
 	return configv1alpha1.TerraformLayerRun{
 		Name:   run.Name,
-		Commit: "",
+		Commit: run.Spec.Layer.Revision,
 		Date:   run.CreationTimestamp,
 		Action: run.Spec.Action,
 	}
@@ -212,10 +307,29 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&configv1alpha1.TerraformLayer{}).
 		WithOptions(controller.Options{MaxConcurrentReconciles: r.Config.Controller.MaxConcurrentReconciles}).
+		// The verdict of the last plan of the layers must be updated when
+		// their policies change
+		Watches(&configv1alpha1.TerraformPolicy{}, handler.EnqueueRequestsFromMapFunc(r.getPolicyLayers)).
 		WithEventFilter(ignorePredicate()).
 		Complete(r)
 }
 
+// getPolicyLayers returns the layers of the namespace of a policy, which may
+// be selected by the policy
+func (r *Reconciler) getPolicyLayers(ctx context.Context, obj client.Object) []reconcile.Request {
+	layers := &configv1alpha1.TerraformLayerList{}
+	err := r.Client.List(ctx, layers, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		log.Errorf("failed to list the layers of policy %s/%s: %s", obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, layer := range layers.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: layer.Namespace, Name: layer.Name}})
+	}
+	return requests
+}
+
 func ignorePredicate() predicate.Predicate {
 	return predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
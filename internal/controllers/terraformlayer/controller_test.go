@@ -8,6 +8,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/padok-team/burrito/internal/annotations"
 	"github.com/padok-team/burrito/internal/burrito/config"
 	"github.com/padok-team/burrito/internal/lock"
 
@@ -16,6 +17,7 @@ import (
 	datastore "github.com/padok-team/burrito/internal/datastore/client"
 	utils "github.com/padok-team/burrito/internal/testing"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
@@ -208,8 +210,13 @@ var _ = Describe("Layer", func() {
 			It("should not return an error", func() {
 				Expect(reconcileError).NotTo(HaveOccurred())
 			})
-			It("should end in ApplyNeeded state", func() {
-				Expect(layer.Status.State).To(Equal("ApplyNeeded"))
+			It("should end in ApprovalPending state", func() {
+				Expect(layer.Status.State).To(Equal("ApprovalPending"))
+			})
+			It("should reference the plan waiting for approval", func() {
+				Expect(layer.Status.PendingApproval.Run).To(Equal("run-succeeded"))
+				Expect(layer.Status.PendingApproval.Attempt).To(Equal("0"))
+				Expect(layer.Status.PendingApproval.Sum).To(Equal("AuP6pMNxWsbSZKnxZvxD842wy0qaF9JCX8HW1nFeL1I="))
 			})
 			It("should set RequeueAfter to DriftDetection", func() {
 				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.DriftDetection))
@@ -423,8 +430,8 @@ var _ = Describe("Layer", func() {
 		It("should end in Idle state", func() {
 			Expect(layer.Status.State).To(Equal("Idle"))
 		})
-		It("should set RequeueAfter to DriftDetection", func() {
-			Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.DriftDetection))
+		It("should set RequeueAfter to the time left before the next plan", func() {
+			Expect(result.RequeueAfter).To(Equal(14 * time.Minute))
 		})
 	})
 	Describe("When a TerraformLayer has errored on apply and was done before current plan", Ordered, func() {
@@ -483,6 +490,222 @@ var _ = Describe("Layer", func() {
 			})
 		})
 	})
+	Describe("Approval cases", func() {
+		Describe("When the pending plan of a TerraformLayer has been approved", Ordered, func() {
+			BeforeAll(func() {
+				name = types.NamespacedName{
+					Name:      "approval-case-1",
+					Namespace: "default",
+				}
+				result, layer, reconcileError, err = getResult(name, reconciler)
+			})
+			It("should still exists", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should not return an error", func() {
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in ApprovalPending state", func() {
+				Expect(layer.Status.State).To(Equal("ApprovalPending"))
+			})
+			It("should set RequeueAfter to WaitAction", func() {
+				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.WaitAction))
+			})
+			It("should have created an apply TerraformRun bound to the approved plan", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(runs.Items)).To(Equal(1))
+				Expect(runs.Items[0].Spec.Action).To(Equal("apply"))
+				Expect(runs.Items[0].Spec.Artifact.Run).To(Equal("run-succeeded"))
+				Expect(runs.Items[0].Spec.Artifact.Attempt).To(Equal("0"))
+				Expect(runs.Items[0].Spec.Layer.Revision).To(Equal("ca9b6c80ac8fb5cd837ae9b374b79ff33f472558"))
+			})
+			It("should have removed the approval annotation", func() {
+				Expect(layer.Annotations).NotTo(HaveKey(annotations.ApprovePlan))
+			})
+		})
+		Describe("When a TerraformLayer has been approved on a plan that has since been replaced", Ordered, func() {
+			BeforeAll(func() {
+				name = types.NamespacedName{
+					Name:      "approval-case-2",
+					Namespace: "default",
+				}
+				result, layer, reconcileError, err = getResult(name, reconciler)
+			})
+			It("should still exists", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should not return an error", func() {
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in ApprovalPending state", func() {
+				Expect(layer.Status.State).To(Equal("ApprovalPending"))
+			})
+			It("should set RequeueAfter to DriftDetection", func() {
+				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.DriftDetection))
+			})
+			It("should not have created an apply TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(runs.Items)).To(Equal(0))
+			})
+			It("should have removed the stale approval annotation", func() {
+				Expect(layer.Annotations).NotTo(HaveKey(annotations.ApprovePlan))
+			})
+		})
+		Describe("When the pending plan of a TerraformLayer has been rejected", Ordered, func() {
+			BeforeAll(func() {
+				name = types.NamespacedName{
+					Name:      "approval-case-3",
+					Namespace: "default",
+				}
+				result, layer, reconcileError, err = getResult(name, reconciler)
+			})
+			It("should still exists", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should not return an error", func() {
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in Idle state", func() {
+				Expect(layer.Status.State).To(Equal("Idle"))
+			})
+			It("should set RequeueAfter to DriftDetection", func() {
+				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.DriftDetection))
+			})
+			It("should not have created an apply TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(runs.Items)).To(Equal(0))
+			})
+		})
+	})
+	Describe("Drift cases", func() {
+		Describe("When a TerraformLayer hasn't been checked for drift since more time than the DriftDetection period", Ordered, func() {
+			BeforeAll(func() {
+				name = types.NamespacedName{
+					Name:      "drift-case-1",
+					Namespace: "default",
+				}
+				result, layer, reconcileError, err = getResult(name, reconciler)
+			})
+			It("should still exists", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should not return an error", func() {
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in DriftCheckNeeded state", func() {
+				Expect(layer.Status.State).To(Equal("DriftCheckNeeded"))
+			})
+			It("should set RequeueAfter to WaitAction", func() {
+				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.WaitAction))
+			})
+			It("should have created a drift-check TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(runs.Items)).To(Equal(1))
+				Expect(runs.Items[0].Spec.Action).To(Equal("drift-check"))
+			})
+		})
+		Describe("When the last drift check of a TerraformLayer has detected a drift", Ordered, func() {
+			BeforeAll(func() {
+				name = types.NamespacedName{
+					Name:      "drift-case-2",
+					Namespace: "default",
+				}
+				result, layer, reconcileError, err = getResult(name, reconciler)
+			})
+			It("should still exists", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should not return an error", func() {
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in Idle state", func() {
+				Expect(layer.Status.State).To(Equal("Idle"))
+			})
+			It("should have a HasDrifted condition set to true", func() {
+				condition := meta.FindStatusCondition(layer.Status.Conditions, "HasDrifted")
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				Expect(condition.Reason).To(Equal("DriftDetected"))
+			})
+			It("should report the drift in its status", func() {
+				Expect(layer.Status.HasDrifted).To(BeTrue())
+			})
+			It("should set RequeueAfter to DriftDetection", func() {
+				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.DriftDetection))
+			})
+		})
+	})
+	Describe("Dependency cases", func() {
+		Describe("When a TerraformLayer depends on a layer that has not been applied", Ordered, func() {
+			BeforeAll(func() {
+				name = types.NamespacedName{
+					Name:      "dependency-case-1",
+					Namespace: "default",
+				}
+				result, layer, reconcileError, err = getResult(name, reconciler)
+			})
+			It("should still exists", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should not return an error", func() {
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in DependenciesPending state", func() {
+				Expect(layer.Status.State).To(Equal("DependenciesPending"))
+			})
+			It("should have a DependenciesReady condition set to false", func() {
+				condition := meta.FindStatusCondition(layer.Status.Conditions, "DependenciesReady")
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+				Expect(condition.Reason).To(Equal("DependenciesNotReady"))
+			})
+			It("should set RequeueAfter to WaitAction", func() {
+				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.WaitAction))
+			})
+			It("should not have created a TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(runs.Items)).To(Equal(0))
+			})
+		})
+		Describe("When a TerraformLayer is part of a dependency cycle", Ordered, func() {
+			BeforeAll(func() {
+				name = types.NamespacedName{
+					Name:      "dependency-case-2",
+					Namespace: "default",
+				}
+				result, layer, reconcileError, err = getResult(name, reconciler)
+			})
+			It("should still exists", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should not return an error", func() {
+				Expect(reconcileError).NotTo(HaveOccurred())
+			})
+			It("should end in DependencyCycle state", func() {
+				Expect(layer.Status.State).To(Equal("DependencyCycle"))
+			})
+			It("should report the cycle in the DependenciesReady condition", func() {
+				condition := meta.FindStatusCondition(layer.Status.Conditions, "DependenciesReady")
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+				Expect(condition.Reason).To(Equal("DependencyCycle"))
+				Expect(condition.Message).To(ContainSubstring("default/dependency-case-2 -> default/dependency-case-3 -> default/dependency-case-2"))
+			})
+			It("should set RequeueAfter to DriftDetection", func() {
+				Expect(result.RequeueAfter).To(Equal(reconciler.Config.Controller.Timers.DriftDetection))
+			})
+			It("should not have created a TerraformRun", func() {
+				runs, err := getLinkedRuns(k8sClient, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(runs.Items)).To(Equal(0))
+			})
+		})
+	})
 	Describe("Webhook issues", func() {
 		Describe("When a TerraformLayer is reconciled", Ordered, func() {
 			BeforeAll(func() {
@@ -0,0 +1,117 @@
+package terraformlayer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type dependenciesInfo struct {
+	ready bool
+	cycle []string
+}
+
+func getDependencyKey(layer *configv1alpha1.TerraformLayer, dependency configv1alpha1.TerraformLayerReference) types.NamespacedName {
+	namespace := dependency.Namespace
+	if namespace == "" {
+		namespace = layer.Namespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: dependency.Name}
+}
+
+// getDependencies returns the layers the layer depends on, the layers it uses
+// the outputs of being implicit dependencies
+func getDependencies(layer *configv1alpha1.TerraformLayer) []configv1alpha1.TerraformLayerReference {
+	dependencies := append([]configv1alpha1.TerraformLayerReference{}, layer.Spec.DependsOn...)
+	for _, input := range layer.Spec.InputsFrom {
+		if !slices.ContainsFunc(dependencies, func(d configv1alpha1.TerraformLayerReference) bool {
+			return getDependencyKey(layer, d) == getDependencyKey(layer, input.Layer)
+		}) {
+			dependencies = append(dependencies, input.Layer)
+		}
+	}
+	return dependencies
+}
+
+// findDependencyCycle walks the dependency graph of the layer and returns the
+// path of the first cycle found, or nil if there is none.
+func (r *Reconciler) findDependencyCycle(ctx context.Context, layer *configv1alpha1.TerraformLayer) ([]string, error) {
+	visited := map[types.NamespacedName]bool{}
+	var walk func(l *configv1alpha1.TerraformLayer, path []types.NamespacedName) ([]string, error)
+	walk = func(l *configv1alpha1.TerraformLayer, path []types.NamespacedName) ([]string, error) {
+		for _, dependency := range getDependencies(l) {
+			key := getDependencyKey(l, dependency)
+			for i, p := range path {
+				if p == key {
+					return append(formatCycle(path[i:]), key.String()), nil
+				}
+			}
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+			upstream := &configv1alpha1.TerraformLayer{}
+			err := r.Client.Get(ctx, key, upstream)
+			if err != nil {
+				return nil, err
+			}
+			cycle, err := walk(upstream, append(path, key))
+			if err != nil || cycle != nil {
+				return cycle, err
+			}
+		}
+		return nil, nil
+	}
+	return walk(layer, []types.NamespacedName{{Namespace: layer.Namespace, Name: layer.Name}})
+}
+
+func formatCycle(path []types.NamespacedName) []string {
+	cycle := []string{}
+	for _, p := range path {
+		cycle = append(cycle, p.String())
+	}
+	return cycle
+}
+
+// isUpstreamReady checks that the upstream layer has applied its latest plan,
+// and that this plan is at the same or a newer commit than the one the layer
+// is about to run on when both layers track the same repository branch.
+func isUpstreamReady(layer *configv1alpha1.TerraformLayer, upstream *configv1alpha1.TerraformLayer) (bool, string) {
+	planSum := upstream.Annotations[annotations.LastPlanSum]
+	if planSum == "" {
+		return false, "has no successful plan"
+	}
+	if upstream.Annotations[annotations.LastApplySum] != planSum {
+		return false, "has not applied its last plan"
+	}
+	planCommit := upstream.Annotations[annotations.LastPlanCommit]
+	if pinnedRevision, ok := upstream.Annotations[annotations.PinnedRevision]; ok {
+		if planCommit != pinnedRevision {
+			return false, fmt.Sprintf("has not planned its pinned revision %s", pinnedRevision)
+		}
+	} else if planCommit != upstream.Annotations[annotations.LastBranchCommit] && planCommit != upstream.Annotations[annotations.LastRelevantCommit] {
+		return false, "has not planned its last relevant commit"
+	}
+	if upstream.Spec.Repository != layer.Spec.Repository || upstream.Spec.Branch != layer.Spec.Branch {
+		return true, ""
+	}
+	upstreamCommit := upstream.Annotations[annotations.LastBranchCommit]
+	layerCommit := layer.Annotations[annotations.LastBranchCommit]
+	if upstreamCommit == layerCommit {
+		return true, ""
+	}
+	upstreamCommitDate, err := time.Parse(time.UnixDate, upstream.Annotations[annotations.LastBranchCommitDate])
+	if err != nil {
+		return false, fmt.Sprintf("is not at commit %s", layerCommit)
+	}
+	layerCommitDate, err := time.Parse(time.UnixDate, layer.Annotations[annotations.LastBranchCommitDate])
+	if err != nil || upstreamCommitDate.Before(layerCommitDate) {
+		return false, fmt.Sprintf("is not at commit %s", layerCommit)
+	}
+	return true, ""
+}
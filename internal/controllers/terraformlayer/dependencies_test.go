@@ -0,0 +1,295 @@
+package terraformlayer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func dependencyLayer(name string, dependsOn ...string) *configv1alpha1.TerraformLayer {
+	layer := &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: map[string]string{},
+		},
+		Spec: configv1alpha1.TerraformLayerSpec{
+			Branch: "main",
+			Repository: configv1alpha1.TerraformLayerRepository{
+				Name:      "repo",
+				Namespace: "default",
+			},
+		},
+	}
+	for _, d := range dependsOn {
+		layer.Spec.DependsOn = append(layer.Spec.DependsOn, configv1alpha1.TerraformLayerReference{Name: d})
+	}
+	return layer
+}
+
+// inputLayer makes the layer use an output of each of the upstream layers
+func inputLayer(layer *configv1alpha1.TerraformLayer, upstreams ...string) *configv1alpha1.TerraformLayer {
+	for _, u := range upstreams {
+		layer.Spec.InputsFrom = append(layer.Spec.InputsFrom, configv1alpha1.TerraformLayerInput{
+			Layer:   configv1alpha1.TerraformLayerReference{Name: u},
+			Outputs: []configv1alpha1.TerraformLayerInputOutput{{Name: "id"}},
+		})
+	}
+	return layer
+}
+
+func newDependencyTestReconciler(t *testing.T, layers ...client.Object) *Reconciler {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := configv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add burrito types to scheme: %s", err)
+	}
+	return &Reconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(layers...).Build(),
+	}
+}
+
+func TestFindDependencyCycle(t *testing.T) {
+	tests := []struct {
+		name   string
+		layers []*configv1alpha1.TerraformLayer
+		want   []string
+	}{
+		{
+			name: "no cycle",
+			layers: []*configv1alpha1.TerraformLayer{
+				dependencyLayer("app", "cluster", "network"),
+				dependencyLayer("cluster", "network"),
+				dependencyLayer("network"),
+			},
+			want: nil,
+		},
+		{
+			name: "direct cycle",
+			layers: []*configv1alpha1.TerraformLayer{
+				dependencyLayer("app", "cluster"),
+				dependencyLayer("cluster", "app"),
+			},
+			want: []string{"default/app", "default/cluster", "default/app"},
+		},
+		{
+			name: "cycle between upstream layers",
+			layers: []*configv1alpha1.TerraformLayer{
+				dependencyLayer("app", "cluster"),
+				dependencyLayer("cluster", "network"),
+				dependencyLayer("network", "cluster"),
+			},
+			want: []string{"default/cluster", "default/network", "default/cluster"},
+		},
+		{
+			name: "cycle through inputs",
+			layers: []*configv1alpha1.TerraformLayer{
+				inputLayer(dependencyLayer("app", "cluster"), "network"),
+				dependencyLayer("cluster"),
+				dependencyLayer("network", "app"),
+			},
+			want: []string{"default/app", "default/network", "default/app"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []client.Object{}
+			for _, l := range tt.layers {
+				objects = append(objects, l)
+			}
+			reconciler := newDependencyTestReconciler(t, objects...)
+			got, err := reconciler.findDependencyCycle(context.Background(), tt.layers[0])
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected cycle %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIsUpstreamReady(t *testing.T) {
+	applied := map[string]string{
+		annotations.LastPlanSum:          "sum",
+		annotations.LastApplySum:         "sum",
+		annotations.LastPlanCommit:       "abc",
+		annotations.LastBranchCommit:     "abc",
+		annotations.LastBranchCommitDate: "Mon May  8 11:21:53 UTC 2023",
+	}
+	tests := []struct {
+		name       string
+		upstream   map[string]string
+		layer      map[string]string
+		otherRepo  bool
+		wantReady  bool
+		wantReason string
+	}{
+		{
+			name:      "upstream applied at the same commit",
+			upstream:  applied,
+			layer:     map[string]string{annotations.LastBranchCommit: "abc"},
+			wantReady: true,
+		},
+		{
+			name:     "upstream applied at a newer commit",
+			upstream: applied,
+			layer: map[string]string{
+				annotations.LastBranchCommit:     "old",
+				annotations.LastBranchCommitDate: "Sun May  7 11:21:53 UTC 2023",
+			},
+			wantReady: true,
+		},
+		{
+			name:     "upstream applied at an older commit",
+			upstream: applied,
+			layer: map[string]string{
+				annotations.LastBranchCommit:     "new",
+				annotations.LastBranchCommitDate: "Tue May  9 11:21:53 UTC 2023",
+			},
+			wantReady:  false,
+			wantReason: "is not at commit new",
+		},
+		{
+			name:       "upstream applied on another repository",
+			upstream:   applied,
+			layer:      map[string]string{annotations.LastBranchCommit: "other"},
+			otherRepo:  true,
+			wantReady:  true,
+			wantReason: "",
+		},
+		{
+			name: "upstream not applied",
+			upstream: map[string]string{
+				annotations.LastPlanSum:      "new-sum",
+				annotations.LastApplySum:     "sum",
+				annotations.LastPlanCommit:   "abc",
+				annotations.LastBranchCommit: "abc",
+			},
+			layer:      map[string]string{annotations.LastBranchCommit: "abc"},
+			wantReady:  false,
+			wantReason: "has not applied its last plan",
+		},
+		{
+			name: "upstream not planned on its last commit",
+			upstream: map[string]string{
+				annotations.LastPlanSum:        "sum",
+				annotations.LastApplySum:       "sum",
+				annotations.LastPlanCommit:     "abc",
+				annotations.LastBranchCommit:   "def",
+				annotations.LastRelevantCommit: "def",
+			},
+			layer:      map[string]string{annotations.LastBranchCommit: "def"},
+			wantReady:  false,
+			wantReason: "has not planned its last relevant commit",
+		},
+		{
+			name: "upstream applied at its pinned revision",
+			upstream: map[string]string{
+				annotations.LastPlanSum:      "sum",
+				annotations.LastApplySum:     "sum",
+				annotations.LastPlanCommit:   "abc",
+				annotations.LastBranchCommit: "def",
+				annotations.PinnedRevision:   "abc",
+			},
+			layer:     map[string]string{annotations.LastBranchCommit: "def"},
+			wantReady: true,
+		},
+		{
+			name: "upstream not planned on its pinned revision",
+			upstream: map[string]string{
+				annotations.LastPlanSum:      "sum",
+				annotations.LastApplySum:     "sum",
+				annotations.LastPlanCommit:   "def",
+				annotations.LastBranchCommit: "def",
+				annotations.PinnedRevision:   "abc",
+			},
+			layer:      map[string]string{annotations.LastBranchCommit: "def"},
+			wantReady:  false,
+			wantReason: "has not planned its pinned revision abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := dependencyLayer("upstream")
+			upstream.Annotations = tt.upstream
+			if tt.otherRepo {
+				upstream.Spec.Repository.Name = "other-repo"
+			}
+			layer := dependencyLayer("layer", "upstream")
+			layer.Annotations = tt.layer
+			ready, reason := isUpstreamReady(layer, upstream)
+			if ready != tt.wantReady || reason != tt.wantReason {
+				t.Fatalf("expected (%t, %q), got (%t, %q)", tt.wantReady, tt.wantReason, ready, reason)
+			}
+		})
+	}
+}
+
+func TestGetDependencies(t *testing.T) {
+	layer := inputLayer(dependencyLayer("app", "cluster"), "cluster", "network")
+	want := []configv1alpha1.TerraformLayerReference{{Name: "cluster"}, {Name: "network"}}
+	if got := getDependencies(layer); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected dependencies %v, got %v", want, got)
+	}
+}
+
+func TestAreInputsUpToDate(t *testing.T) {
+	network := dependencyLayer("network")
+	network.Annotations[annotations.LastOutputsChange] = "network-apply/0"
+	tests := []struct {
+		name   string
+		layer  *configv1alpha1.TerraformLayer
+		inputs string
+		want   bool
+	}{
+		{
+			name:  "no inputs",
+			layer: dependencyLayer("app"),
+			want:  true,
+		},
+		{
+			name:  "inputs never planned",
+			layer: inputLayer(dependencyLayer("app"), "network"),
+			want:  false,
+		},
+		{
+			name:   "outputs unchanged since the last plan",
+			layer:  inputLayer(dependencyLayer("app"), "network"),
+			inputs: "default/network=network-apply/0",
+			want:   true,
+		},
+		{
+			name:   "outputs changed since the last plan",
+			layer:  inputLayer(dependencyLayer("app"), "network"),
+			inputs: "default/network=network-apply-old/1",
+			want:   false,
+		},
+		{
+			name:   "upstream layer not found",
+			layer:  inputLayer(dependencyLayer("app"), "database"),
+			inputs: "default/database=database-apply/0",
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.inputs != "" {
+				tt.layer.Annotations[annotations.LastPlanInputs] = tt.inputs
+			}
+			reconciler := newDependencyTestReconciler(t, tt.layer, network)
+			condition, got := reconciler.AreInputsUpToDate(tt.layer)
+			if got != tt.want {
+				t.Fatalf("expected %t, got %t (%s: %s)", tt.want, got, condition.Reason, condition.Message)
+			}
+		})
+	}
+}
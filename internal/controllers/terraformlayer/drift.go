@@ -0,0 +1,57 @@
+package terraformlayer
+
+import (
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/robfig/cron/v3"
+)
+
+// getNextDriftDetection returns the date at which the layer must be planned
+// and checked for drift again, given the date of the last plan or check.
+func (r *Reconciler) getNextDriftDetection(layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository, last time.Time) (time.Time, error) {
+	driftDetection := configv1alpha1.GetDriftDetection(repository, layer)
+	if driftDetection.Schedule != "" {
+		schedule, err := cron.ParseStandard(driftDetection.Schedule)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return schedule.Next(last), nil
+	}
+	if driftDetection.Interval != "" {
+		interval, err := time.ParseDuration(driftDetection.Interval)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return last.Add(interval), nil
+	}
+	return last.Add(r.Config.Controller.Timers.DriftDetection), nil
+}
+
+// getIdleRequeue returns the delay until the next plan or drift check of the
+// layer, falling back to the DriftDetection timer when it cannot be computed.
+func (r *Reconciler) getIdleRequeue(layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) time.Duration {
+	if !*configv1alpha1.GetDriftDetection(repository, layer).Enabled {
+		return r.Config.Controller.Timers.DriftDetection
+	}
+	next := time.Time{}
+	for _, annotation := range []string{annotations.LastPlanDate, annotations.LastDriftCheckDate} {
+		last, err := time.Parse(time.UnixDate, layer.Annotations[annotation])
+		if err != nil {
+			continue
+		}
+		date, err := r.getNextDriftDetection(layer, repository, last)
+		if err != nil {
+			return r.Config.Controller.Timers.DriftDetection
+		}
+		if next.IsZero() || date.Before(next) {
+			next = date
+		}
+	}
+	now := r.Clock.Now()
+	if next.IsZero() || !next.After(now) {
+		return r.Config.Controller.Timers.DriftDetection
+	}
+	return next.Sub(now)
+}
@@ -0,0 +1,116 @@
+package terraformlayer
+
+import (
+	"testing"
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/burrito/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestGetNextDriftDetection(t *testing.T) {
+	last := time.Date(2023, time.May, 8, 11, 21, 53, 0, time.UTC)
+	tests := []struct {
+		name           string
+		driftDetection configv1alpha1.DriftDetection
+		want           time.Time
+		wantErr        bool
+	}{
+		{
+			name: "controller timer",
+			want: last.Add(20 * time.Minute),
+		},
+		{
+			name:           "interval",
+			driftDetection: configv1alpha1.DriftDetection{Interval: "6h"},
+			want:           last.Add(6 * time.Hour),
+		},
+		{
+			name:           "schedule takes precedence over interval",
+			driftDetection: configv1alpha1.DriftDetection{Schedule: "0 3 * * *", Interval: "6h"},
+			want:           time.Date(2023, time.May, 9, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:           "invalid schedule",
+			driftDetection: configv1alpha1.DriftDetection{Schedule: "invalid-cron"},
+			wantErr:        true,
+		},
+		{
+			name:           "invalid interval",
+			driftDetection: configv1alpha1.DriftDetection{Interval: "invalid-duration"},
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler := &Reconciler{Config: config.TestConfig()}
+			layer := &configv1alpha1.TerraformLayer{
+				Spec: configv1alpha1.TerraformLayerSpec{DriftDetection: tt.driftDetection},
+			}
+			got, err := reconciler.getNextDriftDetection(layer, &configv1alpha1.TerraformRepository{}, last)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetIdleRequeue(t *testing.T) {
+	now := time.Date(2023, time.May, 8, 11, 21, 53, 0, time.UTC)
+	disabled := false
+	tests := []struct {
+		name           string
+		annotations    map[string]string
+		driftDetection configv1alpha1.DriftDetection
+		want           time.Duration
+	}{
+		{
+			name:        "never planned",
+			annotations: map[string]string{},
+			want:        20 * time.Minute,
+		},
+		{
+			name: "earliest of next plan and next drift check",
+			annotations: map[string]string{
+				annotations.LastPlanDate:       "Mon May  8 10:21:53 UTC 2023",
+				annotations.LastDriftCheckDate: "Mon May  8 11:11:53 UTC 2023",
+			},
+			driftDetection: configv1alpha1.DriftDetection{Interval: "90m"},
+			want:           30 * time.Minute,
+		},
+		{
+			name: "drift detection disabled",
+			annotations: map[string]string{
+				annotations.LastPlanDate: "Mon May  8 11:11:53 UTC 2023",
+			},
+			driftDetection: configv1alpha1.DriftDetection{Enabled: &disabled, Interval: "90m"},
+			want:           20 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler := &Reconciler{Config: config.TestConfig(), Clock: fixedClock{now: now}}
+			layer := &configv1alpha1.TerraformLayer{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations},
+				Spec:       configv1alpha1.TerraformLayerSpec{DriftDetection: tt.driftDetection},
+			}
+			got := reconciler.getIdleRequeue(layer, &configv1alpha1.TerraformRepository{})
+			if got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
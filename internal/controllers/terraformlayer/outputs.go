@@ -0,0 +1,169 @@
+package terraformlayer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// output is an output of the layer, as returned by `terraform output -json`
+type output struct {
+	Sensitive bool            `json:"sensitive"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// publishOutputs writes the outputs stored by the last apply to the Secret or
+// ConfigMap described in the layer spec, so that runners never write Secrets.
+// They are written again when the outputs or the layer spec change. Failures
+// are reported by the AreOutputsPublished condition.
+func (r *Reconciler) publishOutputs(ctx context.Context, layer *configv1alpha1.TerraformLayer) error {
+	config := layer.Spec.Outputs
+	change, ok := layer.Annotations[annotations.LastOutputsChange]
+	if config == nil || !ok {
+		return nil
+	}
+	published := fmt.Sprintf("%s@%d", change, layer.Generation)
+	if layer.Annotations[annotations.LastOutputsPublished] == published {
+		return nil
+	}
+	err := r.writeLayerOutputs(ctx, layer, config)
+	if err != nil {
+		log.Errorf("could not write outputs of layer %s to %s %s: %s", layer.Name, config.Kind, config.Name, err)
+		return annotations.Add(ctx, r.Client, layer, map[string]string{
+			annotations.LastOutputsError: fmt.Sprintf("could not write outputs to %s %s: %s", config.Kind, config.Name, err),
+		})
+	}
+	log.Infof("wrote outputs of layer %s to %s %s", layer.Name, config.Kind, config.Name)
+	err = annotations.Add(ctx, r.Client, layer, map[string]string{
+		annotations.LastOutputsPublished: published,
+		annotations.LastOutputsCommit:    layer.Annotations[annotations.LastApplyCommit],
+		annotations.LastOutputsRun:       strings.Split(change, "/")[0],
+	})
+	if err != nil {
+		return err
+	}
+	if _, ok := layer.Annotations[annotations.LastOutputsError]; ok {
+		return annotations.Remove(ctx, r.Client, layer, annotations.LastOutputsError)
+	}
+	return nil
+}
+
+// writeLayerOutputs gets the outputs of the layer from the datastore and
+// writes the selected ones. Values are never logged nor returned in errors as
+// they may be sensitive.
+func (r *Reconciler) writeLayerOutputs(ctx context.Context, layer *configv1alpha1.TerraformLayer, config *configv1alpha1.TerraformLayerOutputs) error {
+	raw, err := r.Datastore.GetOutputs(layer.Namespace, layer.Name)
+	if err != nil {
+		return fmt.Errorf("could not get outputs from datastore: %w", err)
+	}
+	outputs := map[string]output{}
+	err = json.Unmarshal(raw, &outputs)
+	if err != nil {
+		return fmt.Errorf("could not parse outputs")
+	}
+	data, err := selectOutputs(outputs, config)
+	if err != nil {
+		return err
+	}
+	return r.writeOutputs(ctx, layer, config, data)
+}
+
+// selectOutputs returns the value of the outputs to write. Strings are written
+// as is, other values as JSON. Sensitive outputs are only written to Secrets.
+func selectOutputs(outputs map[string]output, config *configv1alpha1.TerraformLayerOutputs) (map[string]string, error) {
+	names := config.Names
+	if len(names) == 0 {
+		for name, o := range outputs {
+			if o.Sensitive && config.Kind == "ConfigMap" {
+				log.Warningf("output %s is sensitive and is not written to ConfigMap %s", name, config.Name)
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	data := map[string]string{}
+	for _, name := range names {
+		o, ok := outputs[name]
+		if !ok {
+			return nil, fmt.Errorf("output %s not found", name)
+		}
+		if o.Sensitive && config.Kind == "ConfigMap" {
+			return nil, fmt.Errorf("output %s is sensitive and can only be written to a Secret", name)
+		}
+		var value string
+		if err := json.Unmarshal(o.Value, &value); err == nil {
+			data[name] = value
+			continue
+		}
+		compacted := bytes.NewBuffer(nil)
+		if err := json.Compact(compacted, o.Value); err != nil {
+			return nil, fmt.Errorf("could not read the value of output %s", name)
+		}
+		data[name] = compacted.String()
+	}
+	return data, nil
+}
+
+// writeOutputs creates or updates the Secret or ConfigMap holding the outputs.
+// An existing resource is only updated if it is owned by the layer.
+func (r *Reconciler) writeOutputs(ctx context.Context, layer *configv1alpha1.TerraformLayer, config *configv1alpha1.TerraformLayerOutputs, data map[string]string) error {
+	var obj client.Object
+	if config.Kind == "ConfigMap" {
+		obj = &corev1.ConfigMap{}
+	} else {
+		obj = &corev1.Secret{}
+	}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: layer.Namespace, Name: config.Name}, obj)
+	exists := true
+	if errors.IsNotFound(err) {
+		exists = false
+		obj.SetName(config.Name)
+		obj.SetNamespace(layer.Namespace)
+		obj.SetLabels(GetDefaultLabels(layer))
+		obj.SetOwnerReferences([]metav1.OwnerReference{
+			{
+				APIVersion: configv1alpha1.GroupVersion.String(),
+				Kind:       "TerraformLayer",
+				Name:       layer.Name,
+				UID:        layer.UID,
+			},
+		})
+	} else if err != nil {
+		return err
+	}
+	if exists && !isOwnedBy(obj, layer) {
+		return fmt.Errorf("%s %s already exists and is not owned by layer %s", config.Kind, config.Name, layer.Name)
+	}
+	switch o := obj.(type) {
+	case *corev1.ConfigMap:
+		o.Data = data
+	case *corev1.Secret:
+		o.Data = nil
+		o.StringData = data
+	}
+	if exists {
+		return r.Client.Update(ctx, obj)
+	}
+	return r.Client.Create(ctx, obj)
+}
+
+func isOwnedBy(obj client.Object, layer *configv1alpha1.TerraformLayer) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == layer.UID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package terraformlayer
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testOutputs = `{
+	"endpoint": {"sensitive": false, "type": "string", "value": "db.example.com"},
+	"ports": {"sensitive": false, "type": ["list", "number"], "value": [5432, 5433]},
+	"password": {"sensitive": true, "type": "string", "value": "hunter2"}
+}`
+
+func TestSelectOutputs(t *testing.T) {
+	outputs := map[string]output{}
+	if err := json.Unmarshal([]byte(testOutputs), &outputs); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		config  configv1alpha1.TerraformLayerOutputs
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "all outputs to a Secret",
+			config: configv1alpha1.TerraformLayerOutputs{Kind: "Secret", Name: "db"},
+			want:   map[string]string{"endpoint": "db.example.com", "ports": "[5432,5433]", "password": "hunter2"},
+		},
+		{
+			name:   "sensitive outputs are left out of ConfigMaps",
+			config: configv1alpha1.TerraformLayerOutputs{Kind: "ConfigMap", Name: "db"},
+			want:   map[string]string{"endpoint": "db.example.com", "ports": "[5432,5433]"},
+		},
+		{
+			name:   "selected outputs",
+			config: configv1alpha1.TerraformLayerOutputs{Kind: "Secret", Name: "db", Names: []string{"endpoint"}},
+			want:   map[string]string{"endpoint": "db.example.com"},
+		},
+		{
+			name:    "selected sensitive output to a ConfigMap",
+			config:  configv1alpha1.TerraformLayerOutputs{Kind: "ConfigMap", Name: "db", Names: []string{"password"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown output",
+			config:  configv1alpha1.TerraformLayerOutputs{Kind: "Secret", Name: "db", Names: []string{"unknown"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectOutputs(outputs, &tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteOutputs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	layer := &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{Name: "database", Namespace: "default", UID: "layer-uid"},
+	}
+	unowned := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unowned", Namespace: "default"}}
+	r := &Reconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(unowned).Build(),
+	}
+	config := &configv1alpha1.TerraformLayerOutputs{Kind: "ConfigMap", Name: "database-outputs"}
+
+	if err := r.writeOutputs(context.Background(), layer, config, map[string]string{"endpoint": "db.example.com"}); err != nil {
+		t.Fatalf("unexpected error creating the ConfigMap: %s", err)
+	}
+	if err := r.writeOutputs(context.Background(), layer, config, map[string]string{"endpoint": "db2.example.com"}); err != nil {
+		t.Fatalf("unexpected error updating the ConfigMap: %s", err)
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := r.Client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "database-outputs"}, configMap); err != nil {
+		t.Fatalf("expected the ConfigMap to exist: %s", err)
+	}
+	if configMap.Data["endpoint"] != "db2.example.com" || !isOwnedBy(configMap, layer) {
+		t.Fatalf("expected an up to date ConfigMap owned by the layer, got %+v", configMap)
+	}
+
+	err := r.writeOutputs(context.Background(), layer, &configv1alpha1.TerraformLayerOutputs{Kind: "ConfigMap", Name: "unowned"}, map[string]string{"endpoint": "db.example.com"})
+	if err == nil {
+		t.Fatalf("expected an error when the ConfigMap is not owned by the layer")
+	}
+}
+
+// outputsDatastore serves the outputs of a layer
+type outputsDatastore struct {
+	*datastore.MockClient
+	outputs []byte
+}
+
+func (d *outputsDatastore) GetOutputs(namespace string, layer string) ([]byte, error) {
+	return d.outputs, nil
+}
+
+func TestPublishOutputs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := configv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	layer := &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "database",
+			Namespace: "default",
+			UID:       "layer-uid",
+			Annotations: map[string]string{
+				annotations.LastApplyCommit:   "abc123",
+				annotations.LastOutputsChange: "database-apply-xyz/0",
+			},
+		},
+		Spec: configv1alpha1.TerraformLayerSpec{
+			Outputs: &configv1alpha1.TerraformLayerOutputs{Kind: "ConfigMap", Name: "database-outputs", Names: []string{"password"}},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(layer).Build()
+	r := &Reconciler{
+		Client:    cl,
+		Datastore: &outputsDatastore{MockClient: datastore.NewMockClient(), outputs: []byte(testOutputs)},
+	}
+
+	if err := r.publishOutputs(context.Background(), layer); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	message, ok := layer.Annotations[annotations.LastOutputsError]
+	if !ok || strings.Contains(message, "hunter2") {
+		t.Fatalf("expected the failure to be reported without the output values, got %q", message)
+	}
+
+	layer.Spec.Outputs.Kind = "Secret"
+	layer.Generation = 2
+	if err := r.publishOutputs(context.Background(), layer); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := layer.Annotations[annotations.LastOutputsError]; ok {
+		t.Fatalf("expected the previous failure to be cleared")
+	}
+	if layer.Annotations[annotations.LastOutputsRun] != "database-apply-xyz" || layer.Annotations[annotations.LastOutputsCommit] != "abc123" {
+		t.Fatalf("expected the published outputs to be recorded, got %v", layer.Annotations)
+	}
+	secret := &corev1.Secret{}
+	if err := cl.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "database-outputs"}, secret); err != nil {
+		t.Fatalf("expected the Secret to exist: %s", err)
+	}
+
+	if err := cl.Delete(context.Background(), secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.publishOutputs(context.Background(), layer); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := cl.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "database-outputs"}, secret); err == nil {
+		t.Fatalf("expected published outputs not to be written again")
+	}
+}
@@ -0,0 +1,193 @@
+package terraformlayer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/policy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// evaluatePolicies evaluates the policies of the layer against the JSON plan
+// stored by its last plan run. The verdict is kept in the status of the layer,
+// which runners cannot write, and the report is stored in the datastore next
+// to the plan. Policies are evaluated once per plan and set of policies, the
+// verdict is updated when a policy is added, removed or modified.
+func (r *Reconciler) evaluatePolicies(ctx context.Context, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) error {
+	planRun := layer.Annotations[annotations.LastPlanRun]
+	sum := layer.Annotations[annotations.LastPlanSum]
+	if planRun == "" {
+		return nil
+	}
+	run := strings.Split(planRun, "/")
+	if len(run) != 2 {
+		return fmt.Errorf("invalid last plan run %s", planRun)
+	}
+	policies, err := r.getPolicies(ctx, layer)
+	if err != nil {
+		return fmt.Errorf("could not get the policies of the layer: %w", err)
+	}
+	digest := policy.Digest(policies)
+	if layer.Status.Policy.Run == planRun && layer.Status.Policy.Sum == sum && layer.Status.Policy.Policies == digest {
+		return nil
+	}
+	result := policy.StatusNone
+	if len(policies) > 0 {
+		var report policy.Report
+		if configv1alpha1.GetTerragruntStackEnabled(repository, layer) {
+			report, err = r.evaluateStackPolicies(ctx, layer, run[0], run[1], policies)
+		} else {
+			report, err = r.evaluatePlanPolicies(ctx, layer, run[0], run[1], policies)
+		}
+		if err != nil {
+			return err
+		}
+		content, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		err = r.Datastore.PutPlan(layer.Namespace, layer.Name, run[0], run[1], "policies", content)
+		if err != nil {
+			return fmt.Errorf("could not put policy report in datastore: %w", err)
+		}
+		result = report.Status()
+		log.Infof("policy check of plan %s of layer %s: %s", planRun, layer.Name, result)
+	}
+	layer.Status.Policy = configv1alpha1.TerraformLayerPolicyStatus{
+		Run:      planRun,
+		Sum:      sum,
+		Result:   result,
+		Policies: digest,
+	}
+	return nil
+}
+
+// isTargetedPlanDenied evaluates the policies of the layer against its last
+// targeted plan, right before it is applied. The report is stored in the
+// datastore next to the plan.
+func (r *Reconciler) isTargetedPlanDenied(ctx context.Context, layer *configv1alpha1.TerraformLayer) (bool, error) {
+	targetedPlan := layer.Annotations[annotations.LastTargetedPlanRun]
+	run := strings.Split(targetedPlan, "/")
+	if len(run) != 2 {
+		return false, fmt.Errorf("invalid targeted plan run %s", targetedPlan)
+	}
+	policies, err := r.getPolicies(ctx, layer)
+	if err != nil {
+		return false, fmt.Errorf("could not get the policies of the layer: %w", err)
+	}
+	if len(policies) == 0 {
+		return false, nil
+	}
+	report, err := r.evaluatePlanPolicies(ctx, layer, run[0], run[1], policies)
+	if err != nil {
+		return false, err
+	}
+	content, err := json.Marshal(report)
+	if err != nil {
+		return false, err
+	}
+	err = r.Datastore.PutPlan(layer.Namespace, layer.Name, run[0], run[1], "policies", content)
+	if err != nil {
+		return false, fmt.Errorf("could not put policy report in datastore: %w", err)
+	}
+	log.Infof("policy check of targeted plan %s of layer %s: %s", targetedPlan, layer.Name, report.Status())
+	return report.Status() == policy.StatusDenied, nil
+}
+
+func (r *Reconciler) evaluatePlanPolicies(ctx context.Context, layer *configv1alpha1.TerraformLayer, run string, attempt string, policies []policy.Policy) (policy.Report, error) {
+	planJson, err := r.Datastore.GetPlan(layer.Namespace, layer.Name, run, attempt, "json")
+	if err != nil {
+		return policy.Report{}, fmt.Errorf("could not get json plan from datastore: %w", err)
+	}
+	return policy.Evaluate(ctx, policies, planJson)
+}
+
+// evaluateStackPolicies evaluates the policies against the plan of each module
+// of the stack, the messages being prefixed with the module
+func (r *Reconciler) evaluateStackPolicies(ctx context.Context, layer *configv1alpha1.TerraformLayer, run string, attempt string, policies []policy.Policy) (policy.Report, error) {
+	report := policy.Report{Results: []policy.Result{}}
+	index, err := r.Datastore.GetPlan(layer.Namespace, layer.Name, run, attempt, "modules")
+	if err != nil {
+		return report, fmt.Errorf("could not get the list of stack modules from datastore: %w", err)
+	}
+	modules := []string{}
+	err = json.Unmarshal(index, &modules)
+	if err != nil {
+		return report, fmt.Errorf("could not parse the list of stack modules: %w", err)
+	}
+	for _, module := range modules {
+		planJson, err := r.Datastore.GetModulePlan(layer.Namespace, layer.Name, run, attempt, module, "json")
+		if err != nil {
+			return report, fmt.Errorf("could not get json plan of module %s from datastore: %w", module, err)
+		}
+		moduleReport, err := policy.Evaluate(ctx, policies, planJson)
+		if err != nil {
+			return report, err
+		}
+		report.Merge(moduleReport, fmt.Sprintf("%s: ", module))
+	}
+	return report, nil
+}
+
+// getPolicies returns the policies of the namespace of the layer which select
+// the layer, sorted by name
+func (r *Reconciler) getPolicies(ctx context.Context, layer *configv1alpha1.TerraformLayer) ([]policy.Policy, error) {
+	list := &configv1alpha1.TerraformPolicyList{}
+	err := r.Client.List(ctx, list, client.InNamespace(layer.Namespace))
+	if err != nil {
+		return nil, err
+	}
+	policies := []policy.Policy{}
+	for _, p := range list.Items {
+		if p.Spec.LayerSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(p.Spec.LayerSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid layer selector in policy %s: %w", p.Name, err)
+			}
+			if !selector.Matches(labels.Set(layer.Labels)) {
+				continue
+			}
+		}
+		module := p.Spec.Rego
+		if p.Spec.ConfigMapKeyRef != nil {
+			content, err := r.getConfigMapValue(ctx, layer.Namespace, p.Spec.ConfigMapKeyRef)
+			if err != nil {
+				return nil, fmt.Errorf("could not get the module of policy %s: %w", p.Name, err)
+			}
+			module = string(content)
+		}
+		mode := p.Spec.Mode
+		if mode == "" {
+			mode = policy.ModeEnforce
+		}
+		policies = append(policies, policy.Policy{Name: p.Name, Mode: mode, Module: module})
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+	return policies, nil
+}
+
+func (r *Reconciler) getConfigMapValue(ctx context.Context, namespace string, selector *corev1.ConfigMapKeySelector) ([]byte, error) {
+	configMap := &corev1.ConfigMap{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: selector.Name}, configMap)
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := configMap.Data[selector.Key]; ok {
+		return []byte(value), nil
+	}
+	if value, ok := configMap.BinaryData[selector.Key]; ok {
+		return value, nil
+	}
+	return nil, fmt.Errorf("key %s not found in ConfigMap %s", selector.Key, selector.Name)
+}
@@ -0,0 +1,217 @@
+package terraformlayer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testPlan = `{"resource_changes": [{"address": "aws_instance.web", "change": {"actions": ["delete"]}}]}`
+
+const testDenyDeletions = `package burrito.deletions
+
+deny contains msg if {
+	some change in input.resource_changes
+	"delete" in change.change.actions
+	msg := sprintf("%s must not be deleted", [change.address])
+}`
+
+// plansDatastore serves the JSON plans of a layer and records the reports
+type plansDatastore struct {
+	*datastore.MockClient
+	plans   map[string][]byte
+	reports map[string][]byte
+}
+
+func (d *plansDatastore) GetPlan(namespace string, layer string, run string, attempt string, format string) ([]byte, error) {
+	plan, ok := d.plans[fmt.Sprintf("%s/%s/%s", run, attempt, format)]
+	if !ok {
+		return nil, fmt.Errorf("plan not found")
+	}
+	return plan, nil
+}
+
+func (d *plansDatastore) PutPlan(namespace string, layer string, run string, attempt string, format string, content []byte) error {
+	d.reports[fmt.Sprintf("%s/%s/%s", run, attempt, format)] = content
+	return nil
+}
+
+func newPolicyTestReconciler(t *testing.T, store *plansDatastore, policies ...*configv1alpha1.TerraformPolicy) *Reconciler {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := configv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, p := range policies {
+		builder = builder.WithObjects(p)
+	}
+	return &Reconciler{Client: builder.Build(), Datastore: store}
+}
+
+func policyLayer() *configv1alpha1.TerraformLayer {
+	return &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "layer",
+			Namespace: "default",
+			Labels:    map[string]string{"env": "production"},
+			Annotations: map[string]string{
+				annotations.LastPlanRun: "plan-run/0",
+				annotations.LastPlanSum: "sum",
+			},
+		},
+	}
+}
+
+func TestEvaluatePolicies(t *testing.T) {
+	enforced := &configv1alpha1.TerraformPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "no-deletion", Namespace: "default"},
+		Spec:       configv1alpha1.TerraformPolicySpec{Rego: testDenyDeletions, Mode: "Enforce"},
+	}
+	otherLayers := &configv1alpha1.TerraformPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "default"},
+		Spec: configv1alpha1.TerraformPolicySpec{
+			Rego: testDenyDeletions,
+			LayerSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"env": "staging"},
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		policies   []*configv1alpha1.TerraformPolicy
+		plans      map[string][]byte
+		wantResult string
+		wantErr    bool
+	}{
+		{
+			name:       "no policy",
+			policies:   []*configv1alpha1.TerraformPolicy{otherLayers},
+			wantResult: "None",
+		},
+		{
+			name:       "denied plan",
+			policies:   []*configv1alpha1.TerraformPolicy{enforced, otherLayers},
+			plans:      map[string][]byte{"plan-run/0/json": []byte(testPlan)},
+			wantResult: "Denied",
+		},
+		{
+			name:     "missing plan",
+			policies: []*configv1alpha1.TerraformPolicy{enforced},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &plansDatastore{MockClient: datastore.NewMockClient(), plans: tt.plans, reports: map[string][]byte{}}
+			r := newPolicyTestReconciler(t, store, tt.policies...)
+			layer := policyLayer()
+
+			err := r.evaluatePolicies(context.Background(), layer, &configv1alpha1.TerraformRepository{})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				if layer.Status.Policy.Run != "" {
+					t.Fatalf("expected no verdict to be recorded, got %v", layer.Status.Policy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			want := configv1alpha1.TerraformLayerPolicyStatus{Run: "plan-run/0", Sum: "sum", Result: tt.wantResult, Policies: layer.Status.Policy.Policies}
+			if layer.Status.Policy != want || want.Policies == "" {
+				t.Fatalf("expected policy status %v, got %v", want, layer.Status.Policy)
+			}
+			report, ok := store.reports["plan-run/0/policies"]
+			if tt.wantResult == "None" && ok {
+				t.Fatalf("expected no report when no policy applies to the layer")
+			}
+			if tt.wantResult == "Denied" && !strings.Contains(string(report), "aws_instance.web must not be deleted") {
+				t.Fatalf("expected the report to contain the denial, got %s", report)
+			}
+		})
+	}
+}
+
+func TestEvaluatePoliciesOncePerPlanAndPolicies(t *testing.T) {
+	enforced := &configv1alpha1.TerraformPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "no-deletion", Namespace: "default"},
+		Spec:       configv1alpha1.TerraformPolicySpec{Rego: testDenyDeletions, Mode: "Enforce"},
+	}
+	store := &plansDatastore{
+		MockClient: datastore.NewMockClient(),
+		plans:      map[string][]byte{"plan-run/0/json": []byte(testPlan)},
+		reports:    map[string][]byte{},
+	}
+	r := newPolicyTestReconciler(t, store, enforced)
+	layer := policyLayer()
+
+	if err := r.evaluatePolicies(context.Background(), layer, &configv1alpha1.TerraformRepository{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if layer.Status.Policy.Result != "Denied" {
+		t.Fatalf("expected the plan to be denied, got %s", layer.Status.Policy.Result)
+	}
+	delete(store.reports, "plan-run/0/policies")
+	if err := r.evaluatePolicies(context.Background(), layer, &configv1alpha1.TerraformRepository{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := store.reports["plan-run/0/policies"]; ok {
+		t.Fatalf("expected the policies not to be evaluated again against the same plan")
+	}
+
+	// Fixing the policy must unblock the layer without a new plan
+	enforced.Spec.Mode = "Warn"
+	if err := r.Client.Update(context.Background(), enforced); err != nil {
+		t.Fatalf("failed to update policy: %s", err)
+	}
+	if err := r.evaluatePolicies(context.Background(), layer, &configv1alpha1.TerraformRepository{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if layer.Status.Policy.Result != "Warned" {
+		t.Fatalf("expected the plan to be evaluated again against the modified policy, got %s", layer.Status.Policy.Result)
+	}
+	if _, ok := store.reports["plan-run/0/policies"]; !ok {
+		t.Fatalf("expected the report to be updated")
+	}
+}
+
+func TestEvaluatePoliciesMissingModule(t *testing.T) {
+	policy := &configv1alpha1.TerraformPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "no-deletion", Namespace: "default"},
+		Spec: configv1alpha1.TerraformPolicySpec{
+			ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "policies"},
+				Key:                  "deletions.rego",
+			},
+		},
+	}
+	store := &plansDatastore{MockClient: datastore.NewMockClient(), reports: map[string][]byte{}}
+	r := newPolicyTestReconciler(t, store, policy)
+	layer := policyLayer()
+	layer.Status.Policy = configv1alpha1.TerraformLayerPolicyStatus{Run: "plan-run/0", Sum: "old-sum", Result: "Passed"}
+
+	// The ConfigMap does not exist, the verdict of the previous plan must not be kept
+	if err := r.evaluatePolicies(context.Background(), layer, &configv1alpha1.TerraformRepository{}); err == nil {
+		t.Fatalf("expected an error when the module of a policy cannot be loaded")
+	}
+	if _, denied := r.IsLastPlanDenied(layer); !denied {
+		t.Fatalf("expected a plan whose policies could not be evaluated to be denied")
+	}
+}
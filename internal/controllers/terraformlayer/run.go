@@ -2,6 +2,7 @@ package terraformlayer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -16,8 +17,10 @@ import (
 type Action string
 
 const (
-	PlanAction  Action = "plan"
-	ApplyAction Action = "apply"
+	PlanAction       Action = "plan"
+	ApplyAction      Action = "apply"
+	DestroyAction    Action = "destroy"
+	DriftCheckAction Action = "drift-check"
 )
 
 func GetDefaultLabels(layer *configv1alpha1.TerraformLayer) map[string]string {
@@ -26,12 +29,20 @@ func GetDefaultLabels(layer *configv1alpha1.TerraformLayer) map[string]string {
 	}
 }
 
+// GetRevision returns the revision the runs of the layer must use: the pinned
+// revision if the layer has been rolled back, the last branch commit otherwise
+func GetRevision(layer *configv1alpha1.TerraformLayer) (string, bool) {
+	if revision, ok := layer.Annotations[annotations.PinnedRevision]; ok {
+		return revision, true
+	}
+	revision, ok := layer.Annotations[annotations.LastBranchCommit]
+	return revision, ok
+}
+
 func (r *Reconciler) getRun(layer *configv1alpha1.TerraformLayer, revision string, action Action) configv1alpha1.TerraformRun {
 	artifact := configv1alpha1.Artifact{}
 	if action == ApplyAction {
-		run := strings.Split(layer.Annotations[annotations.LastPlanRun], "/")
-		artifact.Attempt = run[1]
-		artifact.Run = run[0]
+		artifact = getArtifact(layer.Annotations[annotations.LastPlanRun])
 	}
 	return configv1alpha1.TerraformRun{
 		ObjectMeta: metav1.ObjectMeta{
@@ -59,6 +70,37 @@ func (r *Reconciler) getRun(layer *configv1alpha1.TerraformLayer, revision strin
 	}
 }
 
+// getTargetedApplyRun returns the apply of the last targeted plan of the
+// layer, restricted to the resources of the plan
+func (r *Reconciler) getTargetedApplyRun(layer *configv1alpha1.TerraformLayer) configv1alpha1.TerraformRun {
+	run := r.getRun(layer, layer.Annotations[annotations.LastTargetedPlanCommit], ApplyAction)
+	run.Spec.Artifact = getArtifact(layer.Annotations[annotations.LastTargetedPlanRun])
+	run.Spec.Targets = getAnnotationList(layer, annotations.LastPlanTargets)
+	run.Spec.Replace = getAnnotationList(layer, annotations.LastPlanReplace)
+	return run
+}
+
+// getArtifact returns the artifact of a plan from its run and attempt
+func getArtifact(plan string) configv1alpha1.Artifact {
+	run := strings.Split(plan, "/")
+	if len(run) != 2 {
+		return configv1alpha1.Artifact{}
+	}
+	return configv1alpha1.Artifact{Run: run[0], Attempt: run[1]}
+}
+
+func getAnnotationList(layer *configv1alpha1.TerraformLayer, key string) []string {
+	value, ok := layer.Annotations[key]
+	if !ok {
+		return nil
+	}
+	list := []string{}
+	if err := json.Unmarshal([]byte(value), &list); err != nil {
+		log.Errorf("could not parse annotation %s of layer %s: %s", key, layer.Name, err)
+	}
+	return list
+}
+
 func (r *Reconciler) getAllRuns(ctx context.Context, layer *configv1alpha1.TerraformLayer) ([]*configv1alpha1.TerraformRun, error) {
 	list := &configv1alpha1.TerraformRunList{}
 	labelSelector := labels.NewSelector()
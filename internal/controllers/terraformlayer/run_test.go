@@ -0,0 +1,83 @@
+package terraformlayer
+
+import (
+	"context"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func layerRun(layer *configv1alpha1.TerraformLayer, name string, triggeredBy string) *configv1alpha1.TerraformRun {
+	run := &configv1alpha1.TerraformRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   layer.Namespace,
+			Labels:      GetDefaultLabels(layer),
+			Annotations: map[string]string{},
+		},
+		Spec: configv1alpha1.TerraformRunSpec{
+			Action: string(PlanAction),
+		},
+	}
+	if triggeredBy != "" {
+		run.Annotations[annotations.TriggeredBy] = triggeredBy
+		run.Spec.Targets = []string{"module.app"}
+	}
+	return run
+}
+
+func TestAddTriggeredRuns(t *testing.T) {
+	layer := dependencyLayer("layer")
+	reconciler := newDependencyTestReconciler(t,
+		layer,
+		layerRun(layer, "layer-plan-scheduled", ""),
+		layerRun(layer, "layer-plan-old-scheduled", ""),
+		layerRun(layer, "layer-plan-recorded", "user@example.com"),
+		layerRun(layer, "layer-plan-triggered", "user@example.com"),
+	)
+	history := []configv1alpha1.TerraformLayerRun{
+		{Name: "layer-plan-scheduled", Action: string(PlanAction)},
+		{Name: "layer-plan-recorded", Action: string(PlanAction)},
+	}
+
+	got, err := reconciler.addTriggeredRuns(context.Background(), layer, history, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	names := map[string]int{}
+	for _, run := range got {
+		names[run.Name]++
+	}
+	if len(got) != 3 || names["layer-plan-triggered"] != 1 || names["layer-plan-recorded"] != 1 || names["layer-plan-scheduled"] != 1 {
+		t.Fatalf("expected the triggered run to be added once to the history, got %v", got)
+	}
+}
+
+func TestGetTargetedApplyRun(t *testing.T) {
+	layer := targetedPlanLayer("")
+	reconciler, _ := newApprovalTestReconciler(t, layer)
+
+	run := reconciler.getRun(layer, "abc123", ApplyAction)
+	if run.Spec.Targets != nil || run.Spec.Replace != nil {
+		t.Fatalf("expected the apply of a whole layer plan not to be targeted, got %v %v", run.Spec.Targets, run.Spec.Replace)
+	}
+	if run.Spec.Artifact.Run != "plan-run" {
+		t.Fatalf("expected the apply to use the plan of the whole layer, got %s", run.Spec.Artifact.Run)
+	}
+
+	run = reconciler.getTargetedApplyRun(layer)
+	if len(run.Spec.Targets) != 1 || run.Spec.Targets[0] != "module.app" {
+		t.Fatalf("expected the apply to target module.app, got %v", run.Spec.Targets)
+	}
+	if len(run.Spec.Replace) != 1 || run.Spec.Replace[0] != "module.app.aws_instance.web" {
+		t.Fatalf("expected the apply to replace module.app.aws_instance.web, got %v", run.Spec.Replace)
+	}
+	if run.Spec.Artifact.Run != "targeted-run" || run.Spec.Artifact.Attempt != "0" {
+		t.Fatalf("expected the apply to use the targeted plan artifact, got %s/%s", run.Spec.Artifact.Run, run.Spec.Artifact.Attempt)
+	}
+	if run.Spec.Layer.Revision != "ghi789" {
+		t.Fatalf("expected the apply to use the commit of the targeted plan, got %s", run.Spec.Layer.Revision)
+	}
+}
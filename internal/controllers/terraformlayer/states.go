@@ -7,10 +7,14 @@ import (
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"github.com/padok-team/burrito/internal/annotations"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
 	"github.com/padok-team/burrito/internal/utils/syncwindow"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 type Handler func(context.Context, *Reconciler, *configv1alpha1.TerraformLayer, *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun)
@@ -22,28 +26,80 @@ type State interface {
 func (r *Reconciler) GetState(ctx context.Context, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (State, []metav1.Condition) {
 	log := log.WithContext(ctx)
 	c1, IsRunning := r.IsRunning(layer)
-	c2, IsLastPlanTooOld := r.IsLastPlanTooOld(layer)
+	c2, IsLastPlanTooOld := r.IsLastPlanTooOld(layer, repo)
 	c3, IsLastRelevantCommitPlanned := r.IsLastRelevantCommitPlanned(layer)
 	c4, HasLastPlanFailed := r.HasLastPlanFailed(layer)
 	c5, IsApplyUpToDate := r.IsApplyUpToDate(layer)
 	c6, IsSyncScheduled := r.IsSyncScheduled(layer)
 	c7, retryInfo := r.HasLastRunReachedRetryLimit(layer, repo)
-	conditions := []metav1.Condition{c1, c2, c3, c4, c5, c6, c7}
+	c8, IsLastPlanRejected := r.IsLastPlanRejected(layer)
+	c9, dependencies := r.AreDependenciesReady(layer)
+	c10, IsLastDriftCheckTooOld := r.IsLastDriftCheckTooOld(layer, repo)
+	c11, _ := r.HasDrifted(layer)
+	c12, AreInputsUpToDate := r.AreInputsUpToDate(layer)
+	c13, IsLastPlanDenied := r.IsLastPlanDenied(layer)
+	c14, destructiveChanges := r.IsLastPlanDestructive(layer)
+	c15, _ := r.IsRevisionPinned(layer)
+	c16, IsSuspended := r.IsSuspended(layer, repo)
+	c17, IsLastRunCancelled := r.IsLastRunCancelled(layer)
+	c18, _ := r.AreOutputsPublished(layer)
+	c19, IsTargetedPlanApproved := r.IsTargetedPlanApproved(layer)
+	conditions := []metav1.Condition{c1, c2, c3, c4, c5, c6, c7, c8, c9, c10, c11, c12, c13, c14, c15, c16, c17, c18, c19}
 	LastPlanExhausted := retryInfo.reachedLimit && retryInfo.action == string(PlanAction)
 	LastApplyExhausted := retryInfo.reachedLimit && retryInfo.action == string(ApplyAction)
+	LastDriftCheckExhausted := retryInfo.reachedLimit && retryInfo.action == string(DriftCheckAction)
+	IsPlanNeeded := (IsLastPlanTooOld || !IsLastRelevantCommitPlanned || !AreInputsUpToDate) && !LastPlanExhausted
+	IsApplyNeeded := !IsApplyUpToDate && !HasLastPlanFailed && !LastApplyExhausted
+	IsDriftCheckNeeded := IsLastDriftCheckTooOld && !HasLastPlanFailed && !LastDriftCheckExhausted
 	switch {
+	case IsSuspended:
+		log.Infof("layer %s is suspended, no run is created", layer.Name)
+		return &Suspended{}, conditions
+	case !layer.DeletionTimestamp.IsZero():
+		log.Infof("layer %s is being deleted, its infrastructure must be destroyed", layer.Name)
+		return &DestroyNeeded{retry: IsSyncScheduled}, conditions
 	case IsRunning:
 		log.Infof("layer %s is running, waiting for the run to finish", layer.Name)
 		return &Idle{}, conditions
 	case IsSyncScheduled:
 		log.Infof("layer %s has a sync scheduled, creating a new run", layer.Name)
 		return &PlanNeeded{}, conditions
-	case (IsLastPlanTooOld || !IsLastRelevantCommitPlanned) && !LastPlanExhausted:
+	case IsLastRunCancelled:
+		log.Infof("layer %s last run has been cancelled, waiting for a new commit or a manual sync", layer.Name)
+		return &Idle{}, conditions
+	case dependencies.cycle != nil:
+		log.Infof("layer %s is part of a dependency cycle, requires manual intervention", layer.Name)
+		return &DependencyCycle{cycle: dependencies.cycle}, conditions
+	case IsTargetedPlanApproved:
+		log.Infof("layer %s has an approved targeted plan, creating a new run", layer.Name)
+		return &TargetedApplyNeeded{}, conditions
+	case (IsPlanNeeded || IsApplyNeeded) && !dependencies.ready:
+		log.Infof("layer %s has dependencies that are not ready, waiting for them", layer.Name)
+		return &DependenciesPending{}, conditions
+	case IsPlanNeeded:
 		log.Infof("layer %s has an outdated plan, creating a new run", layer.Name)
 		return &PlanNeeded{}, conditions
-	case !IsApplyUpToDate && !HasLastPlanFailed && !LastApplyExhausted:
+	case IsApplyNeeded && IsLastPlanDenied && c13.Reason == "PolicyNotEvaluated":
+		log.Infof("layer %s last plan has not been checked against the policies, waiting for the policy check", layer.Name)
+		return &PolicyDenied{notEvaluated: true}, conditions
+	case IsApplyNeeded && IsLastPlanDenied:
+		log.Infof("layer %s last plan has been denied by a policy, waiting for a new plan", layer.Name)
+		return &PolicyDenied{}, conditions
+	case IsApplyNeeded && destructiveChanges == runnerutils.DestructiveChangesDenied:
+		log.Infof("layer %s last plan deletes or replaces resources, which is denied, waiting for a new plan", layer.Name)
+		return &DestructiveChangesDenied{}, conditions
+	case IsApplyNeeded && configv1alpha1.GetAutoApplyEnabled(repo, layer) && destructiveChanges != runnerutils.DestructiveChangesApprovalRequired:
 		log.Infof("layer %s needs to be applied, creating a new run", layer.Name)
 		return &ApplyNeeded{}, conditions
+	case IsApplyNeeded && !IsLastPlanRejected:
+		log.Infof("layer %s needs to be applied, waiting for a manual approval", layer.Name)
+		return &ApprovalPending{}, conditions
+	case IsDriftCheckNeeded:
+		log.Infof("layer %s has an outdated drift check, creating a new run", layer.Name)
+		return &DriftCheckNeeded{}, conditions
+	case IsApplyNeeded:
+		log.Infof("layer %s last plan has been rejected, waiting for a new plan", layer.Name)
+		return &Idle{}, conditions
 	case LastPlanExhausted || LastApplyExhausted:
 		log.Infof("layer %s has reached max retries for %s action, requires manual intervention", layer.Name, retryInfo.action)
 		return &MaxRetriesReached{}, conditions
@@ -56,6 +112,16 @@ func (r *Reconciler) GetState(ctx context.Context, layer *configv1alpha1.Terrafo
 type Idle struct{}
 
 func (s *Idle) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		return ctrl.Result{RequeueAfter: r.getIdleRequeue(layer, repository)}, nil
+	}
+}
+
+// Suspended is like Idle, until the suspension of the layer or of its
+// repository is lifted. The destroy of a deleted layer is suspended as well.
+type Suspended struct{}
+
+func (s *Suspended) getHandler() Handler {
 	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
 		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
 	}
@@ -70,7 +136,7 @@ func (s *PlanNeeded) getHandler() Handler {
 		if isActionBlocked(r, layer, repository, syncwindow.PlanAction) {
 			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
 		}
-		revision, ok := layer.Annotations[annotations.LastBranchCommit]
+		revision, ok := GetRevision(layer)
 		if !ok {
 			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Layer has no last branch commit annotation, Plan run not created")
 			log.Errorf("layer %s has no last branch commit annotation, run not created", layer.Name)
@@ -88,21 +154,43 @@ func (s *PlanNeeded) getHandler() Handler {
 	}
 }
 
+type DriftCheckNeeded struct{}
+
+func (s *DriftCheckNeeded) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		log := log.WithContext(ctx)
+		// Check for sync windows that would block the plan action
+		if isActionBlocked(r, layer, repository, syncwindow.PlanAction) {
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+		}
+		revision, ok := GetRevision(layer)
+		if !ok {
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Layer has no last branch commit annotation, DriftCheck run not created")
+			log.Errorf("layer %s has no last branch commit annotation, run not created", layer.Name)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		run := r.getRun(layer, revision, DriftCheckAction)
+		err := r.Client.Create(ctx, &run)
+		if err != nil {
+			r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Failed to create TerraformRun for DriftCheck action: %s", err)
+			log.Errorf("failed to create TerraformRun for DriftCheck action on layer %s: %s", layer.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		r.Recorder.Event(layer, corev1.EventTypeNormal, "Reconciliation", "Created TerraformRun for DriftCheck action")
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, &run
+	}
+}
+
 type ApplyNeeded struct{}
 
 func (s *ApplyNeeded) getHandler() Handler {
 	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
 		log := log.WithContext(ctx)
-		autoApply := configv1alpha1.GetAutoApplyEnabled(repository, layer)
-		if !autoApply {
-			log.Infof("autoApply is disabled for layer %s, no apply action taken", layer.Name)
-			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
-		}
 		// Check for sync windows that would block the apply action
 		if isActionBlocked(r, layer, repository, syncwindow.ApplyAction) {
 			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
 		}
-		revision, ok := layer.Annotations[annotations.LastBranchCommit]
+		revision, ok := GetRevision(layer)
 		if !ok {
 			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Layer has no last branch commit annotation, Apply run not created")
 			log.Errorf("layer %s has no last branch commit annotation, run not created", layer.Name)
@@ -120,6 +208,252 @@ func (s *ApplyNeeded) getHandler() Handler {
 	}
 }
 
+type ApprovalPending struct{}
+
+func (s *ApprovalPending) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		log := log.WithContext(ctx)
+		approvedPlan, ok := layer.Annotations[annotations.ApprovePlan]
+		if !ok {
+			if layer.Annotations[annotations.LastPlanDestructiveChanges] == runnerutils.DestructiveChangesApprovalRequired {
+				r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Last plan deletes or replaces resources, waiting for a manual approval")
+			}
+			log.Infof("layer %s has a plan waiting for a manual approval", layer.Name)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
+		}
+		lastPlan := layer.Annotations[annotations.LastPlanRun]
+		if approvedPlan != lastPlan {
+			r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Approval of plan %s discarded, it has been replaced by plan %s", approvedPlan, lastPlan)
+			log.Warningf("approval of plan %s on layer %s discarded, it has been replaced by plan %s", approvedPlan, layer.Name, lastPlan)
+			err := annotations.Remove(ctx, r.Client, layer, annotations.ApprovePlan)
+			if err != nil {
+				log.Errorf("failed to remove annotation %s from layer %s: %s", annotations.ApprovePlan, layer.Name, err)
+			}
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
+		}
+		// Check for sync windows that would block the apply action
+		if isActionBlocked(r, layer, repository, syncwindow.ApplyAction) {
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+		}
+		// The apply must run on the commit that has been planned and approved
+		revision, ok := layer.Annotations[annotations.LastPlanCommit]
+		if !ok {
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Layer has no last plan commit annotation, Apply run not created")
+			log.Errorf("layer %s has no last plan commit annotation, run not created", layer.Name)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		run := r.getRun(layer, revision, ApplyAction)
+		err := r.Client.Create(ctx, &run)
+		if err != nil {
+			r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Failed to create TerraformRun for approved Apply action: %s", err)
+			log.Errorf("failed to create TerraformRun for approved Apply action on layer %s: %s", layer.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		err = annotations.Remove(ctx, r.Client, layer, annotations.ApprovePlan)
+		if err != nil {
+			log.Errorf("failed to remove annotation %s from layer %s: %s", annotations.ApprovePlan, layer.Name, err)
+		}
+		r.Recorder.Eventf(layer, corev1.EventTypeNormal, "Reconciliation", "Created TerraformRun for approved plan %s", approvedPlan)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, &run
+	}
+}
+
+// TargetedApplyNeeded applies the last targeted plan of the layer, which has
+// been explicitly approved. The plan is discarded if the policies or the
+// destructive changes strategy of the layer deny it.
+type TargetedApplyNeeded struct{}
+
+func (s *TargetedApplyNeeded) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		log := log.WithContext(ctx)
+		targetedPlan := layer.Annotations[annotations.LastTargetedPlanRun]
+		// Check for sync windows that would block the apply action
+		if isActionBlocked(r, layer, repository, syncwindow.ApplyAction) {
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+		}
+		if layer.Annotations[annotations.LastTargetedPlanDestructiveChanges] == runnerutils.DestructiveChangesDenied {
+			discardTargetedPlan(ctx, r, layer, "it deletes or replaces resources, which is denied")
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+		}
+		denied, err := r.isTargetedPlanDenied(ctx, layer)
+		if err != nil {
+			r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Could not check targeted plan %s against the policies: %s", targetedPlan, err)
+			log.Errorf("could not check targeted plan %s of layer %s against the policies: %s", targetedPlan, layer.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		if denied {
+			discardTargetedPlan(ctx, r, layer, "it has been denied by a policy")
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+		}
+		run := r.getTargetedApplyRun(layer)
+		err = r.Client.Create(ctx, &run)
+		if err != nil {
+			r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Failed to create TerraformRun for approved targeted Apply action: %s", err)
+			log.Errorf("failed to create TerraformRun for approved targeted Apply action on layer %s: %s", layer.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		// The targeted plan is applied once, a new one must be planned to
+		// apply the same resources again
+		err = annotations.RemoveAll(ctx, r.Client, layer, annotations.TargetedPlan...)
+		if err != nil {
+			log.Errorf("failed to remove the targeted plan annotations from layer %s: %s", layer.Name, err)
+		}
+		r.Recorder.Eventf(layer, corev1.EventTypeNormal, "Reconciliation", "Created TerraformRun for approved targeted plan %s", targetedPlan)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, &run
+	}
+}
+
+// discardTargetedPlan removes the last targeted plan of the layer, which
+// cannot be applied
+func discardTargetedPlan(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, reason string) {
+	targetedPlan := layer.Annotations[annotations.LastTargetedPlanRun]
+	r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Targeted plan %s discarded, %s", targetedPlan, reason)
+	log.WithContext(ctx).Warningf("targeted plan %s of layer %s discarded, %s", targetedPlan, layer.Name, reason)
+	err := annotations.RemoveAll(ctx, r.Client, layer, annotations.TargetedPlan...)
+	if err != nil {
+		log.WithContext(ctx).Errorf("failed to remove the targeted plan annotations from layer %s: %s", layer.Name, err)
+	}
+}
+
+func getPendingApproval(layer *configv1alpha1.TerraformLayer) configv1alpha1.TerraformLayerPendingApproval {
+	pendingApproval := configv1alpha1.TerraformLayerPendingApproval{
+		Sum:    layer.Annotations[annotations.LastPlanSum],
+		Commit: layer.Annotations[annotations.LastPlanCommit],
+	}
+	run := strings.Split(layer.Annotations[annotations.LastPlanRun], "/")
+	if len(run) == 2 {
+		pendingApproval.Run = run[0]
+		pendingApproval.Attempt = run[1]
+	}
+	return pendingApproval
+}
+
+type DependenciesPending struct{}
+
+func (s *DependenciesPending) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		// Dependencies are not watched, check them again after a while
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+	}
+}
+
+type DependencyCycle struct {
+	cycle []string
+}
+
+func (s *DependencyCycle) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		// A dependency cycle can only be fixed by editing the layers
+		r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Dependency cycle detected: %s, check the dependsOn field of the layers", strings.Join(s.cycle, " -> "))
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
+	}
+}
+
+type DestroyNeeded struct {
+	retry bool
+}
+
+func (s *DestroyNeeded) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		log := log.WithContext(ctx)
+		if layer.Status.LastRun.Name != "" {
+			lastRun := &configv1alpha1.TerraformRun{}
+			err := r.Client.Get(ctx, types.NamespacedName{
+				Namespace: layer.Namespace,
+				Name:      layer.Status.LastRun.Name,
+			}, lastRun)
+			if err != nil && !errors.IsNotFound(err) {
+				log.Errorf("failed to get last run of layer %s: %s", layer.Name, err)
+				return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+			}
+			if err == nil {
+				if lastRun.Status.State != "Succeeded" && lastRun.Status.State != "Failed" && lastRun.Status.State != "Cancelled" {
+					log.Infof("layer %s is running, waiting for run %s to finish before destroying", layer.Name, lastRun.Name)
+					return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+				}
+				if lastRun.Spec.Action == string(DestroyAction) && lastRun.Status.State == "Succeeded" {
+					return removeDestroyFinalizer(ctx, r, layer, lastRun), nil
+				}
+				if lastRun.Spec.Action == string(DestroyAction) && !s.retry {
+					r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Destroy run %s has failed, check its logs and trigger a sync on the layer to retry", lastRun.Name)
+					return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
+				}
+			}
+		}
+		if configv1alpha1.GetTerragruntStackEnabled(repository, layer) {
+			// Only reachable when the stack mode is enabled on the repository,
+			// the layer validation rejects Destroy on stack layers
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "The Destroy deletion policy is not supported for terragrunt stacks, set the deletion policy of the layer to Orphan to delete it")
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
+		}
+		// Check for sync windows that would block the destroy action
+		if isActionBlocked(r, layer, repository, syncwindow.ApplyAction) {
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, nil
+		}
+		revision, ok := GetRevision(layer)
+		if !ok {
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Layer has no last branch commit annotation, Destroy run not created")
+			log.Errorf("layer %s has no last branch commit annotation, run not created", layer.Name)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		run := r.getRun(layer, revision, DestroyAction)
+		err := r.Client.Create(ctx, &run)
+		if err != nil {
+			r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Failed to create TerraformRun for Destroy action: %s", err)
+			log.Errorf("failed to create TerraformRun for Destroy action on layer %s: %s", layer.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		r.Recorder.Event(layer, corev1.EventTypeNormal, "Reconciliation", "Created TerraformRun for Destroy action")
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, &run
+	}
+}
+
+// removeDestroyFinalizer lets Kubernetes delete the layer once the logs of the
+// destroy run have been stored in the datastore
+func removeDestroyFinalizer(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, run *configv1alpha1.TerraformRun) ctrl.Result {
+	attempts := run.Status.Attempts
+	if len(attempts) == 0 || !attempts[len(attempts)-1].LogsUploaded {
+		log.Infof("waiting for the logs of destroy run %s to be uploaded before deleting layer %s", run.Name, layer.Name)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}
+	}
+	controllerutil.RemoveFinalizer(layer, DestroyFinalizer)
+	err := r.Client.Update(ctx, layer)
+	if err != nil {
+		r.Recorder.Eventf(layer, corev1.EventTypeWarning, "Reconciliation", "Could not remove destroy finalizer: %s", err)
+		log.Errorf("could not remove destroy finalizer from layer %s: %s", layer.Name, err)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}
+	}
+	r.Recorder.Eventf(layer, corev1.EventTypeNormal, "Reconciliation", "Layer infrastructure has been destroyed by run %s", run.Name)
+	return ctrl.Result{}
+}
+
+type PolicyDenied struct {
+	notEvaluated bool
+}
+
+func (s *PolicyDenied) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		if s.notEvaluated {
+			// The policies are evaluated again on the next reconciliation
+			r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Last plan has not been checked against the policies, it cannot be applied")
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
+		}
+		// The plan cannot be applied, a new plan is needed
+		r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Last plan has been denied by a policy, check the policy report of the last run")
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
+	}
+}
+
+type DestructiveChangesDenied struct{}
+
+func (s *DestructiveChangesDenied) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository) (ctrl.Result, *configv1alpha1.TerraformRun) {
+		// The plan cannot be applied, even if approved, a new plan is needed
+		r.Recorder.Event(layer, corev1.EventTypeWarning, "Reconciliation", "Last plan deletes or replaces resources, which is denied by the remediation strategy")
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.DriftDetection}, nil
+	}
+}
+
 type MaxRetriesReached struct{}
 
 func (s *MaxRetriesReached) getHandler() Handler {
@@ -5,13 +5,18 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"github.com/padok-team/burrito/internal/annotations"
 	"github.com/padok-team/burrito/internal/burrito/config"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 type createErrorClient struct {
@@ -90,6 +95,516 @@ func TestApplyNeededEventIncludesCreateError(t *testing.T) {
 	assertEventContains(t, recorder, "Failed to create TerraformRun for Apply action: "+createErr.Error())
 }
 
+func TestPlanNeededUsesPinnedRevision(t *testing.T) {
+	layer := approvalPendingLayer("")
+	layer.Annotations[annotations.PinnedRevision] = "abc123"
+	reconciler, _ := newApprovalTestReconciler(t, layer)
+
+	_, run := (&PlanNeeded{}).getHandler()(context.Background(), reconciler, layer, &configv1alpha1.TerraformRepository{})
+
+	if run == nil {
+		t.Fatalf("expected a plan run to be created")
+	}
+	if run.Spec.Layer.Revision != "abc123" {
+		t.Fatalf("expected run to use the pinned revision, got %s", run.Spec.Layer.Revision)
+	}
+	_, planned := reconciler.IsLastRelevantCommitPlanned(layer)
+	if !planned {
+		t.Fatalf("expected the pinned revision to be considered planned")
+	}
+	layer.Annotations[annotations.PinnedRevision] = "012def"
+	_, planned = reconciler.IsLastRelevantCommitPlanned(layer)
+	if planned {
+		t.Fatalf("expected a new pinned revision to need a plan")
+	}
+}
+
+func TestGetStateSuspended(t *testing.T) {
+	layer := approvalPendingLayer("plan-run/1")
+	reconciler, _ := newApprovalTestReconciler(t, layer)
+	repository := &configv1alpha1.TerraformRepository{ObjectMeta: metav1.ObjectMeta{Name: "repo"}}
+
+	layer.Spec.Suspend = true
+	state, _ := reconciler.GetState(context.Background(), layer, repository)
+	if _, ok := state.(*Suspended); !ok {
+		t.Fatalf("expected a suspended layer to be in Suspended state, got %s", getStateString(state))
+	}
+
+	layer.Spec.Suspend = false
+	repository.Spec.Suspend = true
+	state, _ = reconciler.GetState(context.Background(), layer, repository)
+	if _, ok := state.(*Suspended); !ok {
+		t.Fatalf("expected a layer of a suspended repository to be in Suspended state, got %s", getStateString(state))
+	}
+
+	_, run := state.getHandler()(context.Background(), reconciler, layer, repository)
+	if run != nil {
+		t.Fatalf("expected no run to be created for a suspended layer")
+	}
+}
+
+func TestGetStateApprovalPendingBeforeDriftCheck(t *testing.T) {
+	now := time.Now()
+	layer := approvalPendingLayer("")
+	layer.Annotations[annotations.LastRelevantCommit] = "abc123"
+	layer.Annotations[annotations.LastPlanDate] = now.Format(time.UnixDate)
+	reconciler, _ := newApprovalTestReconciler(t, layer)
+	reconciler.Clock = fixedClock{now: now}
+
+	state, _ := reconciler.GetState(context.Background(), layer, &configv1alpha1.TerraformRepository{})
+	if _, ok := state.(*ApprovalPending); !ok {
+		t.Fatalf("expected a layer waiting for approval to be in ApprovalPending state even if its drift check is outdated, got %s", getStateString(state))
+	}
+}
+
+func TestIsLastRunCancelled(t *testing.T) {
+	layer := approvalPendingLayer("")
+	layer.Status.LastRun = configv1alpha1.TerraformLayerRun{Name: "cancelled-run"}
+	reconciler, cl := newApprovalTestReconciler(t, layer)
+	run := &configv1alpha1.TerraformRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cancelled-run",
+			Namespace:   "default",
+			Annotations: map[string]string{annotations.CancelRun: "alice@example.com"},
+		},
+		Spec: configv1alpha1.TerraformRunSpec{
+			Layer: configv1alpha1.TerraformRunLayer{Name: "layer", Revision: "def456"},
+		},
+		Status: configv1alpha1.TerraformRunStatus{State: "Cancelled"},
+	}
+	if err := cl.Create(context.Background(), run); err != nil {
+		t.Fatalf("failed to create run: %s", err)
+	}
+
+	condition, cancelled := reconciler.IsLastRunCancelled(layer)
+	if !cancelled {
+		t.Fatalf("expected the last run to be cancelled, got reason %s", condition.Reason)
+	}
+
+	layer.Annotations[annotations.LastBranchCommit] = "ghi789"
+	condition, cancelled = reconciler.IsLastRunCancelled(layer)
+	if cancelled {
+		t.Fatalf("expected a new commit to clear the cancellation, got reason %s", condition.Reason)
+	}
+}
+
+func TestApprovalPendingCreatesApplyRunForApprovedPlan(t *testing.T) {
+	layer := approvalPendingLayer("plan-run/1")
+	reconciler, cl := newApprovalTestReconciler(t, layer)
+
+	result, run := (&ApprovalPending{}).getHandler()(context.Background(), reconciler, layer, &configv1alpha1.TerraformRepository{})
+
+	if run == nil {
+		t.Fatalf("expected an apply run to be created")
+	}
+	if run.Spec.Action != string(ApplyAction) {
+		t.Fatalf("expected apply action, got %s", run.Spec.Action)
+	}
+	if run.Spec.Artifact.Run != "plan-run" || run.Spec.Artifact.Attempt != "1" {
+		t.Fatalf("expected run to be bound to plan-run/1, got %s/%s", run.Spec.Artifact.Run, run.Spec.Artifact.Attempt)
+	}
+	if run.Spec.Layer.Revision != "abc123" {
+		t.Fatalf("expected run to use the planned commit, got %s", run.Spec.Layer.Revision)
+	}
+	if result.RequeueAfter != reconciler.Config.Controller.Timers.WaitAction {
+		t.Fatalf("expected WaitAction requeue, got %s", result.RequeueAfter)
+	}
+	assertApprovalAnnotationRemoved(t, cl, layer)
+}
+
+func TestApprovalPendingDiscardsApprovalOfReplacedPlan(t *testing.T) {
+	layer := approvalPendingLayer("plan-run/0")
+	reconciler, cl := newApprovalTestReconciler(t, layer)
+
+	result, run := (&ApprovalPending{}).getHandler()(context.Background(), reconciler, layer, &configv1alpha1.TerraformRepository{})
+
+	if run != nil {
+		t.Fatalf("expected no run when the approved plan has been replaced")
+	}
+	if result.RequeueAfter != reconciler.Config.Controller.Timers.DriftDetection {
+		t.Fatalf("expected DriftDetection requeue, got %s", result.RequeueAfter)
+	}
+	assertEventContains(t, reconciler.Recorder.(*record.FakeRecorder), "Approval of plan plan-run/0 discarded")
+	assertApprovalAnnotationRemoved(t, cl, layer)
+}
+
+func TestPolicyDeniedDoesNotCreateApplyRun(t *testing.T) {
+	recorder := record.NewFakeRecorder(1)
+	reconciler := &Reconciler{
+		Recorder: recorder,
+		Config:   config.TestConfig(),
+	}
+	layer := &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "denied",
+			Namespace: "default",
+			Annotations: map[string]string{
+				annotations.LastPlanRun: "plan-run/0",
+				annotations.LastPlanSum: "sum",
+			},
+		},
+		Status: configv1alpha1.TerraformLayerStatus{
+			Policy: configv1alpha1.TerraformLayerPolicyStatus{Run: "plan-run/0", Sum: "sum", Result: "Denied"},
+		},
+	}
+
+	condition, denied := reconciler.IsLastPlanDenied(layer)
+	if !denied || condition.Reason != "PolicyDenied" {
+		t.Fatalf("expected last plan to be denied, got %s", condition.Reason)
+	}
+	result, run := (&PolicyDenied{}).getHandler()(context.Background(), reconciler, layer, &configv1alpha1.TerraformRepository{})
+
+	if run != nil {
+		t.Fatalf("expected no run when the last plan has been denied")
+	}
+	if result.RequeueAfter != reconciler.Config.Controller.Timers.DriftDetection {
+		t.Fatalf("expected DriftDetection requeue, got %s", result.RequeueAfter)
+	}
+	assertEventContains(t, recorder, "Last plan has been denied by a policy")
+}
+
+func TestIsLastPlanDeniedIgnoresVerdictOfAnotherPlan(t *testing.T) {
+	reconciler := &Reconciler{}
+	layer := approvalPendingLayer("")
+	// A runner can rewrite the annotations of the layer, not its status
+	layer.Status.Policy = configv1alpha1.TerraformLayerPolicyStatus{Run: "plan-run/0", Sum: "old-sum", Result: "Passed"}
+
+	condition, denied := reconciler.IsLastPlanDenied(layer)
+	if !denied || condition.Reason != "PolicyNotEvaluated" {
+		t.Fatalf("expected a plan whose policies have not been evaluated to be denied, got %s", condition.Reason)
+	}
+}
+
+func TestGetStatePolicyDeniedTakesPrecedence(t *testing.T) {
+	autoApply := true
+	repository := &configv1alpha1.TerraformRepository{
+		Spec: configv1alpha1.TerraformRepositorySpec{
+			RemediationStrategy: configv1alpha1.RemediationStrategy{
+				AutoApply: &autoApply,
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		repository *configv1alpha1.TerraformRepository
+		approved   string
+	}{
+		{name: "approved plan", repository: &configv1alpha1.TerraformRepository{}, approved: "plan-run/1"},
+		{name: "auto apply", repository: repository},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			layer := approvalPendingLayer(tt.approved)
+			layer.Annotations[annotations.LastRelevantCommit] = "abc123"
+			layer.Annotations[annotations.LastPlanDate] = now.Format(time.UnixDate)
+			reconciler, _ := newApprovalTestReconciler(t, layer)
+			reconciler.Clock = fixedClock{now: now}
+
+			layer.Status.Policy.Result = "Passed"
+			state, _ := reconciler.GetState(context.Background(), layer, tt.repository)
+			if _, ok := state.(*PolicyDenied); ok {
+				t.Fatalf("expected a plan complying with the policies not to be denied")
+			}
+
+			layer.Status.Policy.Result = "Denied"
+			state, _ = reconciler.GetState(context.Background(), layer, tt.repository)
+			if s, ok := state.(*PolicyDenied); !ok || s.notEvaluated {
+				t.Fatalf("expected a denied plan to be in PolicyDenied state, got %s", getStateString(state))
+			}
+
+			layer.Status.Policy = configv1alpha1.TerraformLayerPolicyStatus{}
+			state, _ = reconciler.GetState(context.Background(), layer, tt.repository)
+			if s, ok := state.(*PolicyDenied); !ok || !s.notEvaluated {
+				t.Fatalf("expected a plan whose policies have not been evaluated to be in PolicyDenied state, got %s", getStateString(state))
+			}
+		})
+	}
+}
+
+func TestGetStateTargetedPlan(t *testing.T) {
+	autoApply := true
+	repository := &configv1alpha1.TerraformRepository{
+		Spec: configv1alpha1.TerraformRepositorySpec{
+			RemediationStrategy: configv1alpha1.RemediationStrategy{
+				AutoApply: &autoApply,
+			},
+		},
+	}
+	now := time.Now()
+	layer := targetedPlanLayer("")
+	layer.Annotations[annotations.LastRelevantCommit] = "abc123"
+	layer.Annotations[annotations.LastPlanDate] = now.Format(time.UnixDate)
+	layer.Annotations[annotations.LastApplySum] = "sum"
+	reconciler, _ := newApprovalTestReconciler(t, layer)
+	reconciler.Clock = fixedClock{now: now}
+
+	state, _ := reconciler.GetState(context.Background(), layer, repository)
+	if _, ok := state.(*TargetedApplyNeeded); ok {
+		t.Fatalf("expected a targeted plan not to be applied without an approval, even with autoApply")
+	}
+
+	layer.Annotations[annotations.ApproveTargetedPlan] = "targeted-run/0"
+	state, _ = reconciler.GetState(context.Background(), layer, repository)
+	if _, ok := state.(*TargetedApplyNeeded); !ok {
+		t.Fatalf("expected an approved targeted plan to be applied, got %s", getStateString(state))
+	}
+}
+
+func TestTargetedApplyNeededCreatesTargetedApplyRun(t *testing.T) {
+	layer := targetedPlanLayer("targeted-run/0")
+	reconciler, cl := newApprovalTestReconciler(t, layer)
+
+	_, run := (&TargetedApplyNeeded{}).getHandler()(context.Background(), reconciler, layer, &configv1alpha1.TerraformRepository{})
+
+	if run == nil {
+		t.Fatalf("expected an apply run to be created")
+	}
+	if run.Spec.Artifact.Run != "targeted-run" || len(run.Spec.Targets) != 1 {
+		t.Fatalf("expected the apply of the targeted plan, got %s %v", run.Spec.Artifact.Run, run.Spec.Targets)
+	}
+	updated := &configv1alpha1.TerraformLayer{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(layer), updated); err != nil {
+		t.Fatalf("failed to get layer: %s", err)
+	}
+	for _, key := range annotations.TargetedPlan {
+		if _, ok := updated.Annotations[key]; ok {
+			t.Fatalf("expected annotation %s to be removed once the targeted plan is applied", key)
+		}
+	}
+	if updated.Annotations[annotations.LastPlanRun] != "plan-run/1" {
+		t.Fatalf("expected the last plan of the whole layer to be kept, got %s", updated.Annotations[annotations.LastPlanRun])
+	}
+}
+
+func TestTargetedApplyNeededDiscardsDeniedDestructiveChanges(t *testing.T) {
+	layer := targetedPlanLayer("targeted-run/0")
+	layer.Annotations[annotations.LastTargetedPlanDestructiveChanges] = runnerutils.DestructiveChangesDenied
+	reconciler, cl := newApprovalTestReconciler(t, layer)
+
+	_, run := (&TargetedApplyNeeded{}).getHandler()(context.Background(), reconciler, layer, &configv1alpha1.TerraformRepository{})
+
+	if run != nil {
+		t.Fatalf("expected no run when the targeted plan deletes resources, which is denied")
+	}
+	assertEventContains(t, reconciler.Recorder.(*record.FakeRecorder), "Targeted plan targeted-run/0 discarded")
+	updated := &configv1alpha1.TerraformLayer{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(layer), updated); err != nil {
+		t.Fatalf("failed to get layer: %s", err)
+	}
+	if _, ok := updated.Annotations[annotations.LastTargetedPlanRun]; ok {
+		t.Fatalf("expected the targeted plan to be discarded")
+	}
+}
+
+func TestDestroyNeededCreatesDestroyRun(t *testing.T) {
+	layer := deletingLayer("")
+	reconciler := newDestroyTestReconciler(t, layer)
+
+	result, run := (&DestroyNeeded{}).getHandler()(context.Background(), reconciler, layer, &configv1alpha1.TerraformRepository{})
+
+	if run == nil {
+		t.Fatalf("expected a destroy run to be created")
+	}
+	if run.Spec.Action != string(DestroyAction) {
+		t.Fatalf("expected destroy action, got %s", run.Spec.Action)
+	}
+	if run.Spec.Layer.Revision != "abc123" {
+		t.Fatalf("expected run to use the last branch commit, got %s", run.Spec.Layer.Revision)
+	}
+	if result.RequeueAfter != reconciler.Config.Controller.Timers.WaitAction {
+		t.Fatalf("expected WaitAction requeue, got %s", result.RequeueAfter)
+	}
+}
+
+func TestDestroyNeededWaitsForLogsBeforeRemovingFinalizer(t *testing.T) {
+	layer := deletingLayer("destroy-run")
+	run := destroyRun("Succeeded", false)
+	reconciler := newDestroyTestReconciler(t, layer, run)
+
+	result, created := (&DestroyNeeded{}).getHandler()(context.Background(), reconciler, layer, &configv1alpha1.TerraformRepository{})
+
+	if created != nil {
+		t.Fatalf("expected no run to be created")
+	}
+	if result.RequeueAfter != reconciler.Config.Controller.Timers.WaitAction {
+		t.Fatalf("expected WaitAction requeue, got %s", result.RequeueAfter)
+	}
+	updated := &configv1alpha1.TerraformLayer{}
+	if err := reconciler.Client.Get(context.Background(), client.ObjectKeyFromObject(layer), updated); err != nil {
+		t.Fatalf("expected layer to still exist: %s", err)
+	}
+}
+
+func TestDestroyNeededRemovesFinalizerAfterSuccessfulDestroy(t *testing.T) {
+	layer := deletingLayer("destroy-run")
+	run := destroyRun("Succeeded", true)
+	reconciler := newDestroyTestReconciler(t, layer, run)
+
+	result, created := (&DestroyNeeded{}).getHandler()(context.Background(), reconciler, layer, &configv1alpha1.TerraformRepository{})
+
+	if created != nil {
+		t.Fatalf("expected no run to be created")
+	}
+	if result.RequeueAfter != 0 {
+		t.Fatalf("expected no requeue, got %s", result.RequeueAfter)
+	}
+	err := reconciler.Client.Get(context.Background(), client.ObjectKeyFromObject(layer), &configv1alpha1.TerraformLayer{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("expected layer to be deleted, got %v", err)
+	}
+}
+
+func TestDestroyNeededDoesNotRetryFailedDestroy(t *testing.T) {
+	layer := deletingLayer("destroy-run")
+	run := destroyRun("Failed", true)
+	reconciler := newDestroyTestReconciler(t, layer, run)
+
+	result, created := (&DestroyNeeded{}).getHandler()(context.Background(), reconciler, layer, &configv1alpha1.TerraformRepository{})
+
+	if created != nil {
+		t.Fatalf("expected no run to be created")
+	}
+	if result.RequeueAfter != reconciler.Config.Controller.Timers.DriftDetection {
+		t.Fatalf("expected DriftDetection requeue, got %s", result.RequeueAfter)
+	}
+	assertEventContains(t, reconciler.Recorder.(*record.FakeRecorder), "Destroy run destroy-run has failed")
+}
+
+func TestDestroyNeededRefusesTerragruntStacks(t *testing.T) {
+	layer := deletingLayer("")
+	reconciler := newDestroyTestReconciler(t, layer)
+	stack := true
+	repository := &configv1alpha1.TerraformRepository{
+		Spec: configv1alpha1.TerraformRepositorySpec{
+			TerragruntConfig: configv1alpha1.TerragruntConfig{Enabled: &stack, Stack: &stack},
+		},
+	}
+
+	result, run := (&DestroyNeeded{}).getHandler()(context.Background(), reconciler, layer, repository)
+
+	if run != nil {
+		t.Fatalf("expected no destroy run for a terragrunt stack")
+	}
+	if result.RequeueAfter != reconciler.Config.Controller.Timers.DriftDetection {
+		t.Fatalf("expected DriftDetection requeue, got %s", result.RequeueAfter)
+	}
+	assertEventContains(t, reconciler.Recorder.(*record.FakeRecorder), "not supported for terragrunt stacks")
+}
+
+func deletingLayer(lastRun string) *configv1alpha1.TerraformLayer {
+	now := metav1.Now()
+	return &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "layer",
+			Namespace:         "default",
+			DeletionTimestamp: &now,
+			Finalizers:        []string{DestroyFinalizer},
+			Annotations: map[string]string{
+				annotations.LastBranchCommit: "abc123",
+			},
+		},
+		Spec: configv1alpha1.TerraformLayerSpec{
+			DeletionPolicy: configv1alpha1.DeletionPolicyDestroy,
+		},
+		Status: configv1alpha1.TerraformLayerStatus{
+			LastRun: configv1alpha1.TerraformLayerRun{Name: lastRun},
+		},
+	}
+}
+
+func destroyRun(state string, logsUploaded bool) *configv1alpha1.TerraformRun {
+	return &configv1alpha1.TerraformRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "destroy-run",
+			Namespace: "default",
+		},
+		Spec: configv1alpha1.TerraformRunSpec{
+			Action: string(DestroyAction),
+		},
+		Status: configv1alpha1.TerraformRunStatus{
+			State: state,
+			Attempts: []configv1alpha1.Attempt{
+				{PodName: "destroy-run-pod", Number: 0, LogsUploaded: logsUploaded},
+			},
+		},
+	}
+}
+
+func newDestroyTestReconciler(t *testing.T, objects ...client.Object) *Reconciler {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := configv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add burrito types to scheme: %s", err)
+	}
+	return &Reconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Recorder: record.NewFakeRecorder(1),
+		Config:   config.TestConfig(),
+	}
+}
+
+func approvalPendingLayer(approvedPlan string) *configv1alpha1.TerraformLayer {
+	return &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "layer",
+			Namespace: "default",
+			Annotations: map[string]string{
+				annotations.LastBranchCommit: "def456",
+				annotations.LastPlanCommit:   "abc123",
+				annotations.LastPlanRun:      "plan-run/1",
+				annotations.LastPlanSum:      "sum",
+				annotations.ApprovePlan:      approvedPlan,
+			},
+		},
+		Status: configv1alpha1.TerraformLayerStatus{
+			Policy: configv1alpha1.TerraformLayerPolicyStatus{Run: "plan-run/1", Sum: "sum", Result: "None"},
+		},
+	}
+}
+
+// targetedPlanLayer returns a layer waiting for approval with a targeted plan
+func targetedPlanLayer(approvedPlan string) *configv1alpha1.TerraformLayer {
+	layer := approvalPendingLayer("")
+	layer.Annotations[annotations.LastTargetedPlanRun] = "targeted-run/0"
+	layer.Annotations[annotations.LastTargetedPlanSum] = "targeted-sum"
+	layer.Annotations[annotations.LastTargetedPlanCommit] = "ghi789"
+	layer.Annotations[annotations.LastPlanTargets] = `["module.app"]`
+	layer.Annotations[annotations.LastPlanReplace] = `["module.app.aws_instance.web"]`
+	if approvedPlan != "" {
+		layer.Annotations[annotations.ApproveTargetedPlan] = approvedPlan
+	}
+	return layer
+}
+
+func newApprovalTestReconciler(t *testing.T, layer *configv1alpha1.TerraformLayer) (*Reconciler, client.Client) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := configv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add burrito types to scheme: %s", err)
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(layer).Build()
+	return &Reconciler{
+		Client:   cl,
+		Recorder: record.NewFakeRecorder(1),
+		Config:   config.TestConfig(),
+	}, cl
+}
+
+func assertApprovalAnnotationRemoved(t *testing.T, cl client.Client, layer *configv1alpha1.TerraformLayer) {
+	t.Helper()
+
+	updated := &configv1alpha1.TerraformLayer{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(layer), updated); err != nil {
+		t.Fatalf("failed to get layer: %s", err)
+	}
+	if _, ok := updated.Annotations[annotations.ApprovePlan]; ok {
+		t.Fatalf("expected annotation %s to be removed", annotations.ApprovePlan)
+	}
+}
+
 func assertEventContains(t *testing.T, recorder *record.FakeRecorder, want string) {
 	t.Helper()
 
@@ -102,3 +617,23 @@ func assertEventContains(t *testing.T, recorder *record.FakeRecorder, want strin
 		t.Fatalf("expected event containing %q, got none", want)
 	}
 }
+
+func TestAreOutputsPublished(t *testing.T) {
+	layer := approvalPendingLayer("")
+	reconciler, _ := newApprovalTestReconciler(t, layer)
+
+	condition, _ := reconciler.AreOutputsPublished(layer)
+	if condition.Status != metav1.ConditionUnknown {
+		t.Fatalf("expected an unknown status before the first apply, got %s", condition.Status)
+	}
+	layer.Annotations[annotations.LastApplySum] = "sum"
+	condition, published := reconciler.AreOutputsPublished(layer)
+	if !published {
+		t.Fatalf("expected the outputs to be published, got reason %s", condition.Reason)
+	}
+	layer.Annotations[annotations.LastOutputsError] = "could not store outputs in datastore: timeout"
+	condition, published = reconciler.AreOutputsPublished(layer)
+	if published || condition.Status != metav1.ConditionFalse || condition.Message != layer.Annotations[annotations.LastOutputsError] {
+		t.Fatalf("expected the publication failure to be reported, got %s: %s", condition.Status, condition.Message)
+	}
+}
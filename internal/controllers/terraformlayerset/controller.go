@@ -0,0 +1,262 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terraformlayerset
+
+import (
+	"context"
+	"fmt"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/burrito/config"
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	StateSynced               string = "Synced"
+	StateSyncFailed           string = "SyncFailed"
+	StateWaitingForRepository string = "WaitingForRepository"
+)
+
+// Reconciler reconciles a TerraformLayerSet object
+type Reconciler struct {
+	client.Client
+	Scheme    *runtime.Scheme
+	Config    *config.Config
+	Recorder  record.EventRecorder
+	Datastore datastore.Client
+}
+
+//+kubebuilder:rbac:groups=config.terraform.padok.cloud,resources=terraformlayersets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=config.terraform.padok.cloud,resources=terraformlayersets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=config.terraform.padok.cloud,resources=terraformlayersets/finalizers,verbs=update
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.13.0/pkg/reconcile
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.WithContext(ctx)
+	log.Infof("starting reconciliation for layer set %s/%s ...", req.Namespace, req.Name)
+	set := &configv1alpha1.TerraformLayerSet{}
+	err := r.Client.Get(ctx, req.NamespacedName, set)
+	if errors.IsNotFound(err) {
+		log.Errorf("resource not found. Ignoring since object must be deleted: %s", err)
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		log.Errorf("failed to get TerraformLayerSet: %s", err)
+		return ctrl.Result{}, err
+	}
+	repositoryNamespace := set.Spec.Repository.Namespace
+	if repositoryNamespace == "" {
+		repositoryNamespace = set.Namespace
+	}
+	repository := &configv1alpha1.TerraformRepository{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: repositoryNamespace, Name: set.Spec.Repository.Name}, repository)
+	if err != nil {
+		log.Errorf("failed to get TerraformRepository linked to layer set %s: %s", set.Name, err)
+		return r.updateStatus(ctx, set, StateWaitingForRepository, set.Status.Revision, set.Status.Layers, ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError})
+	}
+	branch, ok := configv1alpha1.GetBranchState(set.Spec.Branch, repository.Status.Branches)
+	if !ok || branch.LatestRev == "" {
+		log.Infof("branch %s of repository %s/%s has not been synced yet, waiting", set.Spec.Branch, repository.Namespace, repository.Name)
+		return r.updateStatus(ctx, set, StateWaitingForRepository, set.Status.Revision, set.Status.Layers, ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction})
+	}
+	paths, err := r.getLayerPaths(set, repository, branch.LatestRev)
+	if err != nil {
+		r.Recorder.Eventf(set, corev1.EventTypeWarning, "Reconciliation", "Could not discover layers at revision %s: %s", branch.LatestRev, err)
+		log.Errorf("could not discover layers of layer set %s at revision %s: %s", set.Name, branch.LatestRev, err)
+		return r.updateStatus(ctx, set, StateSyncFailed, set.Status.Revision, set.Status.Layers, ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError})
+	}
+	layers, err := r.syncLayers(ctx, set, paths)
+	if err != nil {
+		r.Recorder.Eventf(set, corev1.EventTypeWarning, "Reconciliation", "Could not sync layers: %s", err)
+		log.Errorf("could not sync layers of layer set %s: %s", set.Name, err)
+		return r.updateStatus(ctx, set, StateSyncFailed, set.Status.Revision, layers, ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError})
+	}
+	log.Infof("finished reconciliation cycle for layer set %s/%s", set.Namespace, set.Name)
+	return r.updateStatus(ctx, set, StateSynced, branch.LatestRev, layers, ctrl.Result{RequeueAfter: r.Config.Controller.Timers.RepositorySync})
+}
+
+// getLayerPaths returns the paths of the layers of the set. The repository
+// files are only listed again when the revision or the set spec has changed.
+func (r *Reconciler) getLayerPaths(set *configv1alpha1.TerraformLayerSet, repository *configv1alpha1.TerraformRepository, revision string) ([]string, error) {
+	if set.Status.State == StateSynced && set.Status.Revision == revision && set.Status.ObservedGeneration == set.Generation {
+		paths := []string{}
+		for _, layer := range set.Status.Layers {
+			paths = append(paths, layer.Path)
+		}
+		return paths, nil
+	}
+	bundle, err := r.Datastore.GetGitBundle(repository.Namespace, repository.Name, set.Spec.Branch, revision)
+	if err != nil {
+		return nil, err
+	}
+	files, err := listBundleFiles(bundle, revision)
+	if err != nil {
+		return nil, err
+	}
+	return matchDirectories(files, set.Spec.Generator), nil
+}
+
+// syncLayers creates or updates a layer for each path and prunes the layers
+// of the set whose path is no longer matched
+func (r *Reconciler) syncLayers(ctx context.Context, set *configv1alpha1.TerraformLayerSet, paths []string) ([]configv1alpha1.TerraformLayerSetLayer, error) {
+	synced := []configv1alpha1.TerraformLayerSetLayer{}
+	desired := map[string]bool{}
+	for _, layerPath := range paths {
+		layer := getLayer(set, layerPath)
+		if desired[layer.Name] {
+			r.Recorder.Eventf(set, corev1.EventTypeWarning, "Reconciliation", "Path %s gives the name %s which is already used by another path, ignoring", layerPath, layer.Name)
+			continue
+		}
+		desired[layer.Name] = true
+		err := r.createOrUpdateLayer(ctx, set, layer)
+		if err != nil {
+			return synced, err
+		}
+		synced = append(synced, configv1alpha1.TerraformLayerSetLayer{Name: layer.Name, Path: layerPath})
+	}
+	list := &configv1alpha1.TerraformLayerList{}
+	err := r.Client.List(ctx, list, client.InNamespace(set.Namespace))
+	if err != nil {
+		return synced, err
+	}
+	for _, layer := range list.Items {
+		if desired[layer.Name] || !metav1.IsControlledBy(&layer, set) {
+			continue
+		}
+		err = r.pruneLayer(ctx, set, &layer)
+		if err != nil {
+			return synced, err
+		}
+	}
+	return synced, nil
+}
+
+// pruneLayer deletes or orphans a layer of the set whose path is no longer
+// matched, according to the prune policy of the set
+func (r *Reconciler) pruneLayer(ctx context.Context, set *configv1alpha1.TerraformLayerSet, layer *configv1alpha1.TerraformLayer) error {
+	if set.Spec.PrunePolicy == configv1alpha1.PrunePolicyDelete {
+		err := r.Client.Delete(ctx, layer)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		log.Infof("deleted layer %s of layer set %s, its path %s is no longer matched", layer.Name, set.Name, layer.Spec.Path)
+		r.Recorder.Eventf(set, corev1.EventTypeNormal, "Reconciliation", "Deleted layer %s", layer.Name)
+		return nil
+	}
+	owners := []metav1.OwnerReference{}
+	for _, owner := range layer.OwnerReferences {
+		if owner.UID != set.UID {
+			owners = append(owners, owner)
+		}
+	}
+	layer.OwnerReferences = owners
+	err := r.Client.Update(ctx, layer)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	log.Infof("orphaned layer %s of layer set %s, its path %s is no longer matched", layer.Name, set.Name, layer.Spec.Path)
+	r.Recorder.Eventf(set, corev1.EventTypeNormal, "Reconciliation", "Orphaned layer %s, it is no longer managed by the layer set", layer.Name)
+	return nil
+}
+
+func (r *Reconciler) createOrUpdateLayer(ctx context.Context, set *configv1alpha1.TerraformLayerSet, layer *configv1alpha1.TerraformLayer) error {
+	existing := &configv1alpha1.TerraformLayer{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: layer.Namespace, Name: layer.Name}, existing)
+	if errors.IsNotFound(err) {
+		err = r.Client.Create(ctx, layer)
+		if err != nil {
+			return err
+		}
+		log.Infof("created layer %s for path %s of layer set %s", layer.Name, layer.Spec.Path, set.Name)
+		r.Recorder.Eventf(set, corev1.EventTypeNormal, "Reconciliation", "Created layer %s", layer.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(existing, set) {
+		return fmt.Errorf("layer %s already exists and is not managed by this layer set", layer.Name)
+	}
+	updated := existing.DeepCopy()
+	updated.Spec = layer.Spec
+	// Layers are suspended and resumed individually, the template only sets
+	// the suspension of the layers it creates
+	updated.Spec.Suspend = existing.Spec.Suspend
+	updated.Labels = mergeMaps(updated.Labels, layer.Labels)
+	updated.Annotations = mergeMaps(updated.Annotations, layer.Annotations)
+	if equality.Semantic.DeepEqual(existing, updated) {
+		return nil
+	}
+	err = r.Client.Update(ctx, updated)
+	if err != nil {
+		return err
+	}
+	log.Infof("updated layer %s of layer set %s", layer.Name, set.Name)
+	return nil
+}
+
+func mergeMaps(current map[string]string, values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return current
+	}
+	if current == nil {
+		current = map[string]string{}
+	}
+	for k, v := range values {
+		current[k] = v
+	}
+	return current
+}
+
+func (r *Reconciler) updateStatus(ctx context.Context, set *configv1alpha1.TerraformLayerSet, state string, revision string, layers []configv1alpha1.TerraformLayerSetLayer, result ctrl.Result) (ctrl.Result, error) {
+	set.Status.State = state
+	set.Status.Revision = revision
+	set.Status.Layers = layers
+	if state == StateSynced {
+		set.Status.ObservedGeneration = set.Generation
+	}
+	err := r.Client.Status().Update(ctx, set)
+	if err != nil {
+		r.Recorder.Event(set, corev1.EventTypeWarning, "Reconciliation", "Could not update layer set status")
+		log.Errorf("could not update layer set %s status: %s", set.Name, err)
+	}
+	return result, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&configv1alpha1.TerraformLayerSet{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.Config.Controller.MaxConcurrentReconciles}).
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		Complete(r)
+}
@@ -0,0 +1,146 @@
+package terraformlayerset
+
+import (
+	"context"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/burrito/config"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestSet() *configv1alpha1.TerraformLayerSet {
+	return &configv1alpha1.TerraformLayerSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "envs", Namespace: "default", UID: "set-uid"},
+		Spec: configv1alpha1.TerraformLayerSetSpec{
+			Repository: configv1alpha1.TerraformLayerRepository{Name: "monorepo", Namespace: "default"},
+			Branch:     "main",
+			Template: configv1alpha1.TerraformLayerSetTemplate{
+				Spec: configv1alpha1.TerraformLayerSpec{
+					RemediationStrategy: configv1alpha1.RemediationStrategy{AutoApply: &[]bool{true}[0]},
+				},
+			},
+		},
+	}
+}
+
+func newTestReconciler(t *testing.T, objects ...client.Object) *Reconciler {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := configv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add burrito types to scheme: %s", err)
+	}
+	return &Reconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Scheme:   scheme,
+		Config:   config.TestConfig(),
+		Recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func TestSyncLayers(t *testing.T) {
+	set := newTestSet()
+	set.Spec.PrunePolicy = configv1alpha1.PrunePolicyDelete
+	outdated := getLayer(set, "envs/prod/app/")
+	outdated.Spec.RemediationStrategy = configv1alpha1.RemediationStrategy{}
+	pruned := getLayer(set, "envs/old/app/")
+	unmanaged := &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: "default"},
+	}
+	reconciler := newTestReconciler(t, set, outdated, pruned, unmanaged)
+
+	layers, err := reconciler.syncLayers(context.Background(), set, []string{"envs/prod/app/", "envs/staging/app/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(layers) != 2 || layers[0].Name != "envs-envs-prod-app" || layers[1].Name != "envs-envs-staging-app" {
+		t.Fatalf("unexpected synced layers %v", layers)
+	}
+	for _, name := range []string{"envs-envs-prod-app", "envs-envs-staging-app"} {
+		layer := &configv1alpha1.TerraformLayer{}
+		err := reconciler.Client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, layer)
+		if err != nil {
+			t.Fatalf("expected layer %s to exist: %s", name, err)
+		}
+		if layer.Spec.RemediationStrategy.AutoApply == nil || !*layer.Spec.RemediationStrategy.AutoApply {
+			t.Fatalf("expected layer %s to be rendered from the template", name)
+		}
+	}
+	err = reconciler.Client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: pruned.Name}, &configv1alpha1.TerraformLayer{})
+	if !errors.IsNotFound(err) {
+		t.Fatalf("expected layer %s to be pruned, got %v", pruned.Name, err)
+	}
+	err = reconciler.Client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "unmanaged"}, &configv1alpha1.TerraformLayer{})
+	if err != nil {
+		t.Fatalf("expected unmanaged layer to be left untouched: %s", err)
+	}
+}
+
+func TestSyncLayersOrphansUnmatchedLayers(t *testing.T) {
+	set := newTestSet()
+	pruned := getLayer(set, "envs/old/app/")
+	reconciler := newTestReconciler(t, set, pruned)
+
+	_, err := reconciler.syncLayers(context.Background(), set, []string{"envs/prod/app/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	layer := &configv1alpha1.TerraformLayer{}
+	err = reconciler.Client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: pruned.Name}, layer)
+	if err != nil {
+		t.Fatalf("expected layer %s to be kept by the default prune policy: %s", pruned.Name, err)
+	}
+	if metav1.IsControlledBy(layer, set) {
+		t.Fatalf("expected layer %s to be released by the layer set", pruned.Name)
+	}
+}
+
+func TestSyncLayersKeepsLayerSuspension(t *testing.T) {
+	set := newTestSet()
+	suspended := getLayer(set, "envs/prod/app/")
+	suspended.Spec.Suspend = true
+	suspended.Spec.RemediationStrategy = configv1alpha1.RemediationStrategy{}
+	reconciler := newTestReconciler(t, set, suspended)
+
+	_, err := reconciler.syncLayers(context.Background(), set, []string{"envs/prod/app/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	layer := &configv1alpha1.TerraformLayer{}
+	err = reconciler.Client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: suspended.Name}, layer)
+	if err != nil {
+		t.Fatalf("expected layer %s to exist: %s", suspended.Name, err)
+	}
+	if !layer.Spec.Suspend {
+		t.Fatalf("expected the suspension of layer %s to be kept", suspended.Name)
+	}
+	if layer.Spec.RemediationStrategy.AutoApply == nil || !*layer.Spec.RemediationStrategy.AutoApply {
+		t.Fatalf("expected layer %s to be updated from the template", suspended.Name)
+	}
+}
+
+func TestSyncLayersDoesNotTakeOverExistingLayer(t *testing.T) {
+	set := newTestSet()
+	existing := &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{Name: "envs-envs-prod-app", Namespace: "default"},
+		Spec:       configv1alpha1.TerraformLayerSpec{Path: "somewhere/else/"},
+	}
+	reconciler := newTestReconciler(t, set, existing)
+
+	_, err := reconciler.syncLayers(context.Background(), set, []string{"envs/prod/app/"})
+	if err == nil {
+		t.Fatalf("expected an error when a layer with the same name is not managed by the set")
+	}
+	layer := &configv1alpha1.TerraformLayer{}
+	err = reconciler.Client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: existing.Name}, layer)
+	if err != nil || layer.Spec.Path != "somewhere/else/" {
+		t.Fatalf("expected existing layer to be left untouched, got %+v, %v", layer.Spec, err)
+	}
+}
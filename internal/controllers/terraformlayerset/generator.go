@@ -0,0 +1,129 @@
+package terraformlayerset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const maxLayerNameLength = 63
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// listBundleFiles returns the path of every file of the repository at the
+// given revision, using the git bundle stored by the repository controller
+func listBundleFiles(bundle []byte, revision string) ([]string, error) {
+	dir, err := os.MkdirTemp("", "burrito-layerset-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	bundlePath := filepath.Join(dir, "repository.gitbundle")
+	err = os.WriteFile(bundlePath, bundle, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write git bundle: %w", err)
+	}
+	repoPath := filepath.Join(dir, "repository")
+	output, err := exec.Command("git", "clone", "--bare", "--quiet", bundlePath, repoPath).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone git bundle: %v, output: %s", err, string(output))
+	}
+	output, err = exec.Command("git", "-C", repoPath, "ls-tree", "-r", "--name-only", revision).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files at revision %s: %w", revision, err)
+	}
+	return strings.Split(strings.TrimSpace(string(output)), "\n"), nil
+}
+
+// matchDirectories returns the sorted list of directories selected by the
+// generator, with a trailing slash like the paths of TerraformLayers
+func matchDirectories(files []string, generator configv1alpha1.TerraformLayerSetGenerator) []string {
+	candidates := map[string]bool{}
+	for _, file := range files {
+		dir := path.Dir(file)
+		if generator.ContainsFile != "" {
+			if ok, _ := path.Match(generator.ContainsFile, path.Base(file)); ok && dir != "." {
+				candidates[dir] = true
+			}
+			continue
+		}
+		for ; dir != "."; dir = path.Dir(dir) {
+			candidates[dir] = true
+		}
+	}
+	directories := []string{}
+	for dir := range candidates {
+		if len(generator.Directories) > 0 && !matchAny(generator.Directories, dir) {
+			continue
+		}
+		if matchAny(generator.Exclude, dir) {
+			continue
+		}
+		directories = append(directories, dir+"/")
+	}
+	sort.Strings(directories)
+	return directories
+}
+
+func matchAny(patterns []string, dir string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.TrimSuffix(pattern, "/"), dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getLayerName builds a valid and stable layer name from the name of the set
+// and the path of the layer. Long names are truncated and suffixed with a hash
+// of the path so that they stay unique.
+func getLayerName(set *configv1alpha1.TerraformLayerSet, layerPath string) string {
+	suffix := invalidNameCharacters.ReplaceAllString(strings.ToLower(strings.TrimSuffix(layerPath, "/")), "-")
+	name := strings.Trim(fmt.Sprintf("%s-%s", set.Name, strings.Trim(suffix, "-")), "-")
+	if len(name) <= maxLayerNameLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(layerPath))
+	hash := hex.EncodeToString(sum[:])[:16]
+	return fmt.Sprintf("%s-%s", strings.TrimRight(name[:maxLayerNameLength-len(hash)-1], "-"), hash)
+}
+
+// getLayer renders the template of the set for the given path
+func getLayer(set *configv1alpha1.TerraformLayerSet, layerPath string) *configv1alpha1.TerraformLayer {
+	template := set.Spec.Template.DeepCopy()
+	spec := template.Spec
+	spec.Path = layerPath
+	spec.Branch = set.Spec.Branch
+	spec.Repository = set.Spec.Repository
+	if spec.Repository.Namespace == "" {
+		spec.Repository.Namespace = set.Namespace
+	}
+	return &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        getLayerName(set, layerPath),
+			Namespace:   set.Namespace,
+			Labels:      template.Metadata.Labels,
+			Annotations: template.Metadata.Annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: set.GetAPIVersion(),
+					Kind:       set.GetKind(),
+					Name:       set.Name,
+					UID:        set.UID,
+					Controller: &[]bool{true}[0],
+				},
+			},
+		},
+		Spec: spec,
+	}
+}
@@ -0,0 +1,167 @@
+package terraformlayerset
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var repositoryFiles = []string{
+	"README.md",
+	"envs/prod/app/main.tf",
+	"envs/prod/app/terragrunt.hcl",
+	"envs/prod/network/terragrunt.hcl",
+	"envs/staging/app/main.tf",
+	"envs/staging/app/terragrunt.hcl",
+	"modules/app/main.tf",
+}
+
+func TestMatchDirectories(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator configv1alpha1.TerraformLayerSetGenerator
+		want      []string
+	}{
+		{
+			name:      "directories glob",
+			generator: configv1alpha1.TerraformLayerSetGenerator{Directories: []string{"envs/*/*"}},
+			want:      []string{"envs/prod/app/", "envs/prod/network/", "envs/staging/app/"},
+		},
+		{
+			name:      "directories containing a file",
+			generator: configv1alpha1.TerraformLayerSetGenerator{ContainsFile: "main.tf"},
+			want:      []string{"envs/prod/app/", "envs/staging/app/", "modules/app/"},
+		},
+		{
+			name: "directories glob and file",
+			generator: configv1alpha1.TerraformLayerSetGenerator{
+				Directories:  []string{"envs/*/*"},
+				ContainsFile: "main.tf",
+			},
+			want: []string{"envs/prod/app/", "envs/staging/app/"},
+		},
+		{
+			name: "excluded directories",
+			generator: configv1alpha1.TerraformLayerSetGenerator{
+				ContainsFile: "terragrunt.hcl",
+				Exclude:      []string{"envs/staging/*"},
+			},
+			want: []string{"envs/prod/app/", "envs/prod/network/"},
+		},
+		{
+			name:      "no matching directory",
+			generator: configv1alpha1.TerraformLayerSetGenerator{Directories: []string{"stacks/*"}},
+			want:      []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchDirectories(repositoryFiles, tt.generator)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetLayerName(t *testing.T) {
+	set := &configv1alpha1.TerraformLayerSet{ObjectMeta: metav1.ObjectMeta{Name: "envs"}}
+	if got := getLayerName(set, "envs/prod/My_App/"); got != "envs-envs-prod-my-app" {
+		t.Fatalf("expected envs-envs-prod-my-app, got %s", got)
+	}
+	long := "envs/" + strings.Repeat("very-long-directory-name/", 4)
+	got := getLayerName(set, long)
+	if len(got) != maxLayerNameLength {
+		t.Fatalf("expected a name of %d characters, got %s", maxLayerNameLength, got)
+	}
+	if got == getLayerName(set, long+"other/") {
+		t.Fatalf("expected different names for different paths, got %s twice", got)
+	}
+}
+
+func TestGetLayer(t *testing.T) {
+	set := &configv1alpha1.TerraformLayerSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "envs", Namespace: "burrito"},
+		Spec: configv1alpha1.TerraformLayerSetSpec{
+			Repository: configv1alpha1.TerraformLayerRepository{Name: "monorepo"},
+			Branch:     "main",
+			Template: configv1alpha1.TerraformLayerSetTemplate{
+				Metadata: configv1alpha1.TerraformLayerSetTemplateMetadata{
+					Labels: map[string]string{"team": "platform"},
+				},
+				Spec: configv1alpha1.TerraformLayerSpec{
+					Path:   "ignored/",
+					Branch: "ignored",
+					TerragruntConfig: configv1alpha1.TerragruntConfig{
+						Enabled: &[]bool{true}[0],
+					},
+				},
+			},
+		},
+	}
+	layer := getLayer(set, "envs/prod/app/")
+	if layer.Name != "envs-envs-prod-app" || layer.Namespace != "burrito" {
+		t.Fatalf("unexpected layer name %s/%s", layer.Namespace, layer.Name)
+	}
+	if layer.Spec.Path != "envs/prod/app/" || layer.Spec.Branch != "main" {
+		t.Fatalf("expected path and branch to be set by the layer set, got %s on %s", layer.Spec.Path, layer.Spec.Branch)
+	}
+	if layer.Spec.Repository.Name != "monorepo" || layer.Spec.Repository.Namespace != "burrito" {
+		t.Fatalf("expected repository burrito/monorepo, got %s/%s", layer.Spec.Repository.Namespace, layer.Spec.Repository.Name)
+	}
+	if layer.Labels["team"] != "platform" || layer.Spec.TerragruntConfig.Enabled == nil {
+		t.Fatalf("expected the template to be rendered, got %+v", layer)
+	}
+	if !metav1.IsControlledBy(layer, set) {
+		t.Fatalf("expected the layer to be controlled by the layer set")
+	}
+}
+
+func TestListBundleFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=burrito", "GIT_AUTHOR_EMAIL=burrito@example.com", "GIT_COMMITTER_NAME=burrito", "GIT_COMMITTER_EMAIL=burrito@example.com")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %s: %s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	git("init", "--quiet", "--initial-branch", "main")
+	for _, file := range repositoryFiles {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial commit")
+	revision := git("rev-parse", "HEAD")
+	bundlePath := filepath.Join(t.TempDir(), "repository.gitbundle")
+	git("bundle", "create", bundlePath, "main")
+	bundle, err := os.ReadFile(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := listBundleFiles(bundle, revision)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(files, repositoryFiles) {
+		t.Fatalf("expected %v, got %v", repositoryFiles, files)
+	}
+}
@@ -0,0 +1,5 @@
+package terraformlayerset
+
+import logrus "github.com/sirupsen/logrus"
+
+var log = logrus.WithField("controller", "TerraformLayerSet")
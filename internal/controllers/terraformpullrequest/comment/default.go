@@ -2,10 +2,13 @@ package comment
 
 import (
 	"bytes"
+	"encoding/json"
 	"text/template"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	"github.com/padok-team/burrito/internal/policy"
 
 	_ "embed"
 )
@@ -17,10 +20,12 @@ var (
 )
 
 type ReportedLayer struct {
-	Name       string
-	ShortDiff  string
-	Path       string
-	PrettyPlan string
+	Name         string
+	ShortDiff    string
+	Path         string
+	PrettyPlan   string
+	PolicyStatus string
+	Policies     []policy.Result
 }
 
 type DefaultComment struct {
@@ -55,6 +60,20 @@ func (c *DefaultComment) Generate(commit string) (string, error) {
 			ShortDiff:  string(shortDiff),
 			PrettyPlan: string(plan),
 		}
+		// The policy report only exists if policies apply to the layer
+		policies, err := c.datastore.GetPlan(layer.Namespace, layer.Name, layer.Status.LastRun.Name, "", "policies")
+		if err != nil && !storageerrors.NotFound(err) {
+			return "", err
+		}
+		if len(policies) > 0 {
+			report := policy.Report{}
+			err = json.Unmarshal(policies, &report)
+			if err != nil {
+				return "", err
+			}
+			reportedLayer.PolicyStatus = report.Status()
+			reportedLayer.Policies = report.Results
+		}
 		reportedLayers = append(reportedLayers, reportedLayer)
 
 	}
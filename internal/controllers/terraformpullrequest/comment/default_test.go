@@ -29,6 +29,22 @@ func (f *fakeDatastore) PutPlan(namespace string, layer string, run string, atte
 	return nil
 }
 
+func (f *fakeDatastore) GetModulePlan(namespace string, layer string, run string, attempt string, module string, format string) ([]byte, error) {
+	return nil, nil
+}
+
+func (f *fakeDatastore) PutModulePlan(namespace string, layer string, run string, attempt string, module string, format string, content []byte) error {
+	return nil
+}
+
+func (f *fakeDatastore) GetOutputs(namespace string, layer string) ([]byte, error) {
+	return nil, nil
+}
+
+func (f *fakeDatastore) PutOutputs(namespace string, layer string, content []byte) error {
+	return nil
+}
+
 func (f *fakeDatastore) GetLogs(namespace string, layer string, run string, attempt string) ([]string, error) {
 	return nil, nil
 }
@@ -37,6 +53,14 @@ func (f *fakeDatastore) PutLogs(namespace string, layer string, run string, atte
 	return nil
 }
 
+func (f *fakeDatastore) AppendLogs(namespace string, layer string, run string, attempt string, chunk int, content []byte, final bool) error {
+	return nil
+}
+
+func (f *fakeDatastore) CheckLogsFinal(namespace string, layer string, run string, attempt string) (bool, error) {
+	return false, nil
+}
+
 func (f *fakeDatastore) PutGitBundle(namespace string, name string, ref string, revision string, bundle []byte) error {
 	return nil
 }
@@ -84,6 +108,33 @@ func TestDefaultCommentGenerate(t *testing.T) {
 	}
 }
 
+func TestDefaultCommentGenerateWithPolicies(t *testing.T) {
+	comment := NewDefaultComment([]configv1alpha1.TerraformLayer{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "layer-a", Namespace: "default"},
+			Status: configv1alpha1.TerraformLayerStatus{
+				LastRun: configv1alpha1.TerraformLayerRun{Name: "run-a"},
+			},
+		},
+	}, &fakeDatastore{
+		plans: map[string][]byte{
+			"pretty":   []byte("pretty plan"),
+			"short":    []byte("+ create"),
+			"policies": []byte(`{"results":[{"policy":"no-deletion","mode":"Enforce","denials":["aws_instance.web must not be deleted"]},{"policy":"tags","mode":"Warn"}]}`),
+		},
+	})
+
+	got, err := comment.Generate("abc123")
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	for _, expected := range []string{"Policies: Denied", "| no-deletion | Enforce | :no_entry: aws_instance.web must not be deleted<br> |", "| tags | Warn | :white_check_mark: |"} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected generated comment to contain %q, got:\n%s", expected, got)
+		}
+	}
+}
+
 func TestDefaultCommentGenerateReturnsDatastoreError(t *testing.T) {
 	expectedErr := errors.New("datastore unavailable")
 	comment := NewDefaultComment([]configv1alpha1.TerraformLayer{{}}, &fakeDatastore{err: expectedErr})
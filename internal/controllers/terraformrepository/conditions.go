@@ -180,3 +180,23 @@ func (r *Reconciler) HasLastSyncFailed(repo *configv1alpha1.TerraformRepository)
 	condition.Status = metav1.ConditionFalse
 	return condition, false
 }
+
+// IsSuspended checks if the repository is suspended, in which case it is not synced
+func (r *Reconciler) IsSuspended(repo *configv1alpha1.TerraformRepository) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsSuspended",
+		ObservedGeneration: repo.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	if repo.Spec.Suspend {
+		condition.Reason = "RepositorySuspended"
+		condition.Message = "The repository is suspended, it is not synced"
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	condition.Reason = "NotSuspended"
+	condition.Message = "The repository is not suspended"
+	condition.Status = metav1.ConditionFalse
+	return condition, false
+}
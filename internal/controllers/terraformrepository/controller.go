@@ -128,6 +128,19 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 				}
 			},
 		)).
+		Watches(&configv1alpha1.TerraformLayerSet{}, handler.EnqueueRequestsFromMapFunc(
+			func(ctx context.Context, obj client.Object) []reconcile.Request {
+				log.Infof("repository controller has detected the following layer set creation: %s/%s", obj.GetNamespace(), obj.GetName())
+				set := obj.(*configv1alpha1.TerraformLayerSet)
+				namespace := set.Spec.Repository.Namespace
+				if namespace == "" {
+					namespace = set.Namespace
+				}
+				return []reconcile.Request{
+					{NamespacedName: types.NamespacedName{Namespace: namespace, Name: set.Spec.Repository.Name}},
+				}
+			},
+		)).
 		WithEventFilter(ignorePredicate()).
 		Complete(r)
 }
@@ -135,10 +148,13 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 func ignorePredicate() predicate.Predicate {
 	return predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			// Ignore updates on TerraformLayer objects, we only watch their creation
+			// Ignore updates on TerraformLayer and TerraformLayerSet objects, we only watch their creation
 			if _, ok := e.ObjectNew.(*configv1alpha1.TerraformLayer); ok {
 				return false
 			}
+			if _, ok := e.ObjectNew.(*configv1alpha1.TerraformLayerSet); ok {
+				return false
+			}
 			// Update only if generation or annotations change, filter out anything else.
 			// We only need to check generation or annotations change here, because it is only
 			// updated on spec changes. On the other hand RevisionVersion
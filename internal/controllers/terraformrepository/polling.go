@@ -23,6 +23,24 @@ func (r *Reconciler) retrieveManagedLayers(ctx context.Context, repository *conf
 	return managedLayers, nil
 }
 
+func (r *Reconciler) retrieveManagedLayerSets(ctx context.Context, repository *configv1alpha1.TerraformRepository) ([]configv1alpha1.TerraformLayerSet, error) {
+	sets := &configv1alpha1.TerraformLayerSetList{}
+	if err := r.List(ctx, sets); err != nil {
+		return nil, err
+	}
+	managedSets := []configv1alpha1.TerraformLayerSet{}
+	for _, set := range sets.Items {
+		namespace := set.Spec.Repository.Namespace
+		if namespace == "" {
+			namespace = set.Namespace
+		}
+		if set.Spec.Repository.Name == repository.Name && namespace == repository.Namespace {
+			managedSets = append(managedSets, set)
+		}
+	}
+	return managedSets, nil
+}
+
 // Returns a list of all refs (branches and tags) among a list of layers from the same repository (duplicated allowed)
 func retrieveAllLayerRefs(layers []configv1alpha1.TerraformLayer) []string {
 	refs := []string{}
@@ -32,6 +50,16 @@ func retrieveAllLayerRefs(layers []configv1alpha1.TerraformLayer) []string {
 	return refs
 }
 
+// Returns a list of all refs among a list of layer sets, whose branches must be synced
+// before they have generated any layer
+func retrieveAllLayerSetRefs(sets []configv1alpha1.TerraformLayerSet) []string {
+	refs := []string{}
+	for _, set := range sets {
+		refs = append(refs, set.Spec.Branch)
+	}
+	return refs
+}
+
 // Returns a list of all layers referencing a specific ref
 func retrieveLayersForRef(ref string, layers []configv1alpha1.TerraformLayer) []configv1alpha1.TerraformLayer {
 	result := []configv1alpha1.TerraformLayer{}
@@ -31,7 +31,13 @@ func (r *Reconciler) GetState(ctx context.Context, repository *configv1alpha1.Te
 	log := log.WithContext(ctx)
 	c1, IsLastSyncTooOld := r.IsLastSyncTooOld(repository)
 	c2, HasLastSyncFailed := r.HasLastSyncFailed(repository)
-	conditions := []metav1.Condition{c1, c2}
+	c3, IsSuspended := r.IsSuspended(repository)
+	conditions := []metav1.Condition{c1, c2, c3}
+
+	if IsSuspended {
+		log.Infof("repository %s is suspended, skipping sync", repository.Name)
+		return &Suspended{}, conditions
+	}
 
 	if IsLastSyncTooOld || HasLastSyncFailed {
 		log.Infof("repository %s needs to be synced", repository.Name)
@@ -70,11 +76,17 @@ func (s *SyncNeeded) getHandler() Handler {
 			log.Errorf("failed to list managed layers by repo %s/%s: %s", repository.Namespace, repository.Name, err)
 			return ctrl.Result{}, branchStates
 		}
-		if len(layers) == 0 {
+		layerSets, err := r.retrieveManagedLayerSets(ctx, repository)
+		if err != nil {
+			r.Recorder.Event(repository, corev1.EventTypeWarning, "Reconciliation", fmt.Sprintf("Failed to list managed layer sets: %s", err))
+			log.Errorf("failed to list managed layer sets by repo %s/%s: %s", repository.Namespace, repository.Name, err)
+			return ctrl.Result{}, branchStates
+		}
+		if len(layers) == 0 && len(layerSets) == 0 {
 			log.Warningf("no managed layers found for repository %s/%s, have you created TerraformLayer resources?", repository.Namespace, repository.Name)
 			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, []configv1alpha1.BranchState{}
 		}
-		layerBranches := retrieveAllLayerRefs(layers)
+		layerBranches := append(retrieveAllLayerRefs(layers), retrieveAllLayerSetRefs(layerSets)...)
 
 		// add in branchStates branches that were not previously managed
 		branchStates = mergeBranchesWithBranchState(layerBranches, branchStates)
@@ -168,6 +180,15 @@ func (s *Synced) getHandler() Handler {
 	}
 }
 
+// Suspended does not sync the repository until its suspension is lifted
+type Suspended struct{}
+
+func (s *Suspended) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, repository *configv1alpha1.TerraformRepository) (ctrl.Result, []configv1alpha1.BranchState) {
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.RepositorySync}, repository.Status.Branches
+	}
+}
+
 func getStateString(state State) string {
 	t := strings.Split(fmt.Sprintf("%T", state), ".")
 	return t[len(t)-1]
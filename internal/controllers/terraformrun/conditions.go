@@ -6,11 +6,21 @@ import (
 	"time"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
+func (r *Reconciler) getRunnerPod(run *configv1alpha1.TerraformRun) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	err := r.Client.Get(context.Background(), types.NamespacedName{
+		Name:      run.Status.RunnerPod,
+		Namespace: run.Namespace,
+	}, pod)
+	return pod, err
+}
+
 func (r *Reconciler) getPodPhase(name string, namespace string) corev1.PodPhase {
 	pod := &corev1.Pod{}
 	err := r.Client.Get(context.Background(), types.NamespacedName{
@@ -24,6 +34,12 @@ func (r *Reconciler) getPodPhase(name string, namespace string) corev1.PodPhase
 	return pod.Status.Phase
 }
 
+// isRunnerPodPending returns whether the current runner pod of the run has not
+// started yet
+func (r *Reconciler) isRunnerPodPending(run *configv1alpha1.TerraformRun) bool {
+	return run.Status.RunnerPod != "" && r.getPodPhase(run.Status.RunnerPod, run.Namespace) == corev1.PodPending
+}
+
 func (r *Reconciler) HasStatus(t *configv1alpha1.TerraformRun) (metav1.Condition, bool) {
 	condition := metav1.Condition{
 		Type:               "HasStatus",
@@ -61,6 +77,32 @@ func GetMaxRetries(defaultValue int, r *configv1alpha1.TerraformRepository, l *c
 	return *layer
 }
 
+// getLastFailureClass returns the class of the failure of the current attempt
+// of the run, empty if it has not failed
+func getLastFailureClass(run *configv1alpha1.TerraformRun) string {
+	if len(run.Status.Attempts) == 0 {
+		return ""
+	}
+	return run.Status.Attempts[len(run.Status.Attempts)-1].FailureClass
+}
+
+// GetRunMaxRetries returns the retry limit of the run, which depends on the
+// class of the failure of its current attempt
+func GetRunMaxRetries(defaultValue int, run *configv1alpha1.TerraformRun, repo *configv1alpha1.TerraformRepository, layer *configv1alpha1.TerraformLayer) int {
+	if IsStateAction(run.Spec.Action) {
+		// A state maintenance action may have partially changed the state, it is never retried
+		return 0
+	}
+	class := getLastFailureClass(run)
+	if class != "" {
+		policy := configv1alpha1.GetRetryPolicy(repo, layer, class)
+		if policy.MaxRetries != nil {
+			return *policy.MaxRetries
+		}
+	}
+	return GetMaxRetries(defaultValue, repo, layer)
+}
+
 func (r *Reconciler) HasReachedRetryLimit(
 	run *configv1alpha1.TerraformRun,
 	layer *configv1alpha1.TerraformLayer,
@@ -72,7 +114,14 @@ func (r *Reconciler) HasReachedRetryLimit(
 		Status:             metav1.ConditionUnknown,
 		LastTransitionTime: metav1.NewTime(time.Now()),
 	}
-	maxRetries := GetMaxRetries(r.Config.Controller.TerraformMaxRetries, repo, layer)
+	maxRetries := GetRunMaxRetries(r.Config.Controller.TerraformMaxRetries, run, repo, layer)
+	class := getLastFailureClass(run)
+	if maxRetries == 0 && class != "" && !IsStateAction(run.Spec.Action) {
+		condition.Reason = "NonRetryableFailure"
+		condition.Message = fmt.Sprintf("The last attempt failed with a failure of class %s, which is not retried", class)
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
 	if run.Status.Retries >= maxRetries {
 		condition.Reason = "HasReachedRetryLimit"
 		condition.Message = fmt.Sprintf("This run has reached the retry limit (%d)", maxRetries)
@@ -105,6 +154,28 @@ func (r *Reconciler) HasSucceeded(t *configv1alpha1.TerraformRun) (metav1.Condit
 	return condition, false
 }
 
+// IsCancelled checks whether the run has been cancelled, its runner then
+// interrupts the running command and the run is not retried
+func (r *Reconciler) IsCancelled(t *configv1alpha1.TerraformRun) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsCancelled",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	user, ok := t.Annotations[annotations.CancelRun]
+	if !ok {
+		condition.Reason = "NotCancelled"
+		condition.Message = "This run has not been cancelled"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Reason = "Cancelled"
+	condition.Message = fmt.Sprintf("This run has been cancelled by %s", user)
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
 func (r *Reconciler) IsRunning(t *configv1alpha1.TerraformRun) (metav1.Condition, bool) {
 	condition := metav1.Condition{
 		Type:               "IsRunning",
@@ -114,7 +185,7 @@ func (r *Reconciler) IsRunning(t *configv1alpha1.TerraformRun) (metav1.Condition
 	}
 	currentState := t.Status.State
 	runnerPod := t.Status.RunnerPod
-	if (currentState == "Initial" || currentState == "Retrying" || currentState == "Running") && runnerPod != "" {
+	if (currentState == "Initial" || currentState == "Retrying" || currentState == "Rescheduling" || currentState == "Running" || currentState == "TimedOut") && runnerPod != "" {
 		podPhase := r.getPodPhase(runnerPod, t.Namespace)
 		if podPhase == corev1.PodPending || podPhase == corev1.PodRunning {
 			condition.Reason = "IsRunning"
@@ -129,7 +200,143 @@ func (r *Reconciler) IsRunning(t *configv1alpha1.TerraformRun) (metav1.Condition
 	return condition, false
 }
 
-func (r *Reconciler) IsInFailureGracePeriod(t *configv1alpha1.TerraformRun) (metav1.Condition, bool) {
+// HasBeenDisrupted checks whether a runner pod of the run has been evicted,
+// preempted or lost with its node. An apply may then have been interrupted
+// mid-way, leaving the state partially updated or locked.
+func (r *Reconciler) HasBeenDisrupted(t *configv1alpha1.TerraformRun) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "HasBeenDisrupted",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	disruptions := countDisruptions(t)
+	if disruptions == 0 {
+		condition.Reason = "NotDisrupted"
+		condition.Message = "No runner pod of this run has been disrupted"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Status = metav1.ConditionTrue
+	if Action(t.Spec.Action) == ApplyAction || Action(t.Spec.Action) == DestroyAction || IsStateAction(t.Spec.Action) {
+		condition.Reason = "ApplyInterrupted"
+		condition.Message = fmt.Sprintf("%d runner pod(s) of this run have been disrupted, the state may have been partially updated and its lock may need to be released", disruptions)
+		return condition, true
+	}
+	condition.Reason = "Disrupted"
+	condition.Message = fmt.Sprintf("%d runner pod(s) of this run have been disrupted and rescheduled", disruptions)
+	return condition, true
+}
+
+// GetRunTimeout returns the maximum duration of a run attempt, the layer and
+// repository setting taking precedence over the controller timer
+func GetRunTimeout(defaultValue time.Duration, repo *configv1alpha1.TerraformRepository, layer *configv1alpha1.TerraformLayer) time.Duration {
+	timeout := configv1alpha1.GetRunTimeout(repo, layer)
+	if timeout == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		log.Errorf("could not parse run timeout %s of layer %s, using default timeout: %s", timeout, layer.Name, err)
+		return defaultValue
+	}
+	return duration
+}
+
+// HasTimedOut checks whether the current attempt of the run has been running
+// for longer than the run timeout
+func (r *Reconciler) HasTimedOut(
+	run *configv1alpha1.TerraformRun,
+	layer *configv1alpha1.TerraformLayer,
+	repo *configv1alpha1.TerraformRepository,
+) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "HasTimedOut",
+		ObservedGeneration: run.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	timeout := GetRunTimeout(r.Config.Controller.Timers.RunTimeout, repo, layer)
+	if timeout <= 0 {
+		condition.Reason = "NoTimeout"
+		condition.Message = "No timeout is set for this run"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	lastActionTime, err := getLastActionTime(r, run)
+	if err != nil {
+		condition.Reason = "CouldNotGetLastActionTime"
+		condition.Message = "Could not get last action time from resource status"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	if r.Clock.Now().After(lastActionTime.Add(timeout)) {
+		condition.Reason = "TimedOut"
+		condition.Message = fmt.Sprintf("The current attempt has been running for more than %s", timeout)
+		condition.Status = metav1.ConditionTrue
+		return condition, true
+	}
+	condition.Reason = "NotTimedOut"
+	condition.Message = fmt.Sprintf("The current attempt has been running for less than %s", timeout)
+	condition.Status = metav1.ConditionFalse
+	return condition, false
+}
+
+// IsPodStuck checks whether the runner pod of the run has been pending for
+// longer than the pending timeout. The reason of a pod which cannot start,
+// such as an image pull error, is reported as soon as it is pending.
+func (r *Reconciler) IsPodStuck(t *configv1alpha1.TerraformRun) (metav1.Condition, bool) {
+	condition := metav1.Condition{
+		Type:               "IsPodStuck",
+		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
+		Status:             metav1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	pod, err := r.getRunnerPod(t)
+	if err != nil || pod.Status.Phase != corev1.PodPending {
+		condition.Reason = "PodNotPending"
+		condition.Message = "The runner pod is not pending"
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	reason, message := GetPodPendingReason(pod)
+	condition.Reason = reason
+	condition.Message = fmt.Sprintf("The runner pod %s is pending: %s", pod.Name, message)
+	timeout := r.Config.Controller.Timers.PendingTimeout
+	lastActionTime, err := getLastActionTime(r, t)
+	if timeout <= 0 || err != nil || !r.Clock.Now().After(lastActionTime.Add(timeout)) {
+		condition.Status = metav1.ConditionFalse
+		return condition, false
+	}
+	condition.Message = fmt.Sprintf("The runner pod %s has been pending for more than %s: %s", pod.Name, timeout, message)
+	condition.Status = metav1.ConditionTrue
+	return condition, true
+}
+
+// GetFailureGracePeriod returns the initial delay before retrying the run,
+// which depends on the class of the failure of its current attempt
+func GetFailureGracePeriod(defaultValue time.Duration, run *configv1alpha1.TerraformRun, repo *configv1alpha1.TerraformRepository, layer *configv1alpha1.TerraformLayer) time.Duration {
+	class := getLastFailureClass(run)
+	if class == "" {
+		return defaultValue
+	}
+	backoff := configv1alpha1.GetRetryPolicy(repo, layer, class).Backoff
+	if backoff == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(backoff)
+	if err != nil {
+		log.Errorf("could not parse backoff %s of failure class %s, using default grace period: %s", backoff, class, err)
+		return defaultValue
+	}
+	return duration
+}
+
+func (r *Reconciler) IsInFailureGracePeriod(
+	t *configv1alpha1.TerraformRun,
+	layer *configv1alpha1.TerraformLayer,
+	repo *configv1alpha1.TerraformRepository,
+) (metav1.Condition, bool) {
 	condition := metav1.Condition{
 		Type:               "IsInFailureGracePeriod",
 		ObservedGeneration: t.GetObjectMeta().GetGeneration(),
@@ -144,7 +351,8 @@ func (r *Reconciler) IsInFailureGracePeriod(t *configv1alpha1.TerraformRun) (met
 			condition.Status = metav1.ConditionFalse
 			return condition, false
 		}
-		nextFailure := lastFailureTime.Add(GetRunExponentialBackOffTime(r.Config.Controller.Timers.FailureGracePeriod, t))
+		gracePeriod := GetFailureGracePeriod(r.Config.Controller.Timers.FailureGracePeriod, t, repo, layer)
+		nextFailure := lastFailureTime.Add(GetRunExponentialBackOffTime(gracePeriod, t))
 		now := r.Clock.Now()
 		if nextFailure.After(now) {
 			condition.Reason = "InFailureGracePeriod"
@@ -28,6 +28,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/padok-team/burrito/internal/controllers/metrics"
 	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	"github.com/padok-team/burrito/internal/utils/redact"
 	logClient "k8s.io/client-go/kubernetes"
 
 	corev1 "k8s.io/api/core/v1"
@@ -88,9 +89,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		log.Errorf("failed to get TerraformRun: %s", err)
 		return ctrl.Result{}, err
 	}
-	if run.Status.State == "Succeeded" || run.Status.State == "Failed" {
-		log.Infof("run %s is in a terminal state, ignoring...", run.Name)
-		return ctrl.Result{}, nil
+	if isTerminalState(run.Status.State) {
+		if !hasPendingLogs(run) {
+			log.Infof("run %s is in a terminal state, ignoring...", run.Name)
+			return ctrl.Result{}, nil
+		}
+		return r.retryUploadLogs(ctx, run), nil
 	}
 	layer, err := r.getLinkedLayer(run)
 	if err != nil {
@@ -117,13 +121,15 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, nil
 	}
 
+	r.detectDisruption(run)
+	r.classifyFailure(run)
 	state, conditions := r.GetState(ctx, run, layer, repo)
 	result, runInfo := state.getHandler()(ctx, r, run, layer, repo)
 	if runInfo.NewPod {
 		attempt := configv1alpha1.Attempt{
 			PodName:      runInfo.RunnerPod,
 			LogsUploaded: false,
-			Number:       runInfo.Retries,
+			Number:       nextAttemptNumber(run),
 		}
 		run.Status.Attempts = append(run.Status.Attempts, attempt)
 	}
@@ -153,16 +159,34 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 }
 
 func (r *Reconciler) uploadLogs(run *configv1alpha1.TerraformRun) error {
+	var redactor *redact.Redactor
 	for i, attempt := range run.Status.Attempts {
 		if attempt.LogsUploaded {
 			continue
 		}
+		// The runner ships its logs while it runs, the logs of the pod are
+		// only uploaded if it could not ship all of them
+		final, err := r.Datastore.CheckLogsFinal(run.Namespace, run.Spec.Layer.Name, run.Name, strconv.Itoa(attempt.Number))
+		if err != nil {
+			log.Warningf("could not check whether the logs of pod %s have been shipped: %s", attempt.PodName, err)
+		}
+		if final {
+			run.Status.Attempts[i].LogsUploaded = true
+			continue
+		}
 		pod := &corev1.Pod{}
-		err := r.Client.Get(context.Background(), types.NamespacedName{
+		err = r.Client.Get(context.Background(), types.NamespacedName{
 			Namespace: run.Namespace,
 			Name:      attempt.PodName,
 		}, pod)
 		if errors.IsNotFound(err) {
+			// The pod of a past attempt or of a finished run is gone with
+			// its logs, waiting for them would block the run forever
+			if attempt.PodName != run.Status.RunnerPod || isTerminalState(run.Status.State) {
+				log.Warningf("pod %s not found, its logs are lost", attempt.PodName)
+				run.Status.Attempts[i].LogsUploaded = true
+				continue
+			}
 			log.Infof("pod %s not found, ignoring...", attempt.PodName)
 			continue
 		}
@@ -188,7 +212,13 @@ func (r *Reconciler) uploadLogs(run *configv1alpha1.TerraformRun) error {
 			log.Errorf("failed to copy logs for pod %s: %s", pod.Name, err)
 			continue
 		}
-		err = r.Datastore.PutLogs(run.Namespace, run.Spec.Layer.Name, run.Name, strconv.Itoa(attempt.Number), buf.Bytes())
+		if redactor == nil {
+			redactor, err = r.getRedactor(run)
+			if err != nil {
+				return fmt.Errorf("could not redact logs of pod %s: %w", pod.Name, err)
+			}
+		}
+		err = r.Datastore.PutLogs(run.Namespace, run.Spec.Layer.Name, run.Name, strconv.Itoa(attempt.Number), redactor.Redact(buf.Bytes()))
 		if err != nil {
 			return err
 		}
@@ -197,6 +227,52 @@ func (r *Reconciler) uploadLogs(run *configv1alpha1.TerraformRun) error {
 	return nil
 }
 
+// getRedactor returns the redactor masking the sensitive values of the logs of
+// the run
+func (r *Reconciler) getRedactor(run *configv1alpha1.TerraformRun) (*redact.Redactor, error) {
+	layer, err := r.getLinkedLayer(run)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := r.getLinkedRepo(run, layer)
+	if err != nil {
+		return nil, err
+	}
+	return redact.ForLayer(context.Background(), r.Client, repo, layer)
+}
+
+func isTerminalState(state string) bool {
+	return state == "Succeeded" || state == "Failed" || state == "Cancelled"
+}
+
+func hasPendingLogs(run *configv1alpha1.TerraformRun) bool {
+	for _, attempt := range run.Status.Attempts {
+		if !attempt.LogsUploaded {
+			return true
+		}
+	}
+	return false
+}
+
+// retryUploadLogs uploads the logs of a terminal run that could not be
+// uploaded during the reconciliation that ended the run
+func (r *Reconciler) retryUploadLogs(ctx context.Context, run *configv1alpha1.TerraformRun) ctrl.Result {
+	log := log.WithContext(ctx)
+	uploadErr := r.uploadLogs(run)
+	err := r.Client.Status().Update(ctx, run)
+	if err != nil {
+		r.Recorder.Event(run, corev1.EventTypeWarning, "Reconciliation", "Could not update run status")
+		log.Errorf("could not update run %s status: %s", run.Name, err)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}
+	}
+	if uploadErr != nil {
+		r.Recorder.Event(run, corev1.EventTypeWarning, "Reconciliation", "Failed to upload logs")
+		log.Errorf("failed to upload logs for run %s: %s", run.Name, uploadErr)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}
+	}
+	return ctrl.Result{}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Clock = RealClock{}
@@ -774,3 +774,193 @@ func TestGetRunExponentialBackOffTime(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRunTimeout(t *testing.T) {
+	withTimeout := func(timeout string) configv1alpha1.RemediationStrategy {
+		return configv1alpha1.RemediationStrategy{RunTimeout: timeout}
+	}
+	tt := []struct {
+		name     string
+		repo     string
+		layer    string
+		expected time.Duration
+	}{
+		{"Default timeout", "", "", time.Hour},
+		{"Repository timeout", "30m", "", 30 * time.Minute},
+		{"Layer timeout takes precedence", "30m", "3h", 3 * time.Hour},
+		{"Disabled on layer", "30m", "0s", 0},
+		{"Invalid timeout", "", "forever", time.Hour},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &configv1alpha1.TerraformRepository{Spec: configv1alpha1.TerraformRepositorySpec{RemediationStrategy: withTimeout(tc.repo)}}
+			layer := &configv1alpha1.TerraformLayer{Spec: configv1alpha1.TerraformLayerSpec{RemediationStrategy: withTimeout(tc.layer)}}
+			result := controller.GetRunTimeout(time.Hour, repo, layer)
+			if result != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetPodPendingReason(t *testing.T) {
+	waiting := func(reason string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:  "runner",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: "details"}},
+		}
+	}
+	tt := []struct {
+		name     string
+		status   corev1.PodStatus
+		expected string
+	}{
+		{
+			"Image pull error",
+			corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{waiting("ImagePullBackOff")}},
+			"ImagePullBackOff",
+		},
+		{
+			"Init container error",
+			corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{waiting("CreateContainerConfigError")}},
+			"CreateContainerConfigError",
+		},
+		{
+			"Unschedulable pod",
+			corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable", Message: "0/3 nodes are available"},
+			}},
+			"Unschedulable",
+		},
+		{
+			"Container being created",
+			corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{waiting("ContainerCreating")}},
+			"PodPending",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, _ := controller.GetPodPendingReason(&corev1.Pod{Status: tc.status})
+			if reason != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, reason)
+			}
+		})
+	}
+}
+
+func TestGetRunMaxRetries(t *testing.T) {
+	runWithFailure := func(action string, class string) *configv1alpha1.TerraformRun {
+		return &configv1alpha1.TerraformRun{
+			Spec: configv1alpha1.TerraformRunSpec{Action: action},
+			Status: configv1alpha1.TerraformRunStatus{
+				Attempts: []configv1alpha1.Attempt{{PodName: "pod", Number: 0, FailureClass: class}},
+			},
+		}
+	}
+	layer := &configv1alpha1.TerraformLayer{Spec: configv1alpha1.TerraformLayerSpec{RemediationStrategy: configv1alpha1.RemediationStrategy{
+		OnError: configv1alpha1.OnErrorRemediationStrategy{
+			Throttled: configv1alpha1.RetryPolicy{MaxRetries: intPtr(12)},
+		},
+	}}}
+	repo := &configv1alpha1.TerraformRepository{}
+	tt := []struct {
+		name     string
+		run      *configv1alpha1.TerraformRun
+		expected int
+	}{
+		{"Not failed yet", runWithFailure("plan", ""), 5},
+		{"Unknown failure", runWithFailure("plan", configv1alpha1.FailureClassUnknown), 5},
+		{"Validation failure", runWithFailure("plan", configv1alpha1.FailureClassValidation), 0},
+		{"Throttled failure", runWithFailure("apply", configv1alpha1.FailureClassThrottled), 12},
+		{"State maintenance action", runWithFailure("import", configv1alpha1.FailureClassThrottled), 0},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := controller.GetRunMaxRetries(5, tc.run, repo, layer)
+			if result != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetFailureClass(t *testing.T) {
+	terminated := func(reason, message string) corev1.PodStatus {
+		return corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "runner",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: reason, Message: message}},
+		}}}
+	}
+	tt := []struct {
+		name     string
+		status   corev1.PodStatus
+		expected string
+	}{
+		{"Reported by the runner", terminated("Error", "Throttled"), configv1alpha1.FailureClassThrottled},
+		{"Out of memory", terminated("OOMKilled", ""), configv1alpha1.FailureClassOutOfResources},
+		{"Evicted", corev1.PodStatus{Reason: "Evicted"}, configv1alpha1.FailureClassOutOfResources},
+		{"Unexpected message", terminated("Error", "panic: runtime error"), configv1alpha1.FailureClassUnknown},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := controller.GetFailureClass(&corev1.Pod{Status: tc.status})
+			if result != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetPodDisruption(t *testing.T) {
+	tt := []struct {
+		name      string
+		status    corev1.PodStatus
+		reason    string
+		disrupted bool
+	}{
+		{
+			"Preempted spot node",
+			corev1.PodStatus{Phase: corev1.PodFailed, Conditions: []corev1.PodCondition{
+				{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue, Reason: "DeletionByTaintManager"},
+			}},
+			"DeletionByTaintManager",
+			true,
+		},
+		{
+			"Evicted by a node drain",
+			corev1.PodStatus{Phase: corev1.PodFailed, Conditions: []corev1.PodCondition{
+				{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue, Reason: "EvictionByEvictionAPI"},
+			}},
+			"EvictionByEvictionAPI",
+			true,
+		},
+		{
+			"Evicted by the kubelet out of resources",
+			corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted", Message: "The node was low on resource: memory.", Conditions: []corev1.PodCondition{
+				{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue, Reason: "TerminationByKubelet"},
+			}},
+			"",
+			false,
+		},
+		{
+			"Node shutdown",
+			corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Terminated", Message: "Pod was terminated in response to imminent node shutdown."},
+			"Terminated",
+			true,
+		},
+		{
+			"Failed run",
+			corev1.PodStatus{Phase: corev1.PodFailed},
+			"",
+			false,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, _, disrupted := controller.GetPodDisruption(&corev1.Pod{Status: tc.status})
+			if reason != tc.reason || disrupted != tc.disrupted {
+				t.Errorf("expected %s (%t), got %s (%t)", tc.reason, tc.disrupted, reason, disrupted)
+			}
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
 	"github.com/padok-team/burrito/internal/burrito/config"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -18,10 +20,33 @@ import (
 type Action string
 
 const (
-	PlanAction  Action = "plan"
-	ApplyAction Action = "apply"
+	PlanAction       Action = "plan"
+	ApplyAction      Action = "apply"
+	DestroyAction    Action = "destroy"
+	DriftCheckAction Action = "drift-check"
+
+	// State maintenance actions, requested from the server API
+	ImportAction      Action = "import"
+	StateMoveAction   Action = "state-mv"
+	StateRemoveAction Action = "state-rm"
+	ForceUnlockAction Action = "force-unlock"
 )
 
+// StateActions is the allowlist of the state maintenance actions, with the
+// number of arguments they take, -1 meaning at least one
+var StateActions = map[Action]int{
+	ImportAction:      2,
+	StateMoveAction:   2,
+	StateRemoveAction: -1,
+	ForceUnlockAction: 1,
+}
+
+// IsStateAction returns whether the action is a state maintenance action
+func IsStateAction(action string) bool {
+	_, ok := StateActions[Action(action)]
+	return ok
+}
+
 func getDefaultLabels(run *configv1alpha1.TerraformRun) map[string]string {
 	return map[string]string{
 		"burrito/component":  "runner",
@@ -54,6 +79,251 @@ func (r *Reconciler) GetLinkedPods(run *configv1alpha1.TerraformRun) (*corev1.Po
 	return list, nil
 }
 
+// GetPodPendingReason returns why a pending pod has not started yet: the
+// reason of a container which cannot start, such as an image pull error, or
+// the reason why the pod cannot be scheduled
+func GetPodPendingReason(pod *corev1.Pod) (string, string) {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		waiting := status.State.Waiting
+		if waiting == nil || waiting.Reason == "" || waiting.Reason == "ContainerCreating" || waiting.Reason == "PodInitializing" {
+			continue
+		}
+		return waiting.Reason, fmt.Sprintf("container %s: %s", status.Name, waiting.Message)
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse && condition.Reason != "" {
+			return condition.Reason, condition.Message
+		}
+	}
+	return "PodPending", "waiting for the runner pod to start"
+}
+
+// GetFailureClass returns the class of the failure of a failed runner pod,
+// detected by kubernetes for a pod out of resources or reported by the runner
+// in its termination message
+func GetFailureClass(pod *corev1.Pod) string {
+	if pod.Status.Reason == "Evicted" {
+		return configv1alpha1.FailureClassOutOfResources
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.State.Terminated
+		if terminated == nil {
+			continue
+		}
+		if terminated.Reason == "OOMKilled" {
+			return configv1alpha1.FailureClassOutOfResources
+		}
+		switch class := strings.TrimSpace(terminated.Message); class {
+		case configv1alpha1.FailureClassStateLocked,
+			configv1alpha1.FailureClassCredentials,
+			configv1alpha1.FailureClassThrottled,
+			configv1alpha1.FailureClassValidation:
+			return class
+		}
+	}
+	return configv1alpha1.FailureClassUnknown
+}
+
+// GetPodDisruption returns why a runner pod has been stopped by kubernetes
+// rather than by a failure of its run, such as an eviction through the API, a
+// preemption or the loss of its node. A pod evicted by the kubelet because its
+// node is out of resources is not disrupted: it fails as a pod out of memory.
+func GetPodDisruption(pod *corev1.Pod) (string, string, bool) {
+	if pod.Status.Reason == "Evicted" {
+		return "", "", false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.DisruptionTarget && condition.Status == corev1.ConditionTrue {
+			return condition.Reason, condition.Message, true
+		}
+	}
+	switch pod.Status.Reason {
+	case "Preempting", "NodeLost", "NodeShutdown", "Terminated":
+		return pod.Status.Reason, pod.Status.Message, true
+	}
+	return "", "", false
+}
+
+// detectDisruption flags the current attempt of the run as disrupted once its
+// runner pod has been stopped by kubernetes or has disappeared with its node,
+// so that the run is rescheduled without counting a retry. Once the run has
+// been disrupted too many times, the attempt fails instead.
+func (r *Reconciler) detectDisruption(run *configv1alpha1.TerraformRun) {
+	if len(run.Status.Attempts) == 0 || run.Status.RunnerPod == "" {
+		return
+	}
+	attempt := &run.Status.Attempts[len(run.Status.Attempts)-1]
+	switch {
+	case attempt.PodName != run.Status.RunnerPod:
+		return
+	case attempt.Reason == "Disrupted" || attempt.Reason == "TooManyDisruptions" || attempt.Reason == "TimedOut" || attempt.Reason == "PodDeleted":
+		return
+	}
+	pods, err := r.GetLinkedPods(run)
+	if err != nil {
+		log.Errorf("could not list the runner pods of run %s: %s", run.Name, err)
+		return
+	}
+	for _, pod := range pods.Items {
+		if pod.Name != run.Status.RunnerPod {
+			continue
+		}
+		if pod.Spec.NodeName != "" {
+			attempt.NodeName = pod.Spec.NodeName
+		}
+		// A pod about to be stopped by kubernetes has a DisruptionTarget
+		// condition, which is lost if the pod is deleted right away
+		if reason, message, disrupted := GetPodDisruption(&pod); disrupted && (pod.Status.Phase == corev1.PodFailed || pod.DeletionTimestamp != nil) {
+			r.flagDisruption(run, fmt.Sprintf("%s: %s", reason, message))
+		}
+		return
+	}
+	if run.Status.State != "Running" {
+		return
+	}
+	// A pod deleted by hand is a failure of the attempt, it has only been
+	// disrupted if it has disappeared with its node
+	if attempt.NodeName != "" {
+		lost, err := r.isNodeLost(attempt.NodeName)
+		if err != nil {
+			log.Errorf("could not get node %s of runner pod %s: %s", attempt.NodeName, run.Status.RunnerPod, err)
+			return
+		}
+		if lost {
+			r.flagDisruption(run, fmt.Sprintf("NodeLost: runner pod %s has disappeared with its node %s", run.Status.RunnerPod, attempt.NodeName))
+			return
+		}
+	}
+	setAttemptReason(run, "PodDeleted", fmt.Sprintf("Runner pod %s has been deleted", run.Status.RunnerPod))
+	log.Infof("runner pod %s of run %s has been deleted, failing the attempt", run.Status.RunnerPod, run.Name)
+}
+
+// isNodeLost returns whether a node has been removed from the cluster or is
+// unreachable
+func (r *Reconciler) isNodeLost(name string) (bool, error) {
+	node := &corev1.Node{}
+	err := r.Client.Get(context.Background(), client.ObjectKey{Name: name}, node)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status != corev1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
+
+// flagDisruption records the disruption of the runner pod of the current
+// attempt of the run
+func (r *Reconciler) flagDisruption(run *configv1alpha1.TerraformRun, message string) {
+	if countDisruptions(run) >= r.Config.Controller.MaxRunDisruptions {
+		setAttemptReason(run, "TooManyDisruptions", message)
+		log.Infof("runner pod %s of run %s has been disrupted too many times, failing the attempt: %s", run.Status.RunnerPod, run.Name, message)
+		return
+	}
+	setAttemptReason(run, "Disrupted", message)
+	log.Infof("runner pod %s of run %s has been disrupted: %s", run.Status.RunnerPod, run.Name, message)
+}
+
+// countDisruptions returns the number of attempts of the run whose runner pod
+// has been disrupted
+func countDisruptions(run *configv1alpha1.TerraformRun) int {
+	disruptions := 0
+	for _, attempt := range run.Status.Attempts {
+		if attempt.Reason == "Disrupted" {
+			disruptions++
+		}
+	}
+	return disruptions
+}
+
+// nextAttemptNumber returns the number of a new attempt of the run. Attempts
+// are numbered independently of the retries, as a rescheduled attempt does
+// not count as a retry but stores its logs and plans apart.
+func nextAttemptNumber(run *configv1alpha1.TerraformRun) int {
+	if len(run.Status.Attempts) == 0 {
+		return 0
+	}
+	return run.Status.Attempts[len(run.Status.Attempts)-1].Number + 1
+}
+
+// isLastAttemptDisrupted returns whether the current attempt of the run has
+// been disrupted
+func isLastAttemptDisrupted(run *configv1alpha1.TerraformRun) bool {
+	return len(run.Status.Attempts) > 0 && run.Status.Attempts[len(run.Status.Attempts)-1].Reason == "Disrupted"
+}
+
+// classifyFailure records the class of the failure of the current attempt of
+// the run once its runner pod has failed
+func (r *Reconciler) classifyFailure(run *configv1alpha1.TerraformRun) {
+	if len(run.Status.Attempts) == 0 || run.Status.RunnerPod == "" || isLastAttemptDisrupted(run) {
+		return
+	}
+	attempt := &run.Status.Attempts[len(run.Status.Attempts)-1]
+	if attempt.FailureClass != "" || attempt.PodName != run.Status.RunnerPod {
+		return
+	}
+	pod, err := r.getRunnerPod(run)
+	if err != nil || pod.Status.Phase != corev1.PodFailed {
+		return
+	}
+	attempt.FailureClass = GetFailureClass(pod)
+	log.Infof("attempt %d of run %s failed with a failure of class %s", attempt.Number, run.Name, attempt.FailureClass)
+}
+
+// terminatePod stops the runner pod of a run. A pending pod is deleted. The
+// runner of a running pod is first asked to interrupt its command, as for a
+// cancellation, so that terraform stops gracefully and releases its state lock.
+// A pod still running after its termination grace period is deleted, and
+// force deleted if it has not been removed once this deletion has expired.
+func (r *Reconciler) terminatePod(ctx context.Context, run *configv1alpha1.TerraformRun, pod *corev1.Pod) error {
+	if pod.Status.Phase == corev1.PodPending {
+		return client.IgnoreNotFound(r.Client.Delete(ctx, pod))
+	}
+	now := r.Clock.Now()
+	if run.Annotations[annotations.InterruptRunner] != pod.Name {
+		// The status of the run is updated at the end of the reconciliation, only
+		// its metadata is refreshed
+		updated := run.DeepCopy()
+		err := annotations.Add(ctx, r.Client, updated, map[string]string{
+			annotations.InterruptRunner: pod.Name,
+			annotations.InterruptDate:   now.Format(time.UnixDate),
+		})
+		if err != nil {
+			return err
+		}
+		run.Annotations = updated.Annotations
+		run.ResourceVersion = updated.ResourceVersion
+		return nil
+	}
+	if pod.DeletionTimestamp != nil {
+		// The kubelet has not removed the pod in time, its node may be unreachable
+		if now.After(pod.DeletionTimestamp.Time) {
+			log.Infof("runner pod %s of run %s has not been removed after its deletion, force deleting it", pod.Name, run.Name)
+			return client.IgnoreNotFound(r.Client.Delete(ctx, pod, client.GracePeriodSeconds(0)))
+		}
+		return nil
+	}
+	interruptDate, err := time.Parse(time.UnixDate, run.Annotations[annotations.InterruptDate])
+	if err == nil && now.Before(interruptDate.Add(getTerminationGracePeriod(pod))) {
+		return nil
+	}
+	log.Infof("runner pod %s of run %s has not stopped after being interrupted, deleting it", pod.Name, run.Name)
+	return client.IgnoreNotFound(r.Client.Delete(ctx, pod))
+}
+
+func getTerminationGracePeriod(pod *corev1.Pod) time.Duration {
+	if pod.Spec.TerminationGracePeriodSeconds != nil {
+		return time.Duration(*pod.Spec.TerminationGracePeriodSeconds) * time.Second
+	}
+	return corev1.DefaultTerminationGracePeriodSeconds * time.Second
+}
+
 func (r *Reconciler) ensureCertificateAuthoritySecret(tenantNamespace, caSecretName string) error {
 	secret := &corev1.Secret{}
 	err := r.Client.Get(context.Background(), client.ObjectKey{
@@ -158,6 +428,21 @@ func (r *Reconciler) getPod(run *configv1alpha1.TerraformRun, layer *configv1alp
 			Name:  "BURRITO_RUNNER_ACTION",
 			Value: "apply",
 		})
+	case DestroyAction:
+		defaultSpec.Containers[0].Env = append(defaultSpec.Containers[0].Env, corev1.EnvVar{
+			Name:  "BURRITO_RUNNER_ACTION",
+			Value: "destroy",
+		})
+	case DriftCheckAction:
+		defaultSpec.Containers[0].Env = append(defaultSpec.Containers[0].Env, corev1.EnvVar{
+			Name:  "BURRITO_RUNNER_ACTION",
+			Value: "drift-check",
+		})
+	case ImportAction, StateMoveAction, StateRemoveAction, ForceUnlockAction:
+		defaultSpec.Containers[0].Env = append(defaultSpec.Containers[0].Env, corev1.EnvVar{
+			Name:  "BURRITO_RUNNER_ACTION",
+			Value: run.Spec.Action,
+		})
 	}
 
 	overrideSpec := configv1alpha1.GetOverrideRunnerSpec(repository, layer)
@@ -298,6 +583,12 @@ func defaultPodSpec(config *config.Config, layer *configv1alpha1.TerraformLayer,
 						Name:  "BURRITO_RUNNER_RUN",
 						Value: run.Name,
 					},
+					{
+						Name: "BURRITO_RUNNER_POD",
+						ValueFrom: &corev1.EnvVarSource{
+							FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+						},
+					},
 					{
 						Name:  "SSH_KNOWN_HOSTS",
 						Value: "/home/burrito/.ssh/known_hosts",
@@ -7,9 +7,11 @@ import (
 	"time"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
 	"github.com/padok-team/burrito/internal/controllers/metrics"
 	"github.com/padok-team/burrito/internal/lock"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
@@ -44,9 +46,22 @@ func (r *Reconciler) GetState(ctx context.Context, run *configv1alpha1.Terraform
 	c2, hasReachedRetryLimit := r.HasReachedRetryLimit(run, layer, repo)
 	c3, hasSucceeded := r.HasSucceeded(run)
 	c4, isRunning := r.IsRunning(run)
-	c5, isInFailureGracePeriod := r.IsInFailureGracePeriod(run)
-	conditions := []metav1.Condition{c1, c2, c3, c4, c5}
+	c5, isInFailureGracePeriod := r.IsInFailureGracePeriod(run, layer, repo)
+	c6, isCancelled := r.IsCancelled(run)
+	c7, hasTimedOut := r.HasTimedOut(run, layer, repo)
+	c8, isPodStuck := r.IsPodStuck(run)
+	c9, _ := r.HasBeenDisrupted(run)
+	conditions := []metav1.Condition{c1, c2, c3, c4, c5, c6, c7, c8, c9}
 	switch {
+	case isCancelled && !hasSucceeded && (!isRunning || r.isRunnerPodPending(run)):
+		log.Infof("run %s has been cancelled", run.Name)
+		return &Cancelled{}, conditions
+	case isRunning && (hasTimedOut || isPodStuck):
+		log.Infof("run %s has timed out, terminating its runner pod", run.Name)
+		return &TimedOut{}, conditions
+	case isLastAttemptDisrupted(run) && !isRunning && !hasSucceeded:
+		log.Infof("run %s runner pod has been disrupted, rescheduling...", run.Name)
+		return &Rescheduling{}, conditions
 	case !hasStatus:
 		log.Infof("run %s is in initial state", run.Name)
 		return &Initial{}, conditions
@@ -117,7 +132,47 @@ type Running struct{}
 
 func (s *Running) getHandler() Handler {
 	return func(ctx context.Context, r *Reconciler, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (ctrl.Result, RunInfo) {
-		// Wait and do nothing
+		// Report why the runner pod cannot start, until it is running
+		pod, err := r.getRunnerPod(run)
+		if err == nil && pod.Status.Phase == corev1.PodPending {
+			if reason, message := GetPodPendingReason(pod); reason != "PodPending" {
+				setAttemptReason(run, reason, message)
+			}
+		} else if err == nil {
+			setAttemptReason(run, "", "")
+		}
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, getRunInfo(run)
+	}
+}
+
+// TimedOut is the state of a run whose runner pod has been running or pending
+// for too long. The pod is terminated and the attempt then follows the retry
+// policy as any failed attempt.
+type TimedOut struct{}
+
+func (s *TimedOut) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (ctrl.Result, RunInfo) {
+		log := log.WithContext(ctx)
+		pod, err := r.getRunnerPod(run)
+		if err != nil {
+			log.Errorf("could not get runner pod %s of run %s: %s", run.Status.RunnerPod, run.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, getRunInfo(run)
+		}
+		reason := "TimedOut"
+		message := fmt.Sprintf("Attempt timed out after %s", GetRunTimeout(r.Config.Controller.Timers.RunTimeout, repo, layer))
+		if pod.Status.Phase == corev1.PodPending {
+			var podMessage string
+			reason, podMessage = GetPodPendingReason(pod)
+			message = fmt.Sprintf("Runner pod stuck in Pending for more than %s: %s", r.Config.Controller.Timers.PendingTimeout, podMessage)
+		}
+		setAttemptReason(run, reason, message)
+		err = r.terminatePod(ctx, run, pod)
+		if err != nil {
+			r.Recorder.Event(run, corev1.EventTypeWarning, "Run", "Could not terminate runner pod of timed out run")
+			log.Errorf("could not terminate runner pod %s of run %s: %s", pod.Name, run.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, getRunInfo(run)
+		}
+		r.Recorder.Eventf(run, corev1.EventTypeWarning, "Run", "Stopping runner pod %s: %s", pod.Name, message)
 		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, getRunInfo(run)
 	}
 }
@@ -131,7 +186,8 @@ func (s *FailureGracePeriod) getHandler() Handler {
 			log.Errorf("could not get lastActionTime on run %s,: %s", run.Name, ok)
 			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, getRunInfo(run)
 		}
-		expTime := GetRunExponentialBackOffTime(r.Config.Controller.Timers.FailureGracePeriod, run)
+		gracePeriod := GetFailureGracePeriod(r.Config.Controller.Timers.FailureGracePeriod, run, repo, layer)
+		expTime := GetRunExponentialBackOffTime(gracePeriod, run)
 		endIdleTime := lastActionTime.Add(expTime)
 		now := r.Clock.Now()
 		if endIdleTime.After(now) {
@@ -146,35 +202,55 @@ type Retrying struct{}
 
 func (s *Retrying) getHandler() Handler {
 	return func(ctx context.Context, r *Reconciler, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (ctrl.Result, RunInfo) {
-		log := log.WithContext(ctx)
-		runInfo := getRunInfo(run)
-		maxConcurrentPodsReached, err := isMaxConcurrentRunnerPodsReached(ctx, r, repo)
-		if err != nil {
-			r.Recorder.Eventf(run, corev1.EventTypeWarning, "Run", "Could not check max concurrent pods: %s", err)
-			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, runInfo
-		}
-		if maxConcurrentPodsReached {
-			r.Recorder.Event(run, corev1.EventTypeWarning, "Run", "Max concurrent pods reached. Requeuing resource...")
-			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, runInfo
-		}
+		return createNewAttempt(ctx, r, run, layer, repo, run.Status.Retries+1, "retry")
+	}
+}
 
-		pod := r.getPod(run, layer, repo)
-		err = r.Client.Create(ctx, &pod)
-		if err != nil {
-			r.Recorder.Event(run, corev1.EventTypeWarning, "Run", "Could not create retry pod for run")
-			log.Errorf("failed to create retry pod for run %s: %s", run.Name, err)
-			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, runInfo
-		}
-		runInfo = RunInfo{
-			Retries:   runInfo.Retries + 1,
-			LastRun:   r.Clock.Now().Format(time.UnixDate),
-			RunnerPod: pod.Name,
-			NewPod:    true,
+// Rescheduling is the state of a run whose runner pod has been evicted,
+// preempted or lost with its node. A new attempt is created without counting a
+// retry, as the disruption is not a failure of the run.
+type Rescheduling struct{}
+
+func (s *Rescheduling) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (ctrl.Result, RunInfo) {
+		if Action(run.Spec.Action) == ApplyAction || Action(run.Spec.Action) == DestroyAction || IsStateAction(run.Spec.Action) {
+			r.Recorder.Eventf(run, corev1.EventTypeWarning, "Run", "Runner pod %s has been disrupted while changing the state, check the state lock of layer %s", run.Status.RunnerPod, layer.Name)
 		}
-		r.Recorder.Event(run, corev1.EventTypeNormal, "Run", fmt.Sprintf("Successfully created pod %s for retry run", pod.Name))
-		// Minimal time (1s) to transit from Retrying state to Running state
-		return ctrl.Result{RequeueAfter: time.Duration(1 * time.Second)}, runInfo
+		return createNewAttempt(ctx, r, run, layer, repo, run.Status.Retries, "rescheduled")
+	}
+}
+
+// createNewAttempt creates a new runner pod for the run, once the maximum
+// number of concurrent runner pods allows it
+func createNewAttempt(ctx context.Context, r *Reconciler, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository, retries int, kind string) (ctrl.Result, RunInfo) {
+	log := log.WithContext(ctx)
+	runInfo := getRunInfo(run)
+	maxConcurrentPodsReached, err := isMaxConcurrentRunnerPodsReached(ctx, r, repo)
+	if err != nil {
+		r.Recorder.Eventf(run, corev1.EventTypeWarning, "Run", "Could not check max concurrent pods: %s", err)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, runInfo
 	}
+	if maxConcurrentPodsReached {
+		r.Recorder.Event(run, corev1.EventTypeWarning, "Run", "Max concurrent pods reached. Requeuing resource...")
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, runInfo
+	}
+
+	pod := r.getPod(run, layer, repo)
+	err = r.Client.Create(ctx, &pod)
+	if err != nil {
+		r.Recorder.Eventf(run, corev1.EventTypeWarning, "Run", "Could not create %s pod for run", kind)
+		log.Errorf("failed to create %s pod for run %s: %s", kind, run.Name, err)
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, runInfo
+	}
+	runInfo = RunInfo{
+		Retries:   retries,
+		LastRun:   r.Clock.Now().Format(time.UnixDate),
+		RunnerPod: pod.Name,
+		NewPod:    true,
+	}
+	r.Recorder.Event(run, corev1.EventTypeNormal, "Run", fmt.Sprintf("Successfully created pod %s for %s run", pod.Name, kind))
+	// Minimal time (1s) to transit to Running state
+	return ctrl.Result{RequeueAfter: time.Duration(1 * time.Second)}, runInfo
 }
 
 type Succeeded struct{}
@@ -215,6 +291,49 @@ func (s *Failed) getHandler() Handler {
 	}
 }
 
+// Cancelled is a terminal state, the run is not retried. A run cancelled while
+// its runner pod is running stays Running until the runner has interrupted
+// its command. A runner pod which has not started yet is deleted.
+type Cancelled struct{}
+
+func (s *Cancelled) getHandler() Handler {
+	return func(ctx context.Context, r *Reconciler, run *configv1alpha1.TerraformRun, layer *configv1alpha1.TerraformLayer, repo *configv1alpha1.TerraformRepository) (ctrl.Result, RunInfo) {
+		log := log.WithContext(ctx)
+		if r.isRunnerPodPending(run) {
+			pod, err := r.getRunnerPod(run)
+			if err == nil {
+				err = r.Client.Delete(ctx, pod)
+			}
+			if err != nil && !errors.IsNotFound(err) {
+				r.Recorder.Event(run, corev1.EventTypeWarning, "Run", "Could not delete pending runner pod of cancelled run")
+				log.Errorf("could not delete runner pod %s of cancelled run %s: %s", run.Status.RunnerPod, run.Name, err)
+				return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, getRunInfo(run)
+			}
+			setAttemptReason(run, "Cancelled", "The runner pod was deleted before it started")
+		}
+		// Try to delete lock if it still exists
+		err := lock.DeleteLock(ctx, r.Client, layer, run)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Recorder.Event(run, corev1.EventTypeWarning, "Reconciliation", "Could not delete lock for run")
+			log.Errorf("could not delete lock for run %s: %s", run.Name, err)
+			return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.OnError}, getRunInfo(run)
+		}
+		r.Recorder.Eventf(run, corev1.EventTypeNormal, "Run", "Run cancelled by %s", run.Annotations[annotations.CancelRun])
+		return ctrl.Result{RequeueAfter: r.Config.Controller.Timers.WaitAction}, getRunInfo(run)
+	}
+}
+
+// setAttemptReason records why the current attempt of the run is interrupted
+// or blocked
+func setAttemptReason(run *configv1alpha1.TerraformRun, reason, message string) {
+	if len(run.Status.Attempts) == 0 {
+		return
+	}
+	attempt := &run.Status.Attempts[len(run.Status.Attempts)-1]
+	attempt.Reason = reason
+	attempt.Message = message
+}
+
 func getStateString(state State) string {
 	t := strings.Split(fmt.Sprintf("%T", state), ".")
 	return t[len(t)-1]
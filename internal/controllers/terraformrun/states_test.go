@@ -0,0 +1,439 @@
+package terraformrun
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/burrito/config"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func newStateTestReconciler(t *testing.T, objects ...client.Object) *Reconciler {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add kubernetes types to scheme: %s", err)
+	}
+	if err := configv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add burrito types to scheme: %s", err)
+	}
+	return &Reconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Config:   config.TestConfig(),
+		Recorder: record.NewFakeRecorder(10),
+		Clock:    fixedClock{now: time.Now()},
+	}
+}
+
+func stateTestRun(state string, cancelledBy string) *configv1alpha1.TerraformRun {
+	run := &configv1alpha1.TerraformRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "run",
+			Namespace:   "default",
+			Annotations: map[string]string{},
+		},
+		Spec: configv1alpha1.TerraformRunSpec{
+			Action: string(PlanAction),
+			Layer:  configv1alpha1.TerraformRunLayer{Name: "layer", Namespace: "default"},
+		},
+		Status: configv1alpha1.TerraformRunStatus{
+			State:     state,
+			RunnerPod: "run-pod",
+			LastRun:   time.Now().Format(time.UnixDate),
+			Attempts:  []configv1alpha1.Attempt{{PodName: "run-pod", Number: 0}},
+		},
+	}
+	if cancelledBy != "" {
+		run.Annotations[annotations.CancelRun] = cancelledBy
+	}
+	return run
+}
+
+func stateTestPod(phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-pod", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+func stateTestLayer() *configv1alpha1.TerraformLayer {
+	return &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{Name: "layer", Namespace: "default"},
+	}
+}
+
+func TestGetStateCancelled(t *testing.T) {
+	tests := []struct {
+		name  string
+		run   *configv1alpha1.TerraformRun
+		pod   *corev1.Pod
+		state string
+	}{
+		{
+			name:  "running pod is left to the runner",
+			run:   stateTestRun("Running", "alice@example.com"),
+			pod:   stateTestPod(corev1.PodRunning),
+			state: "Running",
+		},
+		{
+			name:  "interrupted pod",
+			run:   stateTestRun("Running", "alice@example.com"),
+			pod:   stateTestPod(corev1.PodFailed),
+			state: "Cancelled",
+		},
+		{
+			name:  "pending pod",
+			run:   stateTestRun("Running", "alice@example.com"),
+			pod:   stateTestPod(corev1.PodPending),
+			state: "Cancelled",
+		},
+		{
+			name:  "succeeded pod",
+			run:   stateTestRun("Running", "alice@example.com"),
+			pod:   stateTestPod(corev1.PodSucceeded),
+			state: "Succeeded",
+		},
+		{
+			name:  "not cancelled",
+			run:   stateTestRun("Running", ""),
+			pod:   stateTestPod(corev1.PodPending),
+			state: "Running",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newStateTestReconciler(t, tt.pod)
+			state, _ := r.GetState(context.Background(), tt.run, stateTestLayer(), &configv1alpha1.TerraformRepository{})
+			if got := getStateString(state); got != tt.state {
+				t.Fatalf("expected state %s, got %s", tt.state, got)
+			}
+		})
+	}
+}
+
+func TestCancelledDeletesPendingPod(t *testing.T) {
+	run := stateTestRun("Running", "alice@example.com")
+	r := newStateTestReconciler(t, stateTestPod(corev1.PodPending))
+
+	result, info := (&Cancelled{}).getHandler()(context.Background(), r, run, stateTestLayer(), &configv1alpha1.TerraformRepository{})
+
+	if result.RequeueAfter != r.Config.Controller.Timers.WaitAction {
+		t.Fatalf("expected WaitAction requeue, got %s", result.RequeueAfter)
+	}
+	if info.RunnerPod != "run-pod" {
+		t.Fatalf("expected the run info to be kept, got %+v", info)
+	}
+	err := r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "run-pod"}, &corev1.Pod{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the pending runner pod to be deleted, got %v", err)
+	}
+	if reason := run.Status.Attempts[0].Reason; reason != "Cancelled" {
+		t.Fatalf("expected the attempt to be marked as cancelled, got %s", reason)
+	}
+}
+
+func TestCancelledKeepsFinishedPod(t *testing.T) {
+	run := stateTestRun("Running", "alice@example.com")
+	r := newStateTestReconciler(t, stateTestPod(corev1.PodFailed))
+
+	(&Cancelled{}).getHandler()(context.Background(), r, run, stateTestLayer(), &configv1alpha1.TerraformRepository{})
+
+	err := r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "run-pod"}, &corev1.Pod{})
+	if err != nil {
+		t.Fatalf("expected the runner pod to be kept for its logs, got %v", err)
+	}
+}
+
+func TestGetStateTimedOut(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		state   string
+	}{
+		{
+			name:    "attempt running for longer than the timeout",
+			timeout: 2 * time.Hour,
+			state:   "TimedOut",
+		},
+		{
+			name:    "timeout disabled",
+			timeout: 0,
+			state:   "Running",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newStateTestReconciler(t, stateTestPod(corev1.PodRunning))
+			r.Config.Controller.Timers.RunTimeout = tt.timeout
+			run := stateTestRun("Running", "")
+			run.Status.LastRun = time.Now().Add(-3 * time.Hour).Format(time.UnixDate)
+
+			state, _ := r.GetState(context.Background(), run, stateTestLayer(), &configv1alpha1.TerraformRepository{})
+			if got := getStateString(state); got != tt.state {
+				t.Fatalf("expected state %s, got %s", tt.state, got)
+			}
+		})
+	}
+}
+
+func TestTimedOutInterruptsRunner(t *testing.T) {
+	run := stateTestRun("Running", "")
+	r := newStateTestReconciler(t, run, stateTestPod(corev1.PodRunning))
+
+	result, _ := (&TimedOut{}).getHandler()(context.Background(), r, run, stateTestLayer(), &configv1alpha1.TerraformRepository{})
+
+	if result.RequeueAfter != r.Config.Controller.Timers.WaitAction {
+		t.Fatalf("expected WaitAction requeue, got %s", result.RequeueAfter)
+	}
+	updated := &configv1alpha1.TerraformRun{}
+	if err := r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "run"}, updated); err != nil {
+		t.Fatalf("failed to get run: %s", err)
+	}
+	if pod := updated.Annotations[annotations.InterruptRunner]; pod != "run-pod" {
+		t.Fatalf("expected the runner of pod run-pod to be interrupted, got %q", pod)
+	}
+	if date := updated.Annotations[annotations.InterruptDate]; date != r.Clock.Now().Format(time.UnixDate) {
+		t.Fatalf("expected the interrupt date to be recorded, got %q", date)
+	}
+	if run.ResourceVersion != updated.ResourceVersion {
+		t.Fatalf("expected the run to be refreshed, got resource version %s instead of %s", run.ResourceVersion, updated.ResourceVersion)
+	}
+	pod := &corev1.Pod{}
+	if err := r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "run-pod"}, pod); err != nil {
+		t.Fatalf("expected the running pod to be kept, got %v", err)
+	}
+	if pod.Spec.ActiveDeadlineSeconds != nil {
+		t.Fatalf("expected the running pod not to be killed")
+	}
+	if reason := run.Status.Attempts[0].Reason; reason != "TimedOut" {
+		t.Fatalf("expected the attempt to be marked as timed out, got %s", reason)
+	}
+}
+
+func TestTimedOutDeletesPendingPod(t *testing.T) {
+	run := stateTestRun("Running", "")
+	r := newStateTestReconciler(t, run, stateTestPod(corev1.PodPending))
+
+	(&TimedOut{}).getHandler()(context.Background(), r, run, stateTestLayer(), &configv1alpha1.TerraformRepository{})
+
+	err := r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "run-pod"}, &corev1.Pod{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the pending runner pod to be deleted, got %v", err)
+	}
+	if _, ok := run.Annotations[annotations.InterruptRunner]; ok {
+		t.Fatalf("expected no runner to be interrupted")
+	}
+}
+
+func TestTimedOutDeletesInterruptedPod(t *testing.T) {
+	gracePeriod := int64(30)
+	tests := []struct {
+		name        string
+		interrupted time.Duration
+		deleted     bool
+	}{
+		{name: "within the grace period", interrupted: 10 * time.Second, deleted: false},
+		{name: "after the grace period", interrupted: time.Minute, deleted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := stateTestPod(corev1.PodRunning)
+			pod.Spec.TerminationGracePeriodSeconds = &gracePeriod
+			run := stateTestRun("TimedOut", "")
+			r := newStateTestReconciler(t, run, pod)
+			run.Annotations[annotations.InterruptRunner] = "run-pod"
+			run.Annotations[annotations.InterruptDate] = r.Clock.Now().Add(-tt.interrupted).Format(time.UnixDate)
+
+			(&TimedOut{}).getHandler()(context.Background(), r, run, stateTestLayer(), &configv1alpha1.TerraformRepository{})
+
+			err := r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "run-pod"}, &corev1.Pod{})
+			if deleted := apierrors.IsNotFound(err); deleted != tt.deleted {
+				t.Fatalf("expected the runner pod deletion to be %t, got %t (%v)", tt.deleted, deleted, err)
+			}
+		})
+	}
+}
+
+func TestGetStateTimedOutPodDeleted(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries int
+		state   string
+	}{
+		{name: "retries left", retries: 0, state: "Retrying"},
+		{name: "retry limit reached", retries: 5, state: "Failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newStateTestReconciler(t)
+			r.Config.Controller.TerraformMaxRetries = 5
+			run := stateTestRun("TimedOut", "")
+			run.Status.Retries = tt.retries
+			run.Status.Attempts[0].Reason = "TimedOut"
+
+			state, _ := r.GetState(context.Background(), run, stateTestLayer(), &configv1alpha1.TerraformRepository{})
+			if got := getStateString(state); got != tt.state {
+				t.Fatalf("expected state %s, got %s", tt.state, got)
+			}
+		})
+	}
+}
+
+func TestTimedOutForceDeletesTerminatingPod(t *testing.T) {
+	tests := []struct {
+		name    string
+		expires time.Duration
+		force   bool
+	}{
+		{name: "deletion not expired", expires: time.Minute, force: false},
+		{name: "deletion expired", expires: -time.Minute, force: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := stateTestRun("TimedOut", "")
+			r := newStateTestReconciler(t, run)
+			run.Annotations[annotations.InterruptRunner] = "run-pod"
+			run.Annotations[annotations.InterruptDate] = r.Clock.Now().Add(-time.Hour).Format(time.UnixDate)
+			pod := stateTestPod(corev1.PodRunning)
+			deletion := metav1.NewTime(r.Clock.Now().Add(tt.expires))
+			pod.DeletionTimestamp = &deletion
+			pod.Finalizers = []string{"test"}
+			var forced bool
+			r.Client = interceptor.NewClient(fake.NewClientBuilder().WithScheme(r.Client.Scheme()).WithObjects(run, pod).Build(), interceptor.Funcs{
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					options := &client.DeleteOptions{}
+					options.ApplyOptions(opts)
+					forced = options.GracePeriodSeconds != nil && *options.GracePeriodSeconds == 0
+					return c.Delete(ctx, obj, opts...)
+				},
+			})
+
+			(&TimedOut{}).getHandler()(context.Background(), r, run, stateTestLayer(), &configv1alpha1.TerraformRepository{})
+
+			if forced != tt.force {
+				t.Fatalf("expected the runner pod force deletion to be %t, got %t", tt.force, forced)
+			}
+		})
+	}
+}
+
+func TestDetectDisruptionLimit(t *testing.T) {
+	drained := stateTestPod(corev1.PodFailed)
+	drained.Labels = getDefaultLabels(stateTestRun("Running", ""))
+	drained.Status.Conditions = []corev1.PodCondition{
+		{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue, Reason: "EvictionByEvictionAPI"},
+	}
+	tests := []struct {
+		name        string
+		disruptions int
+		reason      string
+	}{
+		{name: "below the limit", disruptions: 2, reason: "Disrupted"},
+		{name: "limit reached", disruptions: 3, reason: "TooManyDisruptions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newStateTestReconciler(t, drained)
+			run := stateTestRun("Running", "")
+			run.Status.Attempts = nil
+			for i := 0; i < tt.disruptions; i++ {
+				run.Status.Attempts = append(run.Status.Attempts, configv1alpha1.Attempt{PodName: fmt.Sprintf("old-pod-%d", i), Number: i, Reason: "Disrupted"})
+			}
+			run.Status.Attempts = append(run.Status.Attempts, configv1alpha1.Attempt{PodName: "run-pod", Number: tt.disruptions})
+
+			r.detectDisruption(run)
+
+			if reason := run.Status.Attempts[tt.disruptions].Reason; reason != tt.reason {
+				t.Fatalf("expected the attempt reason to be %s, got %s", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestDetectDisruptionPodDisappeared(t *testing.T) {
+	readyNode := func(status corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+			},
+		}
+	}
+	tests := []struct {
+		name     string
+		nodeName string
+		objects  []client.Object
+		reason   string
+	}{
+		{name: "node removed", nodeName: "node", reason: "Disrupted"},
+		{name: "node unreachable", nodeName: "node", objects: []client.Object{readyNode(corev1.ConditionUnknown)}, reason: "Disrupted"},
+		{name: "pod deleted on a ready node", nodeName: "node", objects: []client.Object{readyNode(corev1.ConditionTrue)}, reason: "PodDeleted"},
+		{name: "pod deleted before being scheduled", reason: "PodDeleted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newStateTestReconciler(t, tt.objects...)
+			run := stateTestRun("Running", "")
+			run.Status.Attempts[0].NodeName = tt.nodeName
+
+			r.detectDisruption(run)
+
+			if reason := run.Status.Attempts[0].Reason; reason != tt.reason {
+				t.Fatalf("expected the attempt reason to be %s, got %s", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestDetectDisruptionRecordsNode(t *testing.T) {
+	run := stateTestRun("Running", "")
+	pod := stateTestPod(corev1.PodRunning)
+	pod.Labels = getDefaultLabels(run)
+	pod.Spec.NodeName = "node"
+	r := newStateTestReconciler(t, pod)
+
+	r.detectDisruption(run)
+
+	if node := run.Status.Attempts[0].NodeName; node != "node" {
+		t.Fatalf("expected the node of the runner pod to be recorded, got %q", node)
+	}
+	if reason := run.Status.Attempts[0].Reason; reason != "" {
+		t.Fatalf("expected a running pod not to be disrupted, got %s", reason)
+	}
+}
+
+func TestNextAttemptNumber(t *testing.T) {
+	run := stateTestRun("Running", "")
+	run.Status.Retries = 0
+	run.Status.Attempts = []configv1alpha1.Attempt{
+		{PodName: "pod-0", Number: 0, Reason: "Disrupted"},
+		{PodName: "pod-1", Number: 1},
+	}
+	if number := nextAttemptNumber(run); number != 2 {
+		t.Fatalf("expected a rescheduled attempt to get the number 2, got %d", number)
+	}
+	if number := nextAttemptNumber(&configv1alpha1.TerraformRun{}); number != 0 {
+		t.Fatalf("expected the first attempt to get the number 0, got %d", number)
+	}
+}
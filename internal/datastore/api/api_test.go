@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/labstack/echo/v4"
@@ -53,6 +54,7 @@ var _ = BeforeSuite(func() {
 	API.Storage.PutPlan("default", "test1", "test1", "0", "bin", []byte("test1"))
 	API.Storage.PutPlan("default", "test1", "test1", "0", "short", []byte("test1"))
 	API.Storage.PutPlan("default", "test1", "test1", "0", "pretty", []byte("test1"))
+	API.Storage.PutModulePlan("default", "test1", "test1", "0", "network", "short", []byte("test1"))
 	API.Storage.PutGitBundle("default", "test1", "main", "abc123", []byte("test-bundle"))
 
 	e = echo.New()
@@ -175,6 +177,33 @@ var _ = Describe("Datastore API", func() {
 						Expect(context.Response().Status).To(Equal(http.StatusOK))
 					})
 				})
+				Describe("Module is present", func() {
+					It("should return the plan of the module with a 200 OK", func() {
+						context := getContext(http.MethodGet, "/plans", map[string]string{
+							"namespace": "default",
+							"layer":     "test1",
+							"run":       "test1",
+							"module":    "network",
+							"format":    "short",
+						}, nil)
+						err := API.GetPlanHandler(context)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(context.Response().Status).To(Equal(http.StatusOK))
+					})
+					It("should return 404 Not found if the module has no plan", func() {
+						context := getContext(http.MethodGet, "/plans", map[string]string{
+							"namespace": "default",
+							"layer":     "test1",
+							"run":       "test1",
+							"attempt":   "0",
+							"module":    "notfound",
+							"format":    "short",
+						}, nil)
+						err := API.GetPlanHandler(context)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(context.Response().Status).To(Equal(http.StatusNotFound))
+					})
+				})
 			})
 			Describe("Plan does not exist", func() {
 				It("should return 404 Not found if attempt is present", func() {
@@ -242,6 +271,71 @@ var _ = Describe("Datastore API", func() {
 					Expect(err).NotTo(HaveOccurred())
 					Expect(context.Response().Status).To(Equal(http.StatusOK))
 				})
+				Describe("When the runner ships its logs in chunks", Ordered, func() {
+					params := map[string]string{
+						"namespace": "default",
+						"layer":     "test1",
+						"run":       "shipped",
+						"attempt":   "0",
+					}
+					It("should append each chunk to the logs", func() {
+						for i, chunk := range []string{"line1\n", "line2\n"} {
+							chunkParams := map[string]string{"chunk": strconv.Itoa(i)}
+							for k, v := range params {
+								chunkParams[k] = v
+							}
+							context := getContext(http.MethodPost, "/logs", chunkParams, []byte(chunk))
+							err := API.AppendLogsHandler(context)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(context.Response().Status).To(Equal(http.StatusOK))
+						}
+						logs, err := API.Storage.GetLogs("default", "test1", "shipped", "0")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(string(logs)).To(Equal("line1\nline2\n"))
+					})
+					It("should replace a chunk shipped again", func() {
+						chunkParams := map[string]string{"chunk": "1"}
+						for k, v := range params {
+							chunkParams[k] = v
+						}
+						context := getContext(http.MethodPost, "/logs", chunkParams, []byte("line2\n"))
+						err := API.AppendLogsHandler(context)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(context.Response().Status).To(Equal(http.StatusOK))
+						logs, err := API.Storage.GetLogs("default", "test1", "shipped", "0")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(string(logs)).To(Equal("line1\nline2\n"))
+					})
+					It("should return 400 Bad Request without a chunk number", func() {
+						context := getContext(http.MethodPost, "/logs", params, []byte("line\n"))
+						err := API.AppendLogsHandler(context)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(context.Response().Status).To(Equal(http.StatusBadRequest))
+					})
+					It("should not mark the logs as final before the last chunk", func() {
+						context := getContext(http.MethodHead, "/logs/final", params, nil)
+						err := API.HeadLogsFinalHandler(context)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(context.Response().Status).To(Equal(http.StatusNotFound))
+					})
+					It("should mark the logs as final with the last chunk", func() {
+						finalParams := map[string]string{"final": "true", "chunk": "2"}
+						for k, v := range params {
+							finalParams[k] = v
+						}
+						context := getContext(http.MethodPost, "/logs", finalParams, []byte("line3"))
+						err := API.AppendLogsHandler(context)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(context.Response().Status).To(Equal(http.StatusOK))
+						context = getContext(http.MethodHead, "/logs/final", params, nil)
+						err = API.HeadLogsFinalHandler(context)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(context.Response().Status).To(Equal(http.StatusOK))
+						logs, err := API.Storage.GetLogs("default", "test1", "shipped", "0")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(string(logs)).To(Equal("line1\nline2\nline3"))
+					})
+				})
 			})
 			Describe("Plans", func() {
 				It("should return 200 OK", func() {
@@ -257,6 +351,59 @@ var _ = Describe("Datastore API", func() {
 					Expect(err).NotTo(HaveOccurred())
 					Expect(context.Response().Status).To(Equal(http.StatusOK))
 				})
+				It("should store the plan of a stack module under the run attempt", func() {
+					body := []byte(`test2`)
+					context := getContext(http.MethodPut, "/plans", map[string]string{
+						"namespace": "default",
+						"layer":     "test1",
+						"run":       "test1",
+						"attempt":   "0",
+						"module":    "envs/app",
+						"format":    "bin",
+					}, body)
+					err := API.PutPlanHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusOK))
+					content, err := API.Storage.Backend.Get("layers/default/test1/test1/0/modules/envs/app/plan.bin")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(content).To(Equal(body))
+				})
+			})
+			Describe("Outputs", func() {
+				It("should store the outputs of the layer and return them", func() {
+					body := []byte(`{"endpoint":{"sensitive":false,"type":"string","value":"db.example.com"}}`)
+					context := getContext(http.MethodPut, "/outputs", map[string]string{
+						"namespace": "default",
+						"layer":     "test1",
+					}, body)
+					err := API.PutOutputsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusOK))
+					context = getContext(http.MethodGet, "/outputs", map[string]string{
+						"namespace": "default",
+						"layer":     "test1",
+					}, nil)
+					err = API.GetOutputsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusOK))
+				})
+				It("should return 404 Not Found when the layer has no outputs", func() {
+					context := getContext(http.MethodGet, "/outputs", map[string]string{
+						"namespace": "default",
+						"layer":     "notfound",
+					}, nil)
+					err := API.GetOutputsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusNotFound))
+				})
+				It("should return 400 Bad Request when missing parameters", func() {
+					context := getContext(http.MethodPut, "/outputs", map[string]string{
+						"namespace": "default",
+					}, []byte(`{}`))
+					err := API.PutOutputsHandler(context)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(context.Response().Status).To(Equal(http.StatusBadRequest))
+				})
 			})
 		})
 		Describe("Write with Encryption", func() {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
@@ -69,3 +70,53 @@ func (a *API) PutLogsHandler(c echo.Context) error {
 	}
 	return c.NoContent(http.StatusOK)
 }
+
+// AppendLogsHandler appends the body of the request to the logs of an
+// attempt. The runner ships its logs in chunks with this endpoint, numbered
+// with the chunk query parameter, and sets the final query parameter on the
+// last one.
+func (a *API) AppendLogsHandler(c echo.Context) error {
+	namespace, layer, run, attempt, err := getLogsArgs(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if attempt == "" {
+		return c.String(http.StatusBadRequest, "missing query parameters")
+	}
+	chunk, err := strconv.Atoi(c.QueryParam("chunk"))
+	if err != nil || chunk < 0 {
+		return c.String(http.StatusBadRequest, "invalid chunk query parameter")
+	}
+	final := c.QueryParam("final") == "true"
+	content, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "could not read request body: "+err.Error())
+	}
+	err = a.Storage.AppendLogs(namespace, layer, run, attempt, chunk, content, final)
+	if err != nil {
+		c.Logger().Errorf("Could not append logs, there's an issue with the storage backend : %s", err)
+		return c.String(http.StatusInternalServerError, "could not append logs, there's an issue with the storage backend")
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// HeadLogsFinalHandler returns 200 if the runner has shipped all the logs of
+// an attempt, 404 otherwise
+func (a *API) HeadLogsFinalHandler(c echo.Context) error {
+	namespace, layer, run, attempt, err := getLogsArgs(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if attempt == "" {
+		return c.String(http.StatusBadRequest, "missing query parameters")
+	}
+	final, err := a.Storage.CheckLogsFinal(namespace, layer, run, attempt)
+	if err != nil {
+		c.Logger().Errorf("Could not check logs, there's an issue with the storage backend : %s", err)
+		return c.String(http.StatusInternalServerError, "could not check logs, there's an issue with the storage backend")
+	}
+	if !final {
+		return c.NoContent(http.StatusNotFound)
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+)
+
+func getOutputsArgs(c echo.Context) (string, string, error) {
+	namespace := c.QueryParam("namespace")
+	layer := c.QueryParam("layer")
+	if namespace == "" || layer == "" {
+		return "", "", fmt.Errorf("missing query parameters")
+	}
+	return namespace, layer, nil
+}
+
+func (a *API) GetOutputsHandler(c echo.Context) error {
+	namespace, layer, err := getOutputsArgs(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	content, err := a.Storage.GetOutputs(namespace, layer)
+	if storageerrors.NotFound(err) {
+		return c.String(http.StatusNotFound, "No outputs for this layer")
+	}
+	if err != nil {
+		c.Logger().Errorf("Could not get outputs, there's an issue with the storage backend : %s", err)
+		return c.String(http.StatusInternalServerError, "could not get outputs, there's an issue with the storage backend")
+	}
+	return c.Blob(http.StatusOK, "application/octet-stream", content)
+}
+
+func (a *API) PutOutputsHandler(c echo.Context) error {
+	namespace, layer, err := getOutputsArgs(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	content, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "could not read request body: "+err.Error())
+	}
+	err = a.Storage.PutOutputs(namespace, layer, content)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "could not put outputs, there's an issue with the storage backend: "+err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -31,9 +31,16 @@ func (a *API) GetPlanHandler(c echo.Context) error {
 	if err != nil {
 		return c.String(http.StatusBadRequest, err.Error())
 	}
-	if attempt == "" {
+	// The plans of the modules of a terragrunt stack are stored under the run attempt
+	module := c.QueryParam("module")
+	switch {
+	case module != "" && attempt == "":
+		content, err = a.Storage.GetLatestModulePlan(namespace, layer, run, module, format)
+	case module != "":
+		content, err = a.Storage.GetModulePlan(namespace, layer, run, attempt, module, format)
+	case attempt == "":
 		content, err = a.Storage.GetLatestPlan(namespace, layer, run, format)
-	} else {
+	default:
 		content, err = a.Storage.GetPlan(namespace, layer, run, attempt, format)
 	}
 	if storageerrors.NotFound(err) {
@@ -59,7 +66,11 @@ func (a *API) PutPlanHandler(c echo.Context) error {
 	if err != nil {
 		return c.String(http.StatusBadRequest, "could not read request body: "+err.Error())
 	}
-	err = a.Storage.PutPlan(namespace, layer, run, attempt, format, content)
+	if module := c.QueryParam("module"); module != "" {
+		err = a.Storage.PutModulePlan(namespace, layer, run, attempt, module, format, content)
+	} else {
+		err = a.Storage.PutPlan(namespace, layer, run, attempt, format, content)
+	}
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "could not put plan, there's an issue with the storage backend: "+err.Error())
 	}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 
 	"github.com/padok-team/burrito/internal/burrito/config"
 	"github.com/padok-team/burrito/internal/datastore/api"
@@ -22,8 +23,14 @@ const (
 type Client interface {
 	GetPlan(namespace string, layer string, run string, attempt string, format string) ([]byte, error)
 	PutPlan(namespace string, layer string, run string, attempt string, format string, content []byte) error
+	GetModulePlan(namespace string, layer string, run string, attempt string, module string, format string) ([]byte, error)
+	PutModulePlan(namespace string, layer string, run string, attempt string, module string, format string, content []byte) error
+	GetOutputs(namespace string, layer string) ([]byte, error)
+	PutOutputs(namespace string, layer string, content []byte) error
 	GetLogs(namespace string, layer string, run string, attempt string) ([]string, error)
 	PutLogs(namespace string, layer string, run string, attempt string, content []byte) error
+	AppendLogs(namespace string, layer string, run string, attempt string, chunk int, content []byte, final bool) error
+	CheckLogsFinal(namespace string, layer string, run string, attempt string) (bool, error)
 	PutGitBundle(namespace, name, ref, revision string, bundle []byte) error
 	CheckGitBundle(namespace, name, ref, revision string) (bool, error)
 	GetGitBundle(namespace, name, ref, revision string) ([]byte, error)
@@ -65,13 +72,29 @@ func (c *DefaultClient) buildRequest(path string, queryParams url.Values, method
 }
 
 func (c *DefaultClient) GetPlan(namespace string, layer string, run string, attempt string, format string) ([]byte, error) {
-	req, err := c.buildRequest("/api/plans", url.Values{
+	return c.getPlan(url.Values{
 		"namespace": {namespace},
 		"layer":     {layer},
 		"run":       {run},
 		"attempt":   {attempt},
 		"format":    {format},
-	}, http.MethodGet, nil)
+	})
+}
+
+// GetModulePlan returns the plan of a module of a terragrunt stack
+func (c *DefaultClient) GetModulePlan(namespace string, layer string, run string, attempt string, module string, format string) ([]byte, error) {
+	return c.getPlan(url.Values{
+		"namespace": {namespace},
+		"layer":     {layer},
+		"run":       {run},
+		"attempt":   {attempt},
+		"module":    {module},
+		"format":    {format},
+	})
+}
+
+func (c *DefaultClient) getPlan(queryParams url.Values) ([]byte, error) {
+	req, err := c.buildRequest("/api/plans", queryParams, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -97,22 +120,98 @@ func (c *DefaultClient) GetPlan(namespace string, layer string, run string, atte
 }
 
 func (c *DefaultClient) PutPlan(namespace string, layer string, run string, attempt string, format string, content []byte) error {
+	return c.putPlan(url.Values{
+		"namespace": {namespace},
+		"layer":     {layer},
+		"run":       {run},
+		"attempt":   {attempt},
+		"format":    {format},
+	}, content)
+}
+
+// PutModulePlan stores the plan of a module of a terragrunt stack
+func (c *DefaultClient) PutModulePlan(namespace string, layer string, run string, attempt string, module string, format string, content []byte) error {
+	return c.putPlan(url.Values{
+		"namespace": {namespace},
+		"layer":     {layer},
+		"run":       {run},
+		"attempt":   {attempt},
+		"module":    {module},
+		"format":    {format},
+	}, content)
+}
+
+func (c *DefaultClient) putPlan(queryParams url.Values, content []byte) error {
 	req, err := c.buildRequest(
 		"/api/plans",
+		queryParams,
+		http.MethodPut,
+		bytes.NewBuffer(content),
+	)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("could not put plan, there's an issue reading the response from datastore: %s", err)
+		}
+		return fmt.Errorf("could not put plan, there's an issue with the storage backend: %s", string(message))
+	}
+	return nil
+}
+
+// GetOutputs returns the outputs of the last apply of a layer
+func (c *DefaultClient) GetOutputs(namespace string, layer string) ([]byte, error) {
+	req, err := c.buildRequest("/api/outputs", url.Values{
+		"namespace": {namespace},
+		"layer":     {layer},
+	}, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, &storageerrors.StorageError{
+			Err: fmt.Errorf("no outputs for this layer"),
+			Nil: true,
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get outputs, there's an issue with the storage backend")
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// PutOutputs stores the outputs of the last apply of a layer
+func (c *DefaultClient) PutOutputs(namespace string, layer string, content []byte) error {
+	req, err := c.buildRequest(
+		"/api/outputs",
 		url.Values{
 			"namespace": {namespace},
 			"layer":     {layer},
-			"run":       {run},
-			"attempt":   {attempt},
-			"format":    {format},
 		},
 		http.MethodPut,
 		bytes.NewBuffer(content),
 	)
-	req.Header.Set("Content-Type", "application/octet-stream")
 	if err != nil {
 		return err
 	}
+	req.Header.Set("Content-Type", "application/octet-stream")
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return err
@@ -121,9 +220,9 @@ func (c *DefaultClient) PutPlan(namespace string, layer string, run string, atte
 	if resp.StatusCode != http.StatusOK {
 		message, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return fmt.Errorf("could not put plan, there's an issue reading the response from datastore: %s", err)
+			return fmt.Errorf("could not put outputs, there's an issue reading the response from datastore: %s", err)
 		}
-		return fmt.Errorf("could not put plan, there's an issue with the storage backend: %s", string(message))
+		return fmt.Errorf("could not put outputs, there's an issue with the storage backend: %s", string(message))
 	}
 	return nil
 }
@@ -198,6 +297,72 @@ func (c *DefaultClient) PutLogs(namespace string, layer string, run string, atte
 	return nil
 }
 
+// AppendLogs appends a chunk of logs to the logs of an attempt, marking them
+// as complete when final is set. Chunks are numbered from 0 in the order they
+// are shipped, shipping a chunk again replaces it.
+func (c *DefaultClient) AppendLogs(namespace string, layer string, run string, attempt string, chunk int, content []byte, final bool) error {
+	queryParams := url.Values{
+		"namespace": {namespace},
+		"layer":     {layer},
+		"run":       {run},
+		"attempt":   {attempt},
+		"chunk":     {strconv.Itoa(chunk)},
+	}
+	if final {
+		queryParams.Set("final", "true")
+	}
+	req, err := c.buildRequest(
+		"/api/logs",
+		queryParams,
+		http.MethodPost,
+		bytes.NewBuffer(content),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not append logs, there's an issue with the storage backend")
+	}
+	return nil
+}
+
+// CheckLogsFinal returns whether the runner has shipped all the logs of an
+// attempt
+func (c *DefaultClient) CheckLogsFinal(namespace string, layer string, run string, attempt string) (bool, error) {
+	req, err := c.buildRequest(
+		"/api/logs/final",
+		url.Values{
+			"namespace": {namespace},
+			"layer":     {layer},
+			"run":       {run},
+			"attempt":   {attempt},
+		},
+		http.MethodHead,
+		nil,
+	)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("could not check logs, there's an issue with the storage backend")
+	}
+	return true, nil
+}
+
 func (c *DefaultClient) PutGitBundle(namespace, name, ref, revision string, bundle []byte) error {
 	req, err := c.buildRequest(
 		"/api/repository/revision/bundle",
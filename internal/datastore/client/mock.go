@@ -43,6 +43,22 @@ func (c *MockClient) PutPlan(namespace string, layer string, run string, attempt
 	return nil
 }
 
+func (c *MockClient) GetModulePlan(namespace string, layer string, run string, attempt string, module string, format string) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *MockClient) PutModulePlan(namespace string, layer string, run string, attempt string, module string, format string, content []byte) error {
+	return nil
+}
+
+func (c *MockClient) GetOutputs(namespace string, layer string) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *MockClient) PutOutputs(namespace string, layer string, content []byte) error {
+	return nil
+}
+
 func (c *MockClient) GetLogs(namespace string, layer string, run string, attempt string) ([]string, error) {
 	return nil, nil
 }
@@ -51,6 +67,14 @@ func (c *MockClient) PutLogs(namespace string, layer string, run string, attempt
 	return nil
 }
 
+func (c *MockClient) AppendLogs(namespace string, layer string, run string, attempt string, chunk int, content []byte, final bool) error {
+	return nil
+}
+
+func (c *MockClient) CheckLogsFinal(namespace string, layer string, run string, attempt string) (bool, error) {
+	return false, nil
+}
+
 func (c *MockClient) GetAttempts(namespace string, layer string, run string) (int, error) {
 	return 0, nil
 }
@@ -47,8 +47,12 @@ func (s *Datastore) Exec() {
 	api.Use(authz.Process)
 	api.GET("/logs", s.API.GetLogsHandler)
 	api.PUT("/logs", s.API.PutLogsHandler)
+	api.POST("/logs", s.API.AppendLogsHandler)
+	api.HEAD("/logs/final", s.API.HeadLogsFinalHandler)
 	api.GET("/plans", s.API.GetPlanHandler)
 	api.PUT("/plans", s.API.PutPlanHandler)
+	api.GET("/outputs", s.API.GetOutputsHandler)
+	api.PUT("/outputs", s.API.PutOutputsHandler)
 	api.PUT("/repository/revision/bundle", s.API.PutGitBundleHandler)
 	api.GET("/repository/revision/bundle", s.API.GetGitBundleHandler)
 	api.HEAD("/repository/revision/bundle", s.API.HeadGitBundleHandler)
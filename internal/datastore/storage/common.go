@@ -2,6 +2,7 @@ package storage
 
 import (
 	"fmt"
+	"path"
 	"slices"
 	"strconv"
 	"strings"
@@ -18,13 +19,20 @@ import (
 
 const (
 	LogFile                string = "run.log"
+	LogFinalFile           string = "run.log.final"
+	LogChunksPrefix        string = "logs"
 	PlanBinFile            string = "plan.bin"
 	PlanJsonFile           string = "plan.json"
 	PrettyPlanFile         string = "pretty.plan"
 	ShortDiffFile          string = "short.diff"
+	SummaryFile            string = "summary.json"
+	ModulesFile            string = "modules.json"
+	OutputsFile            string = "outputs.json"
+	PoliciesFile           string = "policies.json"
 	GitBundleFileExtension string = ".gitbundle"
 	RevisionFile           string = "latest"
 	LayersPrefix           string = "layers"
+	ModulesPrefix          string = "modules"
 	RepositoriesPrefix     string = "repositories"
 )
 
@@ -32,22 +40,60 @@ func computeLogsKey(namespace string, layer string, run string, attempt string)
 	return fmt.Sprintf("%s/%s/%s/%s/%s/%s", LayersPrefix, namespace, layer, run, attempt, LogFile)
 }
 
+// computeLogsFinalKey returns the key of the marker written once the runner
+// has shipped all the logs of an attempt
+func computeLogsFinalKey(namespace string, layer string, run string, attempt string) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s", LayersPrefix, namespace, layer, run, attempt, LogFinalFile)
+}
+
+// computeLogsChunksPrefix returns the prefix of the chunks of logs shipped by
+// the runner of an attempt
+func computeLogsChunksPrefix(namespace string, layer string, run string, attempt string) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s", LayersPrefix, namespace, layer, run, attempt, LogChunksPrefix)
+}
+
+func computeLogsChunkKey(namespace string, layer string, run string, attempt string, chunk int) string {
+	return fmt.Sprintf("%s/%d", computeLogsChunksPrefix(namespace, layer, run, attempt), chunk)
+}
+
 func computePlanKey(namespace string, layer string, run string, attempt string, format string) string {
-	key := ""
 	prefix := fmt.Sprintf("%s/%s/%s/%s/%s", LayersPrefix, namespace, layer, run, attempt)
+	switch format {
+	case "modules":
+		return fmt.Sprintf("%s/%s", prefix, ModulesFile)
+	case "policies":
+		return fmt.Sprintf("%s/%s", prefix, PoliciesFile)
+	}
+	return fmt.Sprintf("%s/%s", prefix, planFile(format))
+}
+
+// computeModulePlanKey returns the key of the plan of a module of a terragrunt
+// stack, stored under the prefix of the run attempt
+func computeModulePlanKey(namespace string, layer string, run string, attempt string, module string, format string) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%s/%s/%s", LayersPrefix, namespace, layer, run, attempt, ModulesPrefix, strings.Trim(module, "/"), planFile(format))
+}
+
+// computeOutputsKey returns the key of the outputs of the last apply of a
+// layer, stored once per layer as they are consumed by other layers
+func computeOutputsKey(namespace string, layer string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", LayersPrefix, namespace, layer, OutputsFile)
+}
+
+func planFile(format string) string {
 	switch format {
 	case "json":
-		key = fmt.Sprintf("%s/%s", prefix, PlanJsonFile)
+		return PlanJsonFile
 	case "pretty":
-		key = fmt.Sprintf("%s/%s", prefix, PrettyPlanFile)
+		return PrettyPlanFile
 	case "short":
-		key = fmt.Sprintf("%s/%s", prefix, ShortDiffFile)
+		return ShortDiffFile
+	case "summary":
+		return SummaryFile
 	case "bin":
-		key = fmt.Sprintf("%s/%s", prefix, PlanBinFile)
+		return PlanBinFile
 	default:
-		key = fmt.Sprintf("%s/%s", prefix, PlanJsonFile)
+		return PlanJsonFile
 	}
-	return key
 }
 
 func computeGitBundleKey(namespace string, repository string, branch string, revision string) string {
@@ -94,13 +140,55 @@ func New(config config.Config) Storage {
 	return storage
 }
 
+// GetLogs returns the logs of an attempt. The logs uploaded from the runner
+// pod are complete, otherwise the chunks shipped by the runner are returned.
 func (s *Storage) GetLogs(namespace string, layer string, run string, attempt string) ([]byte, error) {
 	data, err := s.Backend.Get(computeLogsKey(namespace, layer, run, attempt))
+	if errors.NotFound(err) {
+		return s.getLogsChunks(namespace, layer, run, attempt, err)
+	}
 	if err != nil {
 		return nil, err
-	} else {
-		return s.EncryptionManager.Decrypt(namespace, data)
 	}
+	return s.EncryptionManager.Decrypt(namespace, data)
+}
+
+// getLogsChunks concatenates the chunks of logs shipped by the runner of an
+// attempt, in the order they have been shipped. notFound is returned if the
+// runner has not shipped any chunk.
+func (s *Storage) getLogsChunks(namespace string, layer string, run string, attempt string, notFound error) ([]byte, error) {
+	keys, err := s.Backend.ListRecursive(computeLogsChunksPrefix(namespace, layer, run, attempt))
+	if errors.NotFound(err) {
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	chunks := []int{}
+	for _, key := range keys {
+		chunk, err := strconv.Atoi(path.Base(key))
+		if err != nil {
+			continue
+		}
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) == 0 {
+		return nil, notFound
+	}
+	slices.Sort(chunks)
+	logs := []byte{}
+	for _, chunk := range chunks {
+		data, err := s.Backend.Get(computeLogsChunkKey(namespace, layer, run, attempt, chunk))
+		if err != nil {
+			return nil, err
+		}
+		data, err = s.EncryptionManager.Decrypt(namespace, data)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, data...)
+	}
+	return logs, nil
 }
 
 func (s *Storage) GetLatestLogs(namespace string, layer string, run string) ([]byte, error) {
@@ -129,6 +217,44 @@ func (s *Storage) PutLogs(namespace string, layer string, run string, attempt st
 	return nil
 }
 
+// AppendLogs stores a chunk of logs of an attempt, numbered by the runner in
+// the order it ships them, so that the logs shipped before are not rewritten.
+// Storing a chunk again replaces it. When final is set, the logs of the
+// attempt are marked as complete.
+func (s *Storage) AppendLogs(namespace string, layer string, run string, attempt string, chunk int, content []byte, final bool) error {
+	if len(content) > 0 {
+		dataToStore, err := s.EncryptionManager.Encrypt(namespace, content)
+		if err != nil {
+			return err
+		}
+		err = s.Backend.Set(computeLogsChunkKey(namespace, layer, run, attempt, chunk), dataToStore, 0)
+		if err != nil {
+			return fmt.Errorf("failed to store logs: %w", err)
+		}
+	}
+	if !final {
+		return nil
+	}
+	err := s.Backend.Set(computeLogsFinalKey(namespace, layer, run, attempt), []byte{}, 0)
+	if err != nil {
+		return fmt.Errorf("failed to mark logs as final: %w", err)
+	}
+	return nil
+}
+
+// CheckLogsFinal returns whether the logs of an attempt have been marked as
+// complete by the runner
+func (s *Storage) CheckLogsFinal(namespace string, layer string, run string, attempt string) (bool, error) {
+	_, err := s.Backend.Check(computeLogsFinalKey(namespace, layer, run, attempt))
+	if errors.NotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s *Storage) GetPlan(namespace string, layer string, run string, attempt string, format string) ([]byte, error) {
 	data, err := s.Backend.Get(computePlanKey(namespace, layer, run, attempt, format))
 	if err != nil {
@@ -164,6 +290,64 @@ func (s *Storage) PutPlan(namespace string, layer string, run string, attempt st
 	return nil
 }
 
+func (s *Storage) GetModulePlan(namespace string, layer string, run string, attempt string, module string, format string) ([]byte, error) {
+	data, err := s.Backend.Get(computeModulePlanKey(namespace, layer, run, attempt, module, format))
+	if err != nil {
+		return nil, err
+	} else {
+		return s.EncryptionManager.Decrypt(namespace, data)
+	}
+}
+
+func (s *Storage) GetLatestModulePlan(namespace string, layer string, run string, module string, format string) ([]byte, error) {
+	latestAttempt, err := s.GetLatestAttempt(namespace, layer, run)
+	if err != nil {
+		return nil, err
+	}
+	if latestAttempt == "-1" {
+		return nil, &errors.StorageError{Nil: true}
+	}
+
+	return s.GetModulePlan(namespace, layer, run, latestAttempt, module, format)
+}
+
+func (s *Storage) PutModulePlan(namespace string, layer string, run string, attempt string, module string, format string, plan []byte) error {
+	dataToStore, err := s.EncryptionManager.Encrypt(namespace, plan)
+
+	if err != nil {
+		return err
+	}
+
+	err = s.Backend.Set(computeModulePlanKey(namespace, layer, run, attempt, module, format), dataToStore, 0)
+	if err != nil {
+		return fmt.Errorf("failed to store module plan: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetOutputs(namespace string, layer string) ([]byte, error) {
+	data, err := s.Backend.Get(computeOutputsKey(namespace, layer))
+	if err != nil {
+		return nil, err
+	} else {
+		return s.EncryptionManager.Decrypt(namespace, data)
+	}
+}
+
+func (s *Storage) PutOutputs(namespace string, layer string, outputs []byte) error {
+	dataToStore, err := s.EncryptionManager.Encrypt(namespace, outputs)
+
+	if err != nil {
+		return err
+	}
+
+	err = s.Backend.Set(computeOutputsKey(namespace, layer), dataToStore, 0)
+	if err != nil {
+		return fmt.Errorf("failed to store outputs: %w", err)
+	}
+	return nil
+}
+
 func (s *Storage) GetLatestAttempt(namespace string, layer string, run string) (string, error) {
 	attempts, err := s.GetAttempts(namespace, layer, run)
 
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/padok-team/burrito/internal/burrito/config"
+	errors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	"github.com/padok-team/burrito/internal/datastore/storage/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLogsTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	t.Setenv("BURRITO_DATASTORE_STORAGE_ENCRYPTION_KEY", "test-encryption-key")
+	em, err := NewEncryptionManager(config.EncryptionConfig{Enabled: true})
+	assert.NoError(t, err)
+	return &Storage{Backend: mock.New(), EncryptionManager: em}
+}
+
+func TestAppendLogsStoresChunks(t *testing.T) {
+	s := newLogsTestStorage(t)
+
+	var want strings.Builder
+	for i := 0; i < 12; i++ {
+		line := fmt.Sprintf("line %d\n", i)
+		want.WriteString(line)
+		assert.NoError(t, s.AppendLogs("default", "layer", "run", "0", i, []byte(line), false))
+	}
+	// The last chunk of a runner may be empty, it only marks the logs as final
+	assert.NoError(t, s.AppendLogs("default", "layer", "run", "0", 12, nil, true))
+
+	chunk, err := s.Backend.Get(computeLogsChunkKey("default", "layer", "run", "0", 1))
+	assert.NoError(t, err)
+	assert.NotEqual(t, "line 1\n", string(chunk), "expected the chunk to be encrypted")
+	logs, err := s.GetLogs("default", "layer", "run", "0")
+	assert.NoError(t, err)
+	assert.Equal(t, want.String(), string(logs))
+	final, err := s.CheckLogsFinal("default", "layer", "run", "0")
+	assert.NoError(t, err)
+	assert.True(t, final)
+	attempts, err := s.GetAttempts("default", "layer", "run")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0"}, attempts)
+}
+
+func TestGetLogsPrefersUploadedLogs(t *testing.T) {
+	s := newLogsTestStorage(t)
+
+	assert.NoError(t, s.AppendLogs("default", "layer", "run", "0", 0, []byte("shipped\n"), false))
+	assert.NoError(t, s.PutLogs("default", "layer", "run", "0", []byte("shipped\nuploaded from the pod\n")))
+
+	logs, err := s.GetLogs("default", "layer", "run", "0")
+	assert.NoError(t, err)
+	assert.Equal(t, "shipped\nuploaded from the pod\n", string(logs))
+}
+
+func TestGetLogsNotFound(t *testing.T) {
+	s := newLogsTestStorage(t)
+
+	_, err := s.GetLogs("default", "layer", "run", "0")
+	assert.True(t, errors.NotFound(err))
+}
@@ -0,0 +1,35 @@
+package lock
+
+import (
+	"fmt"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+)
+
+func TestGetLeaseName(t *testing.T) {
+	layer := func(path string, workspace string) *configv1alpha1.TerraformLayer {
+		return &configv1alpha1.TerraformLayer{
+			Spec: configv1alpha1.TerraformLayerSpec{
+				Path:       path,
+				Workspace:  workspace,
+				Repository: configv1alpha1.TerraformLayerRepository{Name: "burrito", Namespace: "default"},
+			},
+		}
+	}
+	if getLeaseName(layer("app/", "")) != getLeaseName(layer("app/", "")) {
+		t.Fatalf("expected layers on the same path to share a lease")
+	}
+	if getLeaseName(layer("app/", "staging")) == getLeaseName(layer("app/", "prod")) {
+		t.Fatalf("expected layers on different workspaces not to share a lease")
+	}
+	if getLeaseName(layer("app/", "staging")) == getLeaseName(layer("app/", "")) {
+		t.Fatalf("expected a layer on a workspace not to share a lease with the default workspace")
+	}
+	if getLeaseName(layer("app/", "staging")) == getLeaseName(layer("app/staging", "")) {
+		t.Fatalf("expected a layer on a workspace not to share a lease with a layer on a longer path")
+	}
+	if getLeaseName(layer("app/", "")) != fmt.Sprintf("%s-%d", lockPrefix, hash("burritodefaultapp/")) {
+		t.Fatalf("expected layers on the default workspace to keep their lease name")
+	}
+}
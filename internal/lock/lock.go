@@ -21,8 +21,17 @@ func hash(s string) uint32 {
 	return h.Sum32()
 }
 
+// getLeaseName returns the name of the lease locking the state of the layer,
+// shared by the layers on the same path and workspace of a repository
 func getLeaseName(layer *configv1alpha1.TerraformLayer) string {
-	return fmt.Sprintf("%s-%d", lockPrefix, hash(layer.Spec.Repository.Name+layer.Spec.Repository.Namespace+layer.Spec.Path))
+	key := layer.Spec.Repository.Name + layer.Spec.Repository.Namespace + layer.Spec.Path
+	if layer.Spec.Workspace != "" {
+		// The NUL separator cannot appear in a path, so a path and a workspace
+		// never hash like a longer path. Layers on the default workspace keep
+		// their lease name.
+		key += "\x00" + layer.Spec.Workspace
+	}
+	return fmt.Sprintf("%s-%d", lockPrefix, hash(key))
 }
 
 func getLeaseLock(layer *configv1alpha1.TerraformLayer, run *configv1alpha1.TerraformRun) *coordination.Lease {
@@ -0,0 +1,170 @@
+package policy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+const (
+	// ModeEnforce blocks the apply of a plan denied by the policy
+	ModeEnforce string = "Enforce"
+	// ModeWarn only reports the denials of the policy
+	ModeWarn string = "Warn"
+
+	// StatusNone means no policy applies to the layer
+	StatusNone string = "None"
+	// StatusPassed means the plan complies with all the policies
+	StatusPassed string = "Passed"
+	// StatusWarned means some policies warned about the plan, or denied it in warn mode
+	StatusWarned string = "Warned"
+	// StatusDenied means an enforced policy denied the plan, or could not be evaluated
+	StatusDenied string = "Denied"
+)
+
+// Policy is a Rego module evaluated against a JSON plan
+type Policy struct {
+	Name   string
+	Mode   string
+	Module string
+}
+
+// Result is the result of the evaluation of a policy
+type Result struct {
+	Policy   string   `json:"policy"`
+	Mode     string   `json:"mode"`
+	Denials  []string `json:"denials,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Report is the result of the evaluation of all the policies of a plan
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Digest returns a digest of the policies, which changes whenever a policy is
+// added, removed or modified. The policies must be sorted.
+func Digest(policies []Policy) string {
+	hash := sha256.New()
+	for _, p := range policies {
+		fmt.Fprintf(hash, "%s\x00%s\x00%s\x00", p.Name, p.Mode, p.Module)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Evaluate evaluates the policies against the JSON plan. A policy which
+// cannot be evaluated is reported as an error in its result, failing closed
+// in enforce mode.
+func Evaluate(ctx context.Context, policies []Policy, plan []byte) (Report, error) {
+	var input interface{}
+	if err := json.Unmarshal(plan, &input); err != nil {
+		return Report{}, fmt.Errorf("could not parse plan: %w", err)
+	}
+	report := Report{Results: []Result{}}
+	for _, p := range policies {
+		result := Result{Policy: p.Name, Mode: p.Mode}
+		denials, warnings, err := evaluate(ctx, p, input)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.Denials = denials
+		result.Warnings = warnings
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+func evaluate(ctx context.Context, p Policy, input interface{}) ([]string, []string, error) {
+	module, err := ast.ParseModule(p.Name, p.Module)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkg := module.Package.Path.String()
+	query, err := rego.New(
+		// The deny and warn rules are both optional
+		rego.Query(fmt.Sprintf(`deny := object.get(%s, "deny", set()); warn := object.get(%s, "warn", set())`, pkg, pkg)),
+		rego.ParsedModule(module),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, err := query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(results) == 0 {
+		return nil, nil, nil
+	}
+	return messages(results[0].Bindings["deny"]), messages(results[0].Bindings["warn"]), nil
+}
+
+// messages returns the sorted messages of a set or an array of messages
+func messages(value interface{}) []string {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	var msgs []string
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			msgs = append(msgs, s)
+			continue
+		}
+		b, _ := json.Marshal(v)
+		msgs = append(msgs, string(b))
+	}
+	sort.Strings(msgs)
+	return msgs
+}
+
+// Status returns the overall status of the report
+func (r Report) Status() string {
+	if len(r.Results) == 0 {
+		return StatusNone
+	}
+	status := StatusPassed
+	for _, result := range r.Results {
+		failed := len(result.Denials) > 0 || result.Error != ""
+		switch {
+		case failed && result.Mode != ModeWarn:
+			return StatusDenied
+		case failed || len(result.Warnings) > 0:
+			status = StatusWarned
+		}
+	}
+	return status
+}
+
+// Merge appends the results of another report, prefixing their messages,
+// e.g. with the module of a terragrunt stack they come from
+func (r *Report) Merge(other Report, prefix string) {
+	for _, result := range other.Results {
+		existing := -1
+		for i, res := range r.Results {
+			if res.Policy == result.Policy {
+				existing = i
+			}
+		}
+		if existing == -1 {
+			r.Results = append(r.Results, Result{Policy: result.Policy, Mode: result.Mode})
+			existing = len(r.Results) - 1
+		}
+		merged := &r.Results[existing]
+		for _, d := range result.Denials {
+			merged.Denials = append(merged.Denials, prefix+d)
+		}
+		for _, w := range result.Warnings {
+			merged.Warnings = append(merged.Warnings, prefix+w)
+		}
+		if result.Error != "" && merged.Error == "" {
+			merged.Error = prefix + result.Error
+		}
+	}
+}
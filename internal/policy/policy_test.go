@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const testPlan = `{
+	"resource_changes": [
+		{"address": "aws_s3_bucket.logs", "type": "aws_s3_bucket", "change": {"actions": ["create"]}},
+		{"address": "aws_instance.web", "type": "aws_instance", "change": {"actions": ["delete"]}}
+	]
+}`
+
+const denyDeletions = `package burrito.deletions
+
+deny contains msg if {
+	some rc in input.resource_changes
+	"delete" in rc.change.actions
+	msg := sprintf("%s must not be deleted", [rc.address])
+}
+`
+
+const warnBuckets = `package burrito.buckets
+
+warn contains msg if {
+	some rc in input.resource_changes
+	rc.type == "aws_s3_bucket"
+	msg := sprintf("%s is a new bucket", [rc.address])
+}
+`
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name       string
+		policies   []Policy
+		wantStatus string
+		want       []Result
+	}{
+		{
+			name:       "no policies",
+			policies:   []Policy{},
+			wantStatus: StatusNone,
+			want:       []Result{},
+		},
+		{
+			name:       "enforced deny",
+			policies:   []Policy{{Name: "deletions", Mode: ModeEnforce, Module: denyDeletions}, {Name: "buckets", Mode: ModeEnforce, Module: warnBuckets}},
+			wantStatus: StatusDenied,
+			want: []Result{
+				{Policy: "deletions", Mode: ModeEnforce, Denials: []string{"aws_instance.web must not be deleted"}},
+				{Policy: "buckets", Mode: ModeEnforce, Warnings: []string{"aws_s3_bucket.logs is a new bucket"}},
+			},
+		},
+		{
+			name:       "deny in warn mode",
+			policies:   []Policy{{Name: "deletions", Mode: ModeWarn, Module: denyDeletions}},
+			wantStatus: StatusWarned,
+			want: []Result{
+				{Policy: "deletions", Mode: ModeWarn, Denials: []string{"aws_instance.web must not be deleted"}},
+			},
+		},
+		{
+			name:       "only warnings",
+			policies:   []Policy{{Name: "buckets", Mode: ModeEnforce, Module: warnBuckets}},
+			wantStatus: StatusWarned,
+			want: []Result{
+				{Policy: "buckets", Mode: ModeEnforce, Warnings: []string{"aws_s3_bucket.logs is a new bucket"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Evaluate(context.Background(), tt.policies, []byte(testPlan))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(report.Results, tt.want) {
+				t.Fatalf("expected results %+v, got %+v", tt.want, report.Results)
+			}
+			if report.Status() != tt.wantStatus {
+				t.Fatalf("expected status %s, got %s", tt.wantStatus, report.Status())
+			}
+		})
+	}
+}
+
+func TestEvaluateInvalidPolicy(t *testing.T) {
+	for _, mode := range []string{ModeEnforce, ModeWarn} {
+		report, err := Evaluate(context.Background(), []Policy{{Name: "invalid", Mode: mode, Module: "package burrito.invalid\n\ndeny contains msg if {"}}, []byte(testPlan))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if report.Results[0].Error == "" {
+			t.Fatalf("expected the result of an invalid policy to have an error")
+		}
+		want := map[string]string{ModeEnforce: StatusDenied, ModeWarn: StatusWarned}[mode]
+		if report.Status() != want {
+			t.Fatalf("expected status %s in %s mode, got %s", want, mode, report.Status())
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	report := Report{Results: []Result{}}
+	report.Merge(Report{Results: []Result{{Policy: "deletions", Mode: ModeEnforce, Denials: []string{"a must not be deleted"}}}}, "network: ")
+	report.Merge(Report{Results: []Result{{Policy: "deletions", Mode: ModeEnforce, Denials: []string{"b must not be deleted"}}}}, "cluster: ")
+	want := []Result{{Policy: "deletions", Mode: ModeEnforce, Denials: []string{"network: a must not be deleted", "cluster: b must not be deleted"}}}
+	if !reflect.DeepEqual(report.Results, want) {
+		t.Fatalf("expected results %+v, got %+v", want, report.Results)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	policies := []Policy{{Name: "deletions", Mode: ModeEnforce, Module: denyDeletions}}
+	digest := Digest(policies)
+	if Digest([]Policy{{Name: "deletions", Mode: ModeEnforce, Module: denyDeletions}}) != digest {
+		t.Fatalf("expected the digest of the same policies to be stable")
+	}
+	if Digest([]Policy{{Name: "deletions", Mode: ModeWarn, Module: denyDeletions}}) == digest {
+		t.Fatalf("expected the digest to change with the mode of a policy")
+	}
+	if Digest(nil) == digest {
+		t.Fatalf("expected the digest to change when a policy is removed")
+	}
+}
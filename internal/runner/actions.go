@@ -27,23 +27,77 @@ func (r *Runner) ExecAction() error {
 
 	switch r.config.Runner.Action {
 	case "plan":
-		sum, err := r.execPlan()
+		sum, destructiveChanges, err := r.execPlan()
 		if err != nil {
 			return err
 		}
+		if r.isTargeted() {
+			// A targeted plan does not reflect the whole layer, it must not
+			// replace the last plan used by the drift detection and the
+			// automatic apply. It is applied once explicitly approved.
+			ann[annotations.LastTargetedPlanDestructiveChanges] = destructiveChanges
+			ann[annotations.LastTargetedPlanRun] = fmt.Sprintf("%s/%s", r.Run.Name, r.attemptNumber())
+			ann[annotations.LastTargetedPlanSum] = sum
+			ann[annotations.LastTargetedPlanCommit] = r.Run.Spec.Layer.Revision
+			// The apply of a targeted plan must be restricted to the same resources
+			ann[annotations.LastPlanTargets], ann[annotations.LastPlanReplace], err = r.getTargetAnnotations()
+			if err != nil {
+				return err
+			}
+			break
+		}
+		ann[annotations.LastPlanDestructiveChanges] = destructiveChanges
 		ann[annotations.LastPlanDate] = time.Now().Format(time.UnixDate)
-		ann[annotations.LastPlanRun] = fmt.Sprintf("%s/%s", r.Run.Name, strconv.Itoa(r.Run.Status.Retries))
+		ann[annotations.LastPlanRun] = fmt.Sprintf("%s/%s", r.Run.Name, r.attemptNumber())
 		ann[annotations.LastPlanSum] = sum
 		ann[annotations.LastPlanCommit] = r.Run.Spec.Layer.Revision
+		if len(r.Layer.Spec.InputsFrom) > 0 {
+			ann[annotations.LastPlanInputs] = r.inputs
+		}
 
 	case "apply":
 		sum, err := r.execApply()
 		if err != nil {
 			return err
 		}
-		ann[annotations.LastApplyDate] = time.Now().Format(time.UnixDate)
-		ann[annotations.LastApplySum] = sum
-		ann[annotations.LastApplyCommit] = r.Run.Spec.Layer.Revision
+		if r.isTargeted() {
+			// The state has changed but the last plan of the whole layer has
+			// not been applied, it must be planned again
+			ann[annotations.SyncNow] = "true"
+		} else {
+			ann[annotations.LastApplyDate] = time.Now().Format(time.UnixDate)
+			ann[annotations.LastApplySum] = sum
+			ann[annotations.LastApplyCommit] = r.Run.Spec.Layer.Revision
+		}
+		if r.isStack() {
+			if r.Layer.Spec.Outputs != nil {
+				log.Warningf("outputs are not supported for terragrunt stacks, they are not written")
+			}
+		} else if err := r.publishOutputs(ann); err != nil {
+			// The apply succeeded, a failure to publish the outputs must not
+			// trigger a new apply, it is reported on the layer instead
+			ann[annotations.LastOutputsError] = err.Error()
+		}
+	case "drift-check":
+		drifted, err := r.execDriftCheck()
+		if err != nil {
+			return err
+		}
+		ann[annotations.LastDriftCheckDate] = time.Now().Format(time.UnixDate)
+		ann[annotations.LastDriftCheckRun] = fmt.Sprintf("%s/%s", r.Run.Name, r.attemptNumber())
+		ann[annotations.LastDriftCheckResult] = strconv.FormatBool(drifted)
+	case "destroy":
+		// The layer is being deleted, there are no annotations to update
+		return r.execDestroy()
+	case "import", "state-mv", "state-rm", "force-unlock":
+		err := r.execStateAction()
+		if err != nil {
+			return err
+		}
+		if r.config.Runner.Action != "force-unlock" {
+			// The state has changed, the last plan of the layer is outdated
+			ann[annotations.SyncNow] = "true"
+		}
 	default:
 		return errors.New("unrecognized runner action, if this is happening there might be a version mismatch between the controller and runner")
 	}
@@ -55,10 +109,43 @@ func (r *Runner) ExecAction() error {
 	}
 	log.Infof("successfully updated TerraformLayer annotations")
 
+	if r.config.Runner.Action == "plan" {
+		// A review only applies to the plan it has been made on
+		outdated := annotations.RejectPlan
+		if r.isTargeted() {
+			outdated = annotations.ApproveTargetedPlan
+		}
+		err = r.removeLayerAnnotations(outdated)
+		if err != nil {
+			log.Errorf("could not remove the annotations of the previous plan: %s", err)
+			return err
+		}
+	}
+	if _, failed := ann[annotations.LastOutputsError]; r.config.Runner.Action == "apply" && !failed {
+		err = r.removeLayerAnnotations(annotations.LastOutputsError)
+		if err != nil {
+			log.Errorf("could not remove the outputs error of the previous apply: %s", err)
+			return err
+		}
+	}
 	return nil
 }
 
-// Run the `init` command
+// removeLayerAnnotations removes the given annotations from the layer, if set
+func (r *Runner) removeLayerAnnotations(keys ...string) error {
+	for _, key := range keys {
+		if _, ok := r.Layer.Annotations[key]; !ok {
+			continue
+		}
+		err := annotations.Remove(context.TODO(), r.Client, r.Layer, key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run the `init` command and select the workspace of the layer
 func (r *Runner) ExecInit() error {
 	log.Infof("launching %s init in %s", r.exec.TenvName(), r.workingDir)
 	if r.exec == nil {
@@ -70,34 +157,128 @@ func (r *Runner) ExecInit() error {
 		log.Errorf("error executing %s init: %s", r.exec.TenvName(), err)
 		return err
 	}
+	workspace := r.Layer.Spec.Workspace
+	if workspace == "" {
+		return nil
+	}
+	if r.isStack() {
+		return errors.New("workspaces are not supported for terragrunt stacks")
+	}
+	log.Infof("selecting %s workspace %s", r.exec.TenvName(), workspace)
+	err = r.exec.SelectWorkspace(workspace)
+	if err != nil {
+		log.Errorf("error selecting %s workspace %s: %s", r.exec.TenvName(), workspace, err)
+		return err
+	}
 	return nil
 }
 
 // Run the `plan` command and save the plan artifact in the datastore
-// Returns the sha256 sum of the plan artifact
-func (r *Runner) execPlan() (string, error) {
+// Returns the sha256 sum of the plan artifact and how its destructive changes
+// must be handled
+func (r *Runner) execPlan() (string, string, error) {
 	log.Infof("running %s plan", r.exec.TenvName())
 	if r.exec == nil {
 		err := errors.New("terraform or terragrunt binary not installed")
-		return "", err
+		return "", "", err
+	}
+	if r.isStack() {
+		if r.isTargeted() {
+			return "", "", errors.New("targeted runs are not supported for terragrunt stacks")
+		}
+		return r.execStackPlan()
 	}
-	err := r.exec.Plan(PlanArtifact)
+	destructiveChanges := runnerutils.DestructiveChangesNone
+	sum, _, err := r.runPlan(func(planArtifactPath string) error {
+		return r.exec.Plan(planArtifactPath, r.Run.Spec.Targets, r.Run.Spec.Replace)
+	}, func(plan *tfjson.Plan) (bool, string) {
+		destructiveChanges = r.checkDestructiveChanges(plan)
+		return runnerutils.GetDiff(plan)
+	})
+	return sum, destructiveChanges, err
+}
+
+// checkDestructiveChanges checks the resources deleted or replaced by the plan
+// against the destructive changes strategy of the layer, and returns how the
+// plan must be handled
+func (r *Runner) checkDestructiveChanges(plan *tfjson.Plan) string {
+	strategy := configv1alpha1.GetDestructiveChanges(r.Repository, r.Layer)
+	result, blocking := runnerutils.CheckDestructiveChanges(plan, strategy, configv1alpha1.GetDestructiveResourceTypes(r.Repository, r.Layer))
+	for _, address := range blocking {
+		log.Warnf("plan deletes or replaces resource %s, which is not allowed by the destructive changes strategy", address)
+	}
+	return result
+}
+
+// Run the `plan -refresh-only` command and save the plan artifact in the datastore
+// Returns whether resources have been changed outside of Terraform
+func (r *Runner) execDriftCheck() (bool, error) {
+	log.Infof("running %s drift check", r.exec.TenvName())
+	if r.exec == nil {
+		err := errors.New("terraform or terragrunt binary not installed")
+		return false, err
+	}
+	if r.isStack() {
+		return false, errors.New("drift checks are not supported for terragrunt stacks")
+	}
+	_, drifted, err := r.runPlan(r.exec.PlanRefreshOnly, runnerutils.GetDrift)
+	if err != nil {
+		return false, err
+	}
+	log.Infof("%s drift check ran successfully, drift detected: %t", r.exec.TenvName(), drifted)
+	return drifted, nil
+}
+
+// Run the `plan -destroy` command, save the plan artifact in the datastore
+// and apply it to destroy all the resources of the layer
+func (r *Runner) execDestroy() error {
+	log.Infof("running %s destroy", r.exec.TenvName())
+	if r.exec == nil {
+		err := errors.New("terraform or terragrunt binary not installed")
+		return err
+	}
+	if r.isStack() {
+		return errors.New("destroy is not supported for terragrunt stacks")
+	}
+	_, _, err := r.runPlan(r.exec.PlanDestroy, runnerutils.GetDiff)
+	if err != nil {
+		return err
+	}
+	log.Infof("launching %s apply of the destroy plan", r.exec.TenvName())
+	err = r.exec.Apply(PlanArtifact, nil, nil)
+	if err != nil {
+		log.Errorf("error executing %s apply of the destroy plan: %s", r.exec.TenvName(), err)
+		return err
+	}
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, r.attemptNumber(), "short", []byte("Destroy Successful"))
+	if err != nil {
+		log.Errorf("could not put short plan in datastore: %s", err)
+	}
+	log.Infof("%s destroy ran successfully", r.exec.TenvName())
+	return nil
+}
+
+// runPlan runs the given plan command and saves the resulting plan artifact
+// in the datastore, along with the summary produced by summarize.
+// Returns the sha256 sum of the plan artifact and whether it has changes
+func (r *Runner) runPlan(planCmd func(string) error, summarize func(*tfjson.Plan) (bool, string)) (string, bool, error) {
+	err := planCmd(PlanArtifact)
 	if err != nil {
 		log.Errorf("error executing %s plan: %s", r.exec.TenvName(), err)
-		return "", err
+		return "", false, err
 	}
 	planJsonBytes, err := r.exec.Show(PlanArtifact, "json")
 	if err != nil {
 		log.Errorf("error getting %s plan json: %s", r.exec.TenvName(), err)
-		return "", err
+		return "", false, err
 	}
 	prettyPlan, err := r.exec.Show(PlanArtifact, "pretty")
 	if err != nil {
 		log.Errorf("error getting %s pretty plan: %s", r.exec.TenvName(), err)
-		return "", err
+		return "", false, err
 	}
 	log.Infof("sending plan to datastore")
-	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "pretty", prettyPlan)
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, r.attemptNumber(), "pretty", r.redactor.Redact(prettyPlan))
 	if err != nil {
 		log.Errorf("could not put pretty plan in datastore: %s", err)
 	}
@@ -105,30 +286,53 @@ func (r *Runner) execPlan() (string, error) {
 	err = json.Unmarshal(planJsonBytes, plan)
 	if err != nil {
 		log.Errorf("error parsing %s json plan: %s", r.exec.TenvName(), err)
-		return "", err
+		return "", false, err
 	}
-	_, shortDiff := runnerutils.GetDiff(plan)
-	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "json", planJsonBytes)
+	hasChanges, shortDiff := summarize(plan)
+	displayPlanJson, err := r.redactJsonPlan(planJsonBytes)
 	if err != nil {
-		log.Errorf("could not put json plan in datastore: %s", err)
+		log.Errorf("could not strip sensitive values from json plan: %s", err)
+	} else {
+		err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, r.attemptNumber(), "json", displayPlanJson)
+		if err != nil {
+			log.Errorf("could not put json plan in datastore: %s", err)
+		}
 	}
-	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "short", []byte(shortDiff))
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, r.attemptNumber(), "short", r.redactor.Redact([]byte(shortDiff)))
 	if err != nil {
 		log.Errorf("could not put short plan in datastore: %s", err)
 	}
+	summary, err := json.Marshal(runnerutils.GetSummary(plan))
+	if err != nil {
+		return "", false, err
+	}
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, r.attemptNumber(), "summary", summary)
+	if err != nil {
+		log.Errorf("could not put plan summary in datastore: %s", err)
+	}
 	planBin, err := os.ReadFile(PlanArtifact)
 	if err != nil {
 		log.Errorf("could not read plan output: %s", err)
-		return "", err
+		return "", false, err
 	}
 	sum := sha256.Sum256(planBin)
-	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "bin", planBin)
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, r.attemptNumber(), "bin", planBin)
 	if err != nil {
 		log.Errorf("could not put plan binary in cache: %s", err)
-		return "", err
+		return "", false, err
 	}
 	log.Infof("%s plan ran successfully", r.exec.TenvName())
-	return b64.StdEncoding.EncodeToString(sum[:]), nil
+	return b64.StdEncoding.EncodeToString(sum[:]), hasChanges, nil
+}
+
+// redactJsonPlan strips the sensitive values from a JSON plan and masks the
+// redacted values, for the plan to be stored for display
+func (r *Runner) redactJsonPlan(planJson []byte) ([]byte, error) {
+	stripped, err := runnerutils.StripSensitiveValues(planJson)
+	if err != nil {
+		return nil, err
+	}
+	return r.redactor.Redact(stripped), nil
 }
 
 // Run the `apply` command, by default with the plan artifact from the previous plan run
@@ -139,6 +343,9 @@ func (r *Runner) execApply() (string, error) {
 		err := fmt.Errorf("%s binary not installed", r.exec.TenvName())
 		return "", err
 	}
+	if r.isStack() {
+		return r.execStackApply()
+	}
 	log.Infof("getting plan binary in datastore at key %s/%s/%s/%s", r.Layer.Namespace, r.Layer.Name, r.Run.Spec.Artifact.Run, r.Run.Spec.Artifact.Attempt)
 	plan, err := r.Datastore.GetPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Spec.Artifact.Run, r.Run.Spec.Artifact.Attempt, "bin")
 	if err != nil {
@@ -154,18 +361,38 @@ func (r *Runner) execApply() (string, error) {
 	log.Infof("launching %s apply", r.exec.TenvName())
 	if configv1alpha1.GetApplyWithoutPlanArtifactEnabled(r.Repository, r.Layer) {
 		log.Infof("applying without reusing plan artifact from previous plan run")
-		err = r.exec.Apply("")
+		err = r.exec.Apply("", r.Run.Spec.Targets, r.Run.Spec.Replace)
 	} else {
-		err = r.exec.Apply(PlanArtifact)
+		err = r.exec.Apply(PlanArtifact, nil, nil)
 	}
 	if err != nil {
 		log.Errorf("error executing %s apply: %s", r.exec.TenvName(), err)
 		return "", err
 	}
-	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, strconv.Itoa(r.Run.Status.Retries), "short", []byte("Apply Successful"))
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, r.attemptNumber(), "short", []byte("Apply Successful"))
 	if err != nil {
 		log.Errorf("could not put short plan in datastore: %s", err)
 	}
 	log.Infof("%s apply ran successfully", r.exec.TenvName())
 	return b64.StdEncoding.EncodeToString(sum[:]), nil
 }
+
+// isTargeted returns whether the run is restricted to some resources of the
+// layer, in which case it does not reflect the state of the whole layer
+func (r *Runner) isTargeted() bool {
+	return len(r.Run.Spec.Targets) > 0 || len(r.Run.Spec.Replace) > 0
+}
+
+// getTargetAnnotations returns the targets and the replaced resources of the
+// run, encoded for the last plan annotations of the layer
+func (r *Runner) getTargetAnnotations() (string, string, error) {
+	targets, err := json.Marshal(r.Run.Spec.Targets)
+	if err != nil {
+		return "", "", err
+	}
+	replace, err := json.Marshal(r.Run.Spec.Replace)
+	if err != nil {
+		return "", "", err
+	}
+	return string(targets), string(replace), nil
+}
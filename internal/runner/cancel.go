@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	c "github.com/padok-team/burrito/internal/utils/cmd"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const CancelPollInterval = 5 * time.Second
+
+var errCancelled = errors.New("run has been cancelled")
+
+// watchCancellation polls the run until the context is done and interrupts the
+// running command once the run is annotated as cancelled, or once the
+// controller asks this runner pod to stop because its attempt has timed out.
+// The command receives a SIGINT so that terraform stops gracefully and
+// releases its state lock, the runner keeps running to upload its logs and no
+// other command is started.
+func (r *Runner) watchCancellation(ctx context.Context) {
+	ticker := time.NewTicker(CancelPollInterval)
+	defer ticker.Stop()
+	for {
+		run := &configv1alpha1.TerraformRun{}
+		err := r.Client.Get(ctx, types.NamespacedName{
+			Namespace: r.config.Runner.Layer.Namespace,
+			Name:      r.config.Runner.Run,
+		}, run)
+		if err != nil && ctx.Err() == nil {
+			log.Warningf("could not check whether the run has been cancelled: %s", err)
+		}
+		if user, ok := run.Annotations[annotations.CancelRun]; ok {
+			log.Infof("run has been cancelled by %s, interrupting the running command", user)
+			r.cancelled.Store(true)
+			err = c.Running.Interrupt()
+			if err != nil {
+				log.Errorf("could not interrupt the running command: %s", err)
+			}
+			return
+		}
+		if pod := run.Annotations[annotations.InterruptRunner]; pod != "" && pod == r.config.Runner.Pod {
+			log.Infof("attempt has timed out, interrupting the running command")
+			err = c.Running.Interrupt()
+			if err != nil {
+				log.Errorf("could not interrupt the running command: %s", err)
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/burrito/config"
+	c "github.com/padok-team/burrito/internal/utils/cmd"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newCancelTestRunner(t *testing.T, run *configv1alpha1.TerraformRun) *Runner {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := configv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add burrito types to scheme: %s", err)
+	}
+	cfg := config.TestConfig()
+	cfg.Runner.Layer.Namespace = run.Namespace
+	cfg.Runner.Run = run.Name
+	r := New(cfg)
+	r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(run).Build()
+	return r
+}
+
+// useCommandGroup runs the commands of the test in their own group
+func useCommandGroup(t *testing.T) {
+	t.Helper()
+
+	running := c.Running
+	c.Running = &c.Group{}
+	t.Cleanup(func() { c.Running = running })
+}
+
+func TestWatchCancellationInterruptsCommand(t *testing.T) {
+	useCommandGroup(t)
+	run := &configv1alpha1.TerraformRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "run",
+			Namespace:   "default",
+			Annotations: map[string]string{annotations.CancelRun: "alice@example.com"},
+		},
+	}
+	r := newCancelTestRunner(t, run)
+
+	started := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		cmd := exec.Command("sleep", "30")
+		close(started)
+		done <- c.Run(cmd)
+	}()
+	<-started
+	// Let the command start before the run is checked
+	time.Sleep(100 * time.Millisecond)
+	r.watchCancellation(context.Background())
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("expected the interrupted command to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the running command to be interrupted")
+	}
+	if !r.cancelled.Load() {
+		t.Fatalf("expected the runner to be marked as cancelled")
+	}
+	if err := c.Run(exec.Command("true")); !errors.Is(err, c.ErrInterrupted) {
+		t.Fatalf("expected no command to start once the run is cancelled, got %v", err)
+	}
+}
+
+func TestWatchCancellationStopsWithContext(t *testing.T) {
+	useCommandGroup(t)
+	run := &configv1alpha1.TerraformRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"},
+	}
+	r := newCancelTestRunner(t, run)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		r.watchCancellation(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the watcher to stop with its context")
+	}
+	if r.cancelled.Load() {
+		t.Fatalf("expected a run which is not cancelled to keep running")
+	}
+	if err := c.Run(exec.Command("true")); err != nil {
+		t.Fatalf("expected commands to keep running, got %v", err)
+	}
+}
+
+func TestWatchCancellationInterruptsTimedOutAttempt(t *testing.T) {
+	tests := []struct {
+		name        string
+		pod         string
+		interrupted bool
+	}{
+		{name: "this runner pod", pod: "run-pod-1", interrupted: true},
+		{name: "another runner pod", pod: "run-pod-0", interrupted: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCommandGroup(t)
+			run := &configv1alpha1.TerraformRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "run",
+					Namespace:   "default",
+					Annotations: map[string]string{annotations.InterruptRunner: tt.pod},
+				},
+			}
+			r := newCancelTestRunner(t, run)
+			r.config.Runner.Pod = "run-pod-1"
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			r.watchCancellation(ctx)
+
+			err := c.Run(exec.Command("true"))
+			if interrupted := errors.Is(err, c.ErrInterrupted); interrupted != tt.interrupted {
+				t.Fatalf("expected interrupted to be %t, got %v", tt.interrupted, err)
+			}
+			if r.cancelled.Load() {
+				t.Fatalf("expected a timed out attempt not to be marked as cancelled")
+			}
+		})
+	}
+}
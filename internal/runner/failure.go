@@ -0,0 +1,25 @@
+package runner
+
+import (
+	"os"
+
+	c "github.com/padok-team/burrito/internal/utils/cmd"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+	log "github.com/sirupsen/logrus"
+)
+
+// TerminationMessagePath is where kubernetes reads the termination message of
+// the runner container, which holds the class of the failure of the run
+const TerminationMessagePath string = "/dev/termination-log"
+
+// reportFailure classifies the failure of the run from the standard error of
+// the command which failed, for the controller to apply the matching retry
+// policy
+func (r *Runner) reportFailure() {
+	class := runnerutils.ClassifyFailure(c.Running.FailedStderr())
+	log.Infof("run failed with a failure of class %s", class)
+	err := os.WriteFile(TerminationMessagePath, []byte(class), 0644)
+	if err != nil {
+		log.Warningf("could not write the failure class to %s: %s", TerminationMessagePath, err)
+	}
+}
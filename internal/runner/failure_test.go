@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"os/exec"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	c "github.com/padok-team/burrito/internal/utils/cmd"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+)
+
+func TestFailureIsClassifiedFromStderrOfFailedCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		class    string
+	}{
+		{
+			name:     "error of the failed command",
+			commands: []string{`echo "Error acquiring the state lock" >&2; exit 1`},
+			class:    configv1alpha1.FailureClassStateLocked,
+		},
+		{
+			name:     "error in the standard output",
+			commands: []string{`echo "Error acquiring the state lock"; exit 1`},
+			class:    configv1alpha1.FailureClassUnknown,
+		},
+		{
+			name: "error of a previous command which succeeded",
+			commands: []string{
+				`echo "Rate exceeded, retrying" >&2`,
+				`echo "Error: Unsupported argument" >&2; exit 1`,
+			},
+			class: configv1alpha1.FailureClassValidation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCommandGroup(t)
+			for _, command := range tt.commands {
+				cmd := exec.Command("sh", "-c", command)
+				c.Verbose(cmd)
+				_ = c.Run(cmd)
+			}
+			if class := runnerutils.ClassifyFailure(c.Running.FailedStderr()); class != tt.class {
+				t.Fatalf("expected failure class %s, got %s", tt.class, class)
+			}
+		})
+	}
+}
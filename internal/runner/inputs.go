@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	log "github.com/sirupsen/logrus"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The inputs file is loaded before the var files and the variables files, so
+// that variables set on the layer take precedence over the inputs
+const InputsFile string = "burrito-inputs.auto.tfvars"
+
+// Write the outputs of the upstream layers used as inputs of the layer in the
+// working directory as a *.auto.tfvars file. Values are never logged as they
+// may be sensitive.
+// Returns the value of the LastPlanInputs annotation matching the inputs.
+func (r *Runner) writeInputs() (string, error) {
+	if len(r.Layer.Spec.InputsFrom) == 0 {
+		return "", nil
+	}
+	if r.isStack() {
+		return "", errors.New("inputsFrom is not supported for terragrunt stacks")
+	}
+	upstreams := []client.Object{}
+	variables := []variable{}
+	for _, input := range r.Layer.Spec.InputsFrom {
+		key := types.NamespacedName{Namespace: input.Layer.Namespace, Name: input.Layer.Name}
+		if key.Namespace == "" {
+			key.Namespace = r.Layer.Namespace
+		}
+		// The upstream layer is fetched before its outputs, so that a
+		// concurrent apply is at worst detected as a change of the inputs
+		upstream := &configv1alpha1.TerraformLayer{}
+		err := r.Client.Get(context.TODO(), key, upstream)
+		if k8serrors.IsNotFound(err) && r.config.Runner.Action == "destroy" {
+			// Layers may be deleted in any order, the destroy of a downstream
+			// layer uses the last outputs stored by a deleted upstream layer
+			log.Warningf("upstream layer %s not found, using its last stored outputs", key)
+		} else if err != nil {
+			return "", fmt.Errorf("could not get upstream layer %s: %w", key, err)
+		} else {
+			upstreams = append(upstreams, upstream)
+		}
+		outputs, err := r.getUpstreamOutputs(key)
+		if err != nil {
+			return "", err
+		}
+		for _, o := range input.Outputs {
+			value, ok := outputs[o.Name]
+			if !ok {
+				return "", fmt.Errorf("output %s not found in layer %s", o.Name, key)
+			}
+			rendered, err := renderOutputValue(value)
+			if err != nil {
+				return "", fmt.Errorf("could not read the value of output %s of layer %s", o.Name, key)
+			}
+			name := o.Variable
+			if name == "" {
+				name = o.Name
+			}
+			variables = append(variables, variable{name: name, value: rendered, hcl: true})
+		}
+	}
+	content, err := renderVariables(variables)
+	if err != nil {
+		return "", err
+	}
+	err = os.WriteFile(filepath.Join(r.workingDir, InputsFile), content, 0600)
+	if err != nil {
+		return "", fmt.Errorf("could not write inputs file: %w", err)
+	}
+	log.Infof("wrote %d input(s) from %d layer(s) to %s", len(variables), len(upstreams), InputsFile)
+	return annotations.ComputeInputs(upstreams), nil
+}
+
+func (r *Runner) getUpstreamOutputs(key types.NamespacedName) (map[string]output, error) {
+	raw, err := r.Datastore.GetOutputs(key.Namespace, key.Name)
+	if storageerrors.NotFound(err) {
+		return nil, fmt.Errorf("layer %s has no outputs yet, it must be applied first", key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get outputs of layer %s: %w", key, err)
+	}
+	outputs := map[string]output{}
+	err = json.Unmarshal(raw, &outputs)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse outputs of layer %s: %w", key, err)
+	}
+	return outputs, nil
+}
+
+// renderOutputValue renders the JSON value of an output as an HCL expression
+func renderOutputValue(o output) (string, error) {
+	ty, err := ctyjson.ImpliedType(o.Value)
+	if err != nil {
+		return "", err
+	}
+	value, err := ctyjson.Unmarshal(o.Value, ty)
+	if err != nil {
+		return "", err
+	}
+	return string(hclwrite.TokensForValue(value).Bytes()), nil
+}
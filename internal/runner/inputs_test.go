@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/burrito/config"
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRenderOutputValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: `"vpc-123"`, want: `"vpc-123"`},
+		{value: `"${not.interpolated}"`, want: `"$${not.interpolated}"`},
+		{value: `3`, want: `3`},
+		{value: `["a", "b"]`, want: `["a", "b"]`},
+		{value: `{"env": "production"}`, want: "{\n  env = \"production\"\n}"},
+	}
+	for _, tt := range tests {
+		got, err := renderOutputValue(output{Value: json.RawMessage(tt.value)})
+		if err != nil {
+			t.Fatalf("unexpected error rendering %s: %s", tt.value, err)
+		}
+		if got != tt.want {
+			t.Fatalf("expected %s to be rendered as %q, got %q", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestWriteInputsOfDeletedUpstream(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := configv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	layer := &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: configv1alpha1.TerraformLayerSpec{
+			InputsFrom: []configv1alpha1.TerraformLayerInput{{
+				Layer:   configv1alpha1.TerraformLayerReference{Name: "network"},
+				Outputs: []configv1alpha1.TerraformLayerInputOutput{{Name: "vpc_id"}},
+			}},
+		},
+	}
+	conf := config.TestConfig()
+	r := &Runner{
+		config:     conf,
+		Client:     fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Datastore:  &outputsDatastore{MockClient: datastore.NewMockClient(), outputs: []byte(`{"vpc_id": {"sensitive": false, "value": "vpc-123"}}`)},
+		Layer:      layer,
+		Repository: &configv1alpha1.TerraformRepository{},
+		workingDir: t.TempDir(),
+	}
+
+	conf.Runner.Action = "plan"
+	if _, err := r.writeInputs(); err == nil {
+		t.Fatalf("expected a plan to fail when an upstream layer is missing")
+	}
+	conf.Runner.Action = "destroy"
+	if _, err := r.writeInputs(); err != nil {
+		t.Fatalf("expected a destroy to use the stored outputs of a deleted upstream layer: %s", err)
+	}
+	content, err := os.ReadFile(filepath.Join(r.workingDir, InputsFile))
+	if err != nil || !strings.Contains(string(content), `vpc_id = "vpc-123"`) {
+		t.Fatalf("expected the stored outputs to be written, got %q (%v)", content, err)
+	}
+}
@@ -0,0 +1,160 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	"github.com/padok-team/burrito/internal/utils/redact"
+	log "github.com/sirupsen/logrus"
+)
+
+// LogShipInterval is the interval at which the runner ships its logs to the
+// datastore
+const LogShipInterval = 5 * time.Second
+
+// LogShipMaxFailures is the number of consecutive failures to ship the logs
+// after which the runner stops shipping them
+const LogShipMaxFailures = 5
+
+// logShipRetryDelay is the delay before shipping the last logs again when the
+// datastore fails
+var logShipRetryDelay = time.Second
+
+// logShipper buffers the output of the runner and of its commands, and
+// appends it to the logs of the attempt in the datastore, so that the logs are
+// kept even if the runner pod disappears. Only complete lines are shipped, so
+// that sensitive values are masked even if they are written in several parts.
+// Once the runner ends, the logs are marked as final and the controller does
+// not upload them from the pod.
+type logShipper struct {
+	mu      sync.Mutex
+	sending sync.Mutex
+	buf     []byte
+
+	datastore datastore.Client
+	redactor  *redact.Redactor
+	namespace string
+	layer     string
+	run       string
+	attempt   string
+	started   bool
+	// Number of the next chunk, a chunk which could not be shipped is shipped
+	// again with the same number
+	chunk int
+	// Number of consecutive chunks which could not be shipped
+	failures int
+	// Whether the runner gave up shipping the logs, in which case the
+	// controller uploads the logs from the pod instead
+	failed bool
+}
+
+func (s *logShipper) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.failed {
+		s.buf = append(s.buf, p...)
+	}
+	return len(p), nil
+}
+
+// start sets the attempt the logs belong to. The output written before is
+// shipped with the first chunk.
+func (s *logShipper) start(client datastore.Client, redactor *redact.Redactor, namespace, layer, run, attempt string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.datastore = client
+	s.redactor = redactor
+	s.namespace = namespace
+	s.layer = layer
+	s.run = run
+	s.attempt = attempt
+	s.started = true
+}
+
+// ship periodically ships the buffered logs until the context is done
+func (s *logShipper) ship(ctx context.Context) {
+	ticker := time.NewTicker(LogShipInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.flush(false)
+		}
+	}
+}
+
+// close ships the remaining logs and marks them as final, retrying until the
+// datastore accepts them or the runner gives up
+func (s *logShipper) close() {
+	s.flush(true)
+	for s.pending() {
+		time.Sleep(logShipRetryDelay)
+		s.flush(true)
+	}
+}
+
+// pending returns whether the logs still have to be shipped
+func (s *logShipper) pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started && !s.failed
+}
+
+// flush appends the buffered logs to the logs of the attempt. When final is
+// set, the logs are marked as complete. A chunk which could not be shipped is
+// shipped again with the next one, until the datastore has failed
+// LogShipMaxFailures times in a row.
+func (s *logShipper) flush(final bool) {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	s.mu.Lock()
+	if !s.started || s.failed || (len(s.buf) == 0 && !final) {
+		s.mu.Unlock()
+		return
+	}
+	chunk := s.buf
+	s.buf = nil
+	if !final {
+		end := bytes.LastIndexByte(chunk, '\n') + 1
+		s.buf = append([]byte{}, chunk[end:]...)
+		chunk = chunk[:end]
+	}
+	s.mu.Unlock()
+	if len(chunk) == 0 && !final {
+		return
+	}
+
+	err := s.datastore.AppendLogs(s.namespace, s.layer, s.run, s.attempt, s.chunk, s.redactor.Redact(chunk), final)
+	s.mu.Lock()
+	if err == nil {
+		s.failures = 0
+		s.chunk++
+		if final {
+			// Nothing may be appended once the logs are marked as final
+			s.started = false
+		}
+		s.mu.Unlock()
+		return
+	}
+	s.failures++
+	failed := s.failures >= LogShipMaxFailures
+	if failed {
+		s.failed = true
+		s.buf = nil
+	} else {
+		s.buf = append(chunk, s.buf...)
+	}
+	s.mu.Unlock()
+	// The logs of the runner are written to the shipper, they are logged once
+	// it is unlocked
+	if failed {
+		log.Warningf("could not ship logs to the datastore, they will be uploaded once the run ends: %s", err)
+	} else {
+		log.Warningf("could not ship logs to the datastore, retrying: %s", err)
+	}
+}
@@ -0,0 +1,139 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	"github.com/padok-team/burrito/internal/utils/redact"
+)
+
+// logsDatastore records the chunks of logs appended to the datastore, and
+// fails the number of times set in failures
+type logsDatastore struct {
+	*datastore.MockClient
+	chunks   []string
+	numbers  []int
+	final    bool
+	calls    int
+	failures int
+}
+
+func (d *logsDatastore) AppendLogs(namespace string, layer string, run string, attempt string, chunk int, content []byte, final bool) error {
+	d.calls++
+	if d.failures > 0 {
+		d.failures--
+		return errors.New("datastore unavailable")
+	}
+	if d.final {
+		return fmt.Errorf("logs of attempt %s have already been marked as final", attempt)
+	}
+	d.chunks = append(d.chunks, string(content))
+	d.numbers = append(d.numbers, chunk)
+	d.final = final
+	return nil
+}
+
+func newTestLogShipper(t *testing.T, store *logsDatastore) *logShipper {
+	t.Helper()
+
+	redactor, err := redact.New([]string{"s3cr3t-value"}, nil)
+	if err != nil {
+		t.Fatalf("failed to create redactor: %s", err)
+	}
+	delay := logShipRetryDelay
+	logShipRetryDelay = time.Millisecond
+	t.Cleanup(func() { logShipRetryDelay = delay })
+	s := &logShipper{}
+	s.start(store, redactor, "default", "layer", "run", "0")
+	return s
+}
+
+func TestLogShipperShipsCompleteLines(t *testing.T) {
+	store := &logsDatastore{MockClient: datastore.NewMockClient()}
+	s := newTestLogShipper(t, store)
+
+	_, _ = s.Write([]byte("first line\nsecond line\npassword: s3cr3t"))
+	s.flush(false)
+	_, _ = s.Write([]byte("-value\n"))
+	s.flush(false)
+	s.flush(false)
+
+	want := []string{"first line\nsecond line\n", "password: " + redact.Mask + "\n"}
+	if strings.Join(store.chunks, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected chunks %q, got %q", want, store.chunks)
+	}
+	if fmt.Sprint(store.numbers) != "[0 1]" {
+		t.Fatalf("expected the chunks to be numbered in order, got %v", store.numbers)
+	}
+	if store.final {
+		t.Fatalf("expected the logs not to be marked as final while the runner runs")
+	}
+}
+
+func TestLogShipperFinalMarker(t *testing.T) {
+	store := &logsDatastore{MockClient: datastore.NewMockClient()}
+	s := newTestLogShipper(t, store)
+
+	_, _ = s.Write([]byte("line\nincomplete line"))
+	s.flush(false)
+	s.close()
+	_, _ = s.Write([]byte("written after the end\n"))
+	s.flush(false)
+	s.close()
+
+	want := []string{"line\n", "incomplete line"}
+	if strings.Join(store.chunks, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected chunks %q, got %q", want, store.chunks)
+	}
+	if !store.final {
+		t.Fatalf("expected the logs to be marked as final")
+	}
+}
+
+func TestLogShipperRetriesOnDatastoreErrors(t *testing.T) {
+	store := &logsDatastore{MockClient: datastore.NewMockClient(), failures: 2}
+	s := newTestLogShipper(t, store)
+
+	_, _ = s.Write([]byte("first line\n"))
+	s.flush(false)
+	_, _ = s.Write([]byte("second line\n"))
+	s.flush(false)
+	s.flush(false)
+
+	if len(store.chunks) != 1 || !strings.HasPrefix(store.chunks[0], "first line\nsecond line\n") {
+		t.Fatalf("expected the chunks which could not be shipped to be shipped again, got %q", store.chunks)
+	}
+	if store.numbers[0] != 0 {
+		t.Fatalf("expected the chunk shipped again to keep its number, got %d", store.numbers[0])
+	}
+
+	store.failures = 1
+	s.close()
+	if !store.final {
+		t.Fatalf("expected the final marker to be shipped again")
+	}
+}
+
+func TestLogShipperGivesUp(t *testing.T) {
+	store := &logsDatastore{MockClient: datastore.NewMockClient(), failures: LogShipMaxFailures}
+	s := newTestLogShipper(t, store)
+
+	_, _ = s.Write([]byte("line\n"))
+	s.close()
+
+	if store.calls != LogShipMaxFailures {
+		t.Fatalf("expected the runner to stop shipping after %d failures, got %d calls", LogShipMaxFailures, store.calls)
+	}
+	if len(store.chunks) != 0 || store.final {
+		t.Fatalf("expected the logs to be left to the controller, got %q", store.chunks)
+	}
+	_, _ = s.Write([]byte("other line\n"))
+	s.flush(false)
+	if store.calls != LogShipMaxFailures {
+		t.Fatalf("expected no logs to be shipped once the runner gave up")
+	}
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/padok-team/burrito/internal/annotations"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	log "github.com/sirupsen/logrus"
+)
+
+// output is an output of the layer, as returned by `terraform output -json`
+type output struct {
+	Sensitive bool            `json:"sensitive"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// Store the outputs of the layer in the datastore, for the layers using them
+// as inputs and for the controller to write them to the Secret or ConfigMap
+// described in the layer spec. Values are never logged nor returned in errors
+// as they may be sensitive.
+func (r *Runner) publishOutputs(ann map[string]string) error {
+	raw, err := r.exec.Output()
+	if err != nil {
+		log.Errorf("error getting %s outputs: %s", r.exec.TenvName(), err)
+		return fmt.Errorf("could not get %s outputs: %w", r.exec.TenvName(), err)
+	}
+	outputs := map[string]output{}
+	err = json.Unmarshal(raw, &outputs)
+	if err != nil {
+		log.Errorf("error parsing %s outputs: %s", r.exec.TenvName(), err)
+		return fmt.Errorf("could not parse %s outputs", r.exec.TenvName())
+	}
+	changed, err := r.storeOutputs(raw)
+	if err != nil {
+		log.Errorf("could not store outputs in datastore: %s", err)
+		return fmt.Errorf("could not store outputs in datastore: %w", err)
+	}
+	if changed {
+		ann[annotations.LastOutputsChange] = fmt.Sprintf("%s/%s", r.Run.Name, r.attemptNumber())
+	}
+	return nil
+}
+
+// storeOutputs stores the outputs in the datastore if they differ from the
+// stored ones, and returns whether they have changed
+func (r *Runner) storeOutputs(raw []byte) (bool, error) {
+	previous, err := r.Datastore.GetOutputs(r.Layer.Namespace, r.Layer.Name)
+	if err != nil && !storageerrors.NotFound(err) {
+		return false, err
+	}
+	if err == nil && bytes.Equal(previous, raw) {
+		log.Infof("outputs have not changed since the last apply")
+		return false, nil
+	}
+	err = r.Datastore.PutOutputs(r.Layer.Namespace, r.Layer.Name, raw)
+	if err != nil {
+		return false, err
+	}
+	log.Infof("stored outputs in datastore")
+	return true, nil
+}
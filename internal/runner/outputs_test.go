@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	"github.com/padok-team/burrito/internal/runner/tools"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// outputExec returns fixed outputs
+type outputExec struct {
+	tools.BaseExec
+	outputs []byte
+}
+
+func (e *outputExec) Output() ([]byte, error) {
+	return e.outputs, nil
+}
+
+func (e *outputExec) TenvName() string {
+	return "terraform"
+}
+
+// outputsDatastore keeps the outputs of a layer in memory
+type outputsDatastore struct {
+	*datastore.MockClient
+	outputs []byte
+}
+
+func (d *outputsDatastore) GetOutputs(namespace string, layer string) ([]byte, error) {
+	return d.outputs, nil
+}
+
+func (d *outputsDatastore) PutOutputs(namespace string, layer string, content []byte) error {
+	d.outputs = content
+	return nil
+}
+
+func TestPublishOutputs(t *testing.T) {
+	store := &outputsDatastore{MockClient: datastore.NewMockClient()}
+	exec := &outputExec{outputs: []byte(`{"endpoint": {"sensitive": false, "value": "db.example.com"}}`)}
+	r := &Runner{
+		exec:      exec,
+		Datastore: store,
+		Layer:     &configv1alpha1.TerraformLayer{ObjectMeta: metav1.ObjectMeta{Name: "database", Namespace: "default"}},
+		Run:       &configv1alpha1.TerraformRun{ObjectMeta: metav1.ObjectMeta{Name: "database-apply-xyz"}},
+	}
+
+	ann := map[string]string{}
+	if err := r.publishOutputs(ann); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ann[annotations.LastOutputsChange] != "database-apply-xyz/0" || string(store.outputs) != string(exec.outputs) {
+		t.Fatalf("expected the outputs to be stored, got %v", ann)
+	}
+
+	ann = map[string]string{}
+	if err := r.publishOutputs(ann); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := ann[annotations.LastOutputsChange]; ok {
+		t.Fatalf("expected unchanged outputs not to be reported as a change")
+	}
+
+	exec.outputs = []byte("not json")
+	if err := r.publishOutputs(map[string]string{}); err == nil {
+		t.Fatalf("expected an error for invalid outputs")
+	}
+}
@@ -3,16 +3,21 @@ package runner
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"github.com/padok-team/burrito/internal/burrito/config"
 	datastore "github.com/padok-team/burrito/internal/datastore/client"
 	"github.com/padok-team/burrito/internal/runner/tools"
 	"github.com/padok-team/burrito/internal/utils"
+	c "github.com/padok-team/burrito/internal/utils/cmd"
+	"github.com/padok-team/burrito/internal/utils/redact"
 	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/types"
@@ -29,33 +34,66 @@ type Runner struct {
 	Repository *configv1alpha1.TerraformRepository
 	repoDir    string
 	workingDir string
+	// Value of the LastPlanInputs annotation matching the inputs of the run
+	inputs string
+	// Whether the run has been cancelled while running
+	cancelled atomic.Bool
+	// Ships the output of the runner to the datastore while it runs
+	logs *logShipper
+	// Masks the sensitive values of the logs and plans stored in the datastore
+	redactor *redact.Redactor
 }
 
 func New(c *config.Config) *Runner {
 	return &Runner{
 		config: c,
+		logs:   &logShipper{},
 	}
 }
 
-// Entrypoint function of the runner. Initializes the runner and executes its action.
+// Entrypoint function of the runner. Initializes the runner and executes its
+// action, then reports the class of the failure if it fails. The output of
+// the runner is shipped to the datastore along the way.
 func (r *Runner) Exec() error {
+	log.SetOutput(io.MultiWriter(os.Stderr, r.logs))
+	c.Output = r.logs
+	err := r.execute()
+	if err != nil && !r.cancelled.Load() {
+		r.reportFailure()
+	}
+	r.logs.close()
+	return err
+}
+
+func (r *Runner) execute() error {
 	err := r.initClients()
 	if err != nil {
 		log.Errorf("error initializing runner clients: %s", err)
 		return err
 	}
 
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go r.watchCancellation(ctx)
+	go r.logs.ship(ctx)
+
 	err = r.Init()
 	if err != nil {
 		log.Errorf("error initializing runner: %s", err)
 		return err
 	}
+	if r.cancelled.Load() {
+		return errCancelled
+	}
 
 	err = r.ExecInit()
 	if err != nil {
 		log.Errorf("error executing init: %s", err)
 		return err
 	}
+	if r.cancelled.Load() {
+		return errCancelled
+	}
 
 	return r.ExecAction()
 }
@@ -76,7 +114,8 @@ func (r *Runner) initClients() error {
 }
 
 // Initialize the runner: retrieve linked resources (layer, run, repository),
-// fetch the repository content, install the binaries and configure Hermitcrab mirror.
+// fetch the repository content, write the variables, install the binaries and
+// configure Hermitcrab mirror.
 func (r *Runner) Init() error {
 	log.Infof("retrieving linked TerraformLayer and TerraformRepository")
 	err := r.GetResources()
@@ -84,6 +123,12 @@ func (r *Runner) Init() error {
 		log.Errorf("error getting kubernetes resources: %s", err)
 		return err
 	}
+	r.redactor, err = redact.ForLayer(context.TODO(), r.Client, r.Repository, r.Layer)
+	if err != nil {
+		log.Errorf("error initializing redaction: %s", err)
+		return err
+	}
+	r.logs.start(r.Datastore, r.redactor, r.Layer.Namespace, r.Layer.Name, r.Run.Name, r.attemptNumber())
 
 	r.repoDir = filepath.Join(r.config.Runner.RepositoryPath, "content")
 	r.workingDir = filepath.Join(r.repoDir, r.Layer.Spec.Path)
@@ -94,6 +139,18 @@ func (r *Runner) Init() error {
 		return err
 	}
 
+	err = r.writeVariables()
+	if err != nil {
+		log.Errorf("error writing variables: %s", err)
+		return err
+	}
+
+	r.inputs, err = r.writeInputs()
+	if err != nil {
+		log.Errorf("error writing inputs: %s", err)
+		return err
+	}
+
 	log.Infof("installing binaries...")
 	r.exec, err = tools.InstallBinaries(r.Layer, r.Repository, r.config.Runner.RunnerBinaryPath, r.workingDir)
 	if err != nil {
@@ -109,6 +166,21 @@ func (r *Runner) Init() error {
 	return nil
 }
 
+// attemptNumber returns the number of the attempt of the run executed by the
+// runner, under which its logs and plans are stored
+func (r *Runner) attemptNumber() string {
+	attempts := r.Run.Status.Attempts
+	for _, attempt := range attempts {
+		if r.config.Runner.Pod != "" && attempt.PodName == r.config.Runner.Pod {
+			return strconv.Itoa(attempt.Number)
+		}
+	}
+	if len(attempts) > 0 {
+		return strconv.Itoa(attempts[len(attempts)-1].Number)
+	}
+	return "0"
+}
+
 // Enable Hermitcrab network mirror configuration.
 func (r *Runner) EnableHermitcrab() error {
 	log.Infof("Hermitcrab configuration detected, creating network mirror configuration...")
@@ -138,6 +138,12 @@ var _ = Describe("Runner Tests", func() {
 				Expect(layer.Annotations).To(HaveKey(annotations.LastPlanSum))
 				Expect(layer.Annotations).To(HaveKey(annotations.LastPlanCommit))
 			})
+			It("should have removed the rejection of the previous plan", func() {
+				layer := &configv1alpha1.TerraformLayer{}
+				err := k8sClient.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "nominal-case-1"}, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(layer.Annotations).NotTo(HaveKey(annotations.RejectPlan))
+			})
 		})
 		Describe("End-to-End - When Runner is launched for running a Terraform apply", Ordered, func() {
 			var conf *config.Config
@@ -166,6 +172,52 @@ var _ = Describe("Runner Tests", func() {
 				Expect(layer.Annotations).To(HaveKey(annotations.LastPlanCommit))
 			})
 		})
+		Describe("End-to-End - When Runner is launched for running a Terraform drift check", Ordered, func() {
+			var conf *config.Config
+			BeforeAll(func() {
+				conf = generateTestConfig()
+				conf.Runner.Action = "drift-check"
+				conf.Runner.Layer.Name = "nominal-case-1"
+				conf.Runner.Layer.Namespace = "default"
+				conf.Runner.Run = "nominal-case-1-drift-check"
+
+				runner := runner.New(conf)
+				err = executeRunner(runner)
+			})
+			AfterAll(func() {
+				cleanup(conf)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should have updated the TerraformLayer annotations", func() {
+				layer := &configv1alpha1.TerraformLayer{}
+				err := k8sClient.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "nominal-case-1"}, layer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(layer.Annotations).To(HaveKey(annotations.LastDriftCheckDate))
+				Expect(layer.Annotations).To(HaveKey(annotations.LastDriftCheckRun))
+				Expect(layer.Annotations).To(HaveKeyWithValue(annotations.LastDriftCheckResult, "false"))
+			})
+		})
+		Describe("End-to-End - When Runner is launched for running a Terraform destroy", Ordered, func() {
+			var conf *config.Config
+			BeforeAll(func() {
+				conf = generateTestConfig()
+				conf.Runner.Action = "destroy"
+				conf.Runner.Layer.Name = "nominal-case-1"
+				conf.Runner.Layer.Namespace = "default"
+				conf.Runner.Run = "nominal-case-1-destroy"
+
+				runner := runner.New(conf)
+				err = executeRunner(runner)
+			})
+			AfterAll(func() {
+				cleanup(conf)
+			})
+			It("should not return an error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
 		Describe("End-to-End - When Runner is launched for running a Terragrunt plan", Ordered, func() {
 			var conf *config.Config
 			BeforeAll(func() {
@@ -0,0 +1,232 @@
+package runner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/runner/tools"
+	"github.com/padok-team/burrito/internal/runner/tools/terragrunt"
+	runnerutils "github.com/padok-team/burrito/internal/utils/runner"
+	log "github.com/sirupsen/logrus"
+)
+
+const StackPlanDir string = "/tmp/stack-plans"
+
+// isStack returns whether the layer is run as a terragrunt stack
+func (r *Runner) isStack() bool {
+	return configv1alpha1.GetTerragruntStackEnabled(r.Repository, r.Layer)
+}
+
+func (r *Runner) getStackExec() (tools.StackExec, error) {
+	stack, ok := r.exec.(tools.StackExec)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support stacks", r.exec.TenvName())
+	}
+	return stack, nil
+}
+
+// Run the `run-all plan` command and save the plan artifact of each module in
+// the datastore, along with a short diff and a pretty plan aggregated over
+// all the modules
+// Returns the sha256 sum of the plan artifacts and how their destructive
+// changes must be handled
+func (r *Runner) execStackPlan() (string, string, error) {
+	log.Infof("running %s stack plan", r.exec.TenvName())
+	stack, err := r.getStackExec()
+	if err != nil {
+		return "", "", err
+	}
+	if r.isTargeted() {
+		return "", "", errors.New("targeted runs are not supported for terragrunt stacks")
+	}
+	err = os.RemoveAll(StackPlanDir)
+	if err != nil {
+		return "", "", err
+	}
+	err = stack.PlanStack(StackPlanDir)
+	if err != nil {
+		log.Errorf("error executing %s stack plan: %s", r.exec.TenvName(), err)
+		return "", "", err
+	}
+	modules, err := listStackModules(StackPlanDir)
+	if err != nil {
+		log.Errorf("could not list the plans of the stack modules: %s", err)
+		return "", "", err
+	}
+	attempt := r.attemptNumber()
+	destructiveChanges := runnerutils.DestructiveChangesNone
+	plans := []*tfjson.Plan{}
+	prettyPlans := bytes.NewBufferString("")
+	hash := sha256.New()
+	for _, module := range modules {
+		planArtifact := filepath.Join(StackPlanDir, module, terragrunt.StackPlanFile)
+		planJsonBytes, err := stack.ShowModule(module, planArtifact, "json")
+		if err != nil {
+			log.Errorf("error getting %s plan json of module %s: %s", r.exec.TenvName(), module, err)
+			return "", "", err
+		}
+		prettyPlan, err := stack.ShowModule(module, planArtifact, "pretty")
+		if err != nil {
+			log.Errorf("error getting %s pretty plan of module %s: %s", r.exec.TenvName(), module, err)
+			return "", "", err
+		}
+		plan := &tfjson.Plan{}
+		err = json.Unmarshal(planJsonBytes, plan)
+		if err != nil {
+			log.Errorf("error parsing %s json plan of module %s: %s", r.exec.TenvName(), module, err)
+			return "", "", err
+		}
+		plans = append(plans, plan)
+		destructiveChanges = runnerutils.MergeDestructiveChanges(destructiveChanges, r.checkDestructiveChanges(plan))
+		_, shortDiff := runnerutils.GetDiff(plan)
+		fmt.Fprintf(prettyPlans, "# Module %s: %s\n\n%s\n", module, shortDiff, prettyPlan)
+		planBin, err := os.ReadFile(planArtifact)
+		if err != nil {
+			log.Errorf("could not read plan output of module %s: %s", module, err)
+			return "", "", err
+		}
+		hash.Write(planBin)
+		log.Infof("sending plan of module %s to datastore", module)
+		summary, err := json.Marshal(runnerutils.GetSummary(plan))
+		if err != nil {
+			return "", "", err
+		}
+		displayPlanJson, err := r.redactJsonPlan(planJsonBytes)
+		if err != nil {
+			log.Errorf("could not strip sensitive values from json plan of module %s: %s", module, err)
+			return "", "", err
+		}
+		for format, content := range map[string][]byte{"json": displayPlanJson, "pretty": r.redactor.Redact(prettyPlan), "short": r.redactor.Redact([]byte(shortDiff)), "summary": summary} {
+			err = r.Datastore.PutModulePlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, attempt, module, format, content)
+			if err != nil {
+				log.Errorf("could not put %s plan of module %s in datastore: %s", format, module, err)
+			}
+		}
+		err = r.Datastore.PutModulePlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, attempt, module, "bin", planBin)
+		if err != nil {
+			log.Errorf("could not put plan binary of module %s in cache: %s", module, err)
+			return "", "", err
+		}
+	}
+	_, shortDiff := runnerutils.GetStackDiff(plans)
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, attempt, "pretty", r.redactor.Redact(prettyPlans.Bytes()))
+	if err != nil {
+		log.Errorf("could not put pretty plan in datastore: %s", err)
+	}
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, attempt, "short", r.redactor.Redact([]byte(shortDiff)))
+	if err != nil {
+		log.Errorf("could not put short plan in datastore: %s", err)
+	}
+	summary, err := json.Marshal(runnerutils.GetStackSummary(modules, plans))
+	if err != nil {
+		return "", "", err
+	}
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, attempt, "summary", summary)
+	if err != nil {
+		log.Errorf("could not put plan summary in datastore: %s", err)
+	}
+	modulesIndex, err := json.Marshal(modules)
+	if err != nil {
+		return "", "", err
+	}
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, attempt, "modules", modulesIndex)
+	if err != nil {
+		log.Errorf("could not put the list of stack modules in cache: %s", err)
+		return "", "", err
+	}
+	log.Infof("%s stack plan ran successfully on %d module(s)", r.exec.TenvName(), len(modules))
+	return b64.StdEncoding.EncodeToString(hash.Sum(nil)), destructiveChanges, nil
+}
+
+// Run the `run-all apply` command with the plan artifacts of the modules from
+// the previous plan run, the modules are applied in dependency order
+// Returns the sha256 sum of the plan artifacts used
+func (r *Runner) execStackApply() (string, error) {
+	log.Infof("starting %s stack apply", r.exec.TenvName())
+	stack, err := r.getStackExec()
+	if err != nil {
+		return "", err
+	}
+	log.Infof("getting stack plan binaries in datastore at key %s/%s/%s/%s", r.Layer.Namespace, r.Layer.Name, r.Run.Spec.Artifact.Run, r.Run.Spec.Artifact.Attempt)
+	modulesIndex, err := r.Datastore.GetPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Spec.Artifact.Run, r.Run.Spec.Artifact.Attempt, "modules")
+	if err != nil {
+		log.Errorf("could not get the list of stack modules: %s", err)
+		return "", err
+	}
+	modules := []string{}
+	err = json.Unmarshal(modulesIndex, &modules)
+	if err != nil {
+		log.Errorf("could not parse the list of stack modules: %s", err)
+		return "", err
+	}
+	err = os.RemoveAll(StackPlanDir)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, module := range modules {
+		plan, err := r.Datastore.GetModulePlan(r.Layer.Namespace, r.Layer.Name, r.Run.Spec.Artifact.Run, r.Run.Spec.Artifact.Attempt, module, "bin")
+		if err != nil {
+			log.Errorf("could not get plan artifact of module %s: %s", module, err)
+			return "", err
+		}
+		hash.Write(plan)
+		planArtifact := filepath.Join(StackPlanDir, module, terragrunt.StackPlanFile)
+		err = os.MkdirAll(filepath.Dir(planArtifact), 0755)
+		if err != nil {
+			return "", err
+		}
+		err = os.WriteFile(planArtifact, plan, 0644)
+		if err != nil {
+			log.Errorf("could not write plan artifact of module %s to disk: %s", module, err)
+			return "", err
+		}
+	}
+	log.Infof("launching %s stack apply on %d module(s)", r.exec.TenvName(), len(modules))
+	if configv1alpha1.GetApplyWithoutPlanArtifactEnabled(r.Repository, r.Layer) {
+		log.Infof("applying without reusing plan artifacts from previous plan run")
+		err = stack.ApplyStack("")
+	} else {
+		err = stack.ApplyStack(StackPlanDir)
+	}
+	if err != nil {
+		log.Errorf("error executing %s stack apply: %s", r.exec.TenvName(), err)
+		return "", err
+	}
+	err = r.Datastore.PutPlan(r.Layer.Namespace, r.Layer.Name, r.Run.Name, r.attemptNumber(), "short", []byte(fmt.Sprintf("Apply Successful in %d module(s)", len(modules))))
+	if err != nil {
+		log.Errorf("could not put short plan in datastore: %s", err)
+	}
+	log.Infof("%s stack apply ran successfully", r.exec.TenvName())
+	return b64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
+// listStackModules returns the sorted paths of the modules which have a plan
+// artifact in the given directory, relative to this directory
+func listStackModules(dir string) ([]string, error) {
+	modules := []string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != terragrunt.StackPlanFile {
+			return nil
+		}
+		module, err := filepath.Rel(dir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		modules = append(modules, filepath.ToSlash(module))
+		return nil
+	})
+	return modules, err
+}
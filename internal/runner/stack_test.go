@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/padok-team/burrito/internal/runner/tools/terragrunt"
+)
+
+func TestListStackModules(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{
+		"app/" + terragrunt.StackPlanFile,
+		"network/" + terragrunt.StackPlanFile,
+		"network/vpc/" + terragrunt.StackPlanFile,
+		"network/.terragrunt-cache/plan.json",
+		"README.md",
+	} {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	modules, err := listStackModules(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := []string{"app", "network", "network/vpc"}
+	if !reflect.DeepEqual(modules, want) {
+		t.Fatalf("expected %v, got %v", want, modules)
+	}
+}
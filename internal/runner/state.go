@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Run the state maintenance action of the run with its arguments. The state
+// is changed outside of any plan, so the action is refused if the arguments
+// do not match the action.
+func (r *Runner) execStateAction() error {
+	action := r.config.Runner.Action
+	args := r.Run.Spec.Args
+	if r.isStack() {
+		return errors.New("state maintenance actions are not supported for terragrunt stacks")
+	}
+	for _, arg := range args {
+		if arg == "" || strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("invalid argument %q for %s action", arg, action)
+		}
+	}
+	log.Infof("running %s %s with arguments %v", r.exec.TenvName(), action, args)
+	var err error
+	switch {
+	case action == "import" && len(args) == 2:
+		err = r.exec.Import(args[0], args[1])
+	case action == "state-mv" && len(args) == 2:
+		err = r.exec.StateMove(args[0], args[1])
+	case action == "state-rm" && len(args) > 0:
+		err = r.exec.StateRemove(args)
+	case action == "force-unlock" && len(args) == 1:
+		err = r.exec.ForceUnlock(args[0])
+	default:
+		return fmt.Errorf("invalid number of arguments for %s action: %d", action, len(args))
+	}
+	if err != nil {
+		log.Errorf("error executing %s %s: %s", r.exec.TenvName(), action, err)
+		return err
+	}
+	log.Infof("%s %s ran successfully", r.exec.TenvName(), action)
+	return nil
+}
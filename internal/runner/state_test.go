@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/burrito/config"
+	"github.com/padok-team/burrito/internal/runner/tools"
+)
+
+// stateExec records the state commands it is asked to run
+type stateExec struct {
+	tools.BaseExec
+	calls [][]string
+}
+
+func (e *stateExec) Import(address, id string) error {
+	e.calls = append(e.calls, []string{"import", address, id})
+	return nil
+}
+
+func (e *stateExec) StateMove(source, destination string) error {
+	e.calls = append(e.calls, []string{"state-mv", source, destination})
+	return nil
+}
+
+func (e *stateExec) StateRemove(addresses []string) error {
+	e.calls = append(e.calls, append([]string{"state-rm"}, addresses...))
+	return nil
+}
+
+func (e *stateExec) ForceUnlock(id string) error {
+	e.calls = append(e.calls, []string{"force-unlock", id})
+	return nil
+}
+
+func (e *stateExec) TenvName() string {
+	return "terraform"
+}
+
+func TestExecStateAction(t *testing.T) {
+	tests := []struct {
+		action string
+		args   []string
+		want   [][]string
+		err    bool
+	}{
+		{"import", []string{"aws_s3_bucket.logs", "logs-bucket"}, [][]string{{"import", "aws_s3_bucket.logs", "logs-bucket"}}, false},
+		{"state-mv", []string{"random_pet.a", "random_pet.b"}, [][]string{{"state-mv", "random_pet.a", "random_pet.b"}}, false},
+		{"state-rm", []string{"random_pet.a", "random_pet.b"}, [][]string{{"state-rm", "random_pet.a", "random_pet.b"}}, false},
+		{"force-unlock", []string{"1234"}, [][]string{{"force-unlock", "1234"}}, false},
+		{"import", []string{"aws_s3_bucket.logs"}, nil, true},
+		{"state-rm", []string{}, nil, true},
+		{"force-unlock", []string{"1234", "5678"}, nil, true},
+		{"state-rm", []string{"-lock=false", "random_pet.a"}, nil, true},
+		{"state-mv", []string{"random_pet.a", ""}, nil, true},
+	}
+	for _, tt := range tests {
+		exec := &stateExec{}
+		conf := config.TestConfig()
+		conf.Runner.Action = tt.action
+		r := &Runner{
+			config:     conf,
+			exec:       exec,
+			Layer:      &configv1alpha1.TerraformLayer{},
+			Repository: &configv1alpha1.TerraformRepository{},
+			Run:        &configv1alpha1.TerraformRun{Spec: configv1alpha1.TerraformRunSpec{Action: tt.action, Args: tt.args}},
+		}
+		err := r.execStateAction()
+		if tt.err != (err != nil) {
+			t.Errorf("%s %v: unexpected error: %v", tt.action, tt.args, err)
+		}
+		if !reflect.DeepEqual(exec.calls, tt.want) {
+			t.Errorf("%s %v: expected calls %v, got %v", tt.action, tt.args, tt.want, exec.calls)
+		}
+	}
+}
@@ -23,32 +23,89 @@ func (t *BaseTool) Init(workingDir string) error {
 	cmd := exec.Command(t.ExecPath, "init", "-no-color", "-upgrade")
 	c.Verbose(cmd)
 	cmd.Dir = workingDir
-	if err := cmd.Run(); err != nil {
+	if err := c.Run(cmd); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *BaseTool) Plan(planArtifactPath string) error {
-	cmd := exec.Command(t.ExecPath, "plan", "-no-color", "-out", planArtifactPath)
+// SelectWorkspace selects the given workspace, creating it if it does not
+// exist yet. `workspace select -or-create` is not used as older versions of
+// Terraform do not support it.
+func (t *BaseTool) SelectWorkspace(workspace string) error {
+	cmd := exec.Command(t.ExecPath, "workspace", "select", workspace)
 	c.Verbose(cmd)
 	cmd.Dir = t.WorkingDir
-	if err := cmd.Run(); err != nil {
+	if err := c.Run(cmd); err == nil {
+		return nil
+	}
+	cmd = exec.Command(t.ExecPath, "workspace", "new", workspace)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TargetArgs returns the -target and -replace flags restricting a plan or an
+// apply to the given resource addresses
+func TargetArgs(targets []string, replace []string) []string {
+	args := []string{}
+	for _, target := range targets {
+		args = append(args, "-target="+target)
+	}
+	for _, address := range replace {
+		args = append(args, "-replace="+address)
+	}
+	return args
+}
+
+func (t *BaseTool) Plan(planArtifactPath string, targets []string, replace []string) error {
+	args := append([]string{"plan", "-no-color", "-out", planArtifactPath}, TargetArgs(targets, replace)...)
+	cmd := exec.Command(t.ExecPath, args...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *BaseTool) Apply(planArtifactPath string) error {
+func (t *BaseTool) PlanDestroy(planArtifactPath string) error {
+	cmd := exec.Command(t.ExecPath, "plan", "-no-color", "-destroy", "-out", planArtifactPath)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *BaseTool) PlanRefreshOnly(planArtifactPath string) error {
+	cmd := exec.Command(t.ExecPath, "plan", "-no-color", "-refresh-only", "-out", planArtifactPath)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Apply applies the given plan artifact, or plans and applies the layer if
+// there is none. Targets and replaced resources are only used in the latter
+// case, as a saved plan already accounts for them.
+func (t *BaseTool) Apply(planArtifactPath string, targets []string, replace []string) error {
 	var cmd *exec.Cmd
 	if planArtifactPath != "" {
 		cmd = exec.Command(t.ExecPath, "apply", "-no-color", "-auto-approve", planArtifactPath)
 	} else {
-		cmd = exec.Command(t.ExecPath, "apply", "-no-color", "-auto-approve")
+		args := append([]string{"apply", "-no-color", "-auto-approve"}, TargetArgs(targets, replace)...)
+		cmd = exec.Command(t.ExecPath, args...)
 	}
 	c.Verbose(cmd)
 	cmd.Dir = t.WorkingDir
-	if err := cmd.Run(); err != nil {
+	if err := c.Run(cmd); err != nil {
 		return err
 	}
 	return nil
@@ -73,6 +130,50 @@ func (t *BaseTool) Show(planArtifactPath, mode string) ([]byte, error) {
 	return out, nil
 }
 
+// Output returns the outputs of the layer in the JSON format of
+// `terraform output -json`
+func (t *BaseTool) Output() ([]byte, error) {
+	cmd := exec.Command(t.ExecPath, "output", "-no-color", "-json")
+	cmd.Dir = t.WorkingDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Import imports the existing infrastructure object with the given ID in the
+// state, at the given resource address
+func (t *BaseTool) Import(address string, id string) error {
+	return t.run("import", "-no-color", "-input=false", address, id)
+}
+
+// StateMove moves a resource of the state to another address
+func (t *BaseTool) StateMove(source string, destination string) error {
+	return t.run("state", "mv", source, destination)
+}
+
+// StateRemove removes the given resources from the state, without destroying
+// them
+func (t *BaseTool) StateRemove(addresses []string) error {
+	return t.run(append([]string{"state", "rm"}, addresses...)...)
+}
+
+// ForceUnlock releases the lock of the state with the given ID
+func (t *BaseTool) ForceUnlock(lockID string) error {
+	return t.run("force-unlock", "-force", lockID)
+}
+
+func (t *BaseTool) run(args ...string) error {
+	cmd := exec.Command(t.ExecPath, args...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (t *BaseTool) GetExecPath() string {
 	return t.ExecPath
 }
@@ -2,9 +2,25 @@ package tools
 
 type BaseExec interface {
 	Init(string) error
-	Plan(string) error
-	Apply(string) error
+	SelectWorkspace(string) error
+	Plan(string, []string, []string) error
+	PlanDestroy(string) error
+	PlanRefreshOnly(string) error
+	Apply(string, []string, []string) error
 	Show(string, string) ([]byte, error)
+	Output() ([]byte, error)
+	Import(string, string) error
+	StateMove(string, string) error
+	StateRemove([]string) error
+	ForceUnlock(string) error
 	TenvName() string
 	GetExecPath() string
 }
+
+// StackExec is implemented by the tools able to run a stack of modules, with
+// one plan artifact per module
+type StackExec interface {
+	PlanStack(string) error
+	ApplyStack(string) error
+	ShowModule(string, string, string) ([]byte, error)
+}
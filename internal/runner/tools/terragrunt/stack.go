@@ -0,0 +1,76 @@
+package terragrunt
+
+import (
+	"os/exec"
+	"path/filepath"
+
+	"github.com/blang/semver/v4"
+	c "github.com/padok-team/burrito/internal/utils/cmd"
+)
+
+// StackPlanFile is the name of the plan artifact terragrunt writes for each
+// module of a stack in the output directory
+const StackPlanFile string = "tfplan.tfplan"
+
+// getStackOptions returns the options of a `run-all` command. The plan of
+// each module is read from or written to outDir, under the relative path of
+// the module. No plan is used when outDir is empty.
+func (t *Terragrunt) getStackOptions(command string, outDir string) ([]string, error) {
+	options, err := t.getDefaultOptions(command)
+	if err != nil {
+		return nil, err
+	}
+	options = append([]string{"run-all"}, options...)
+	nonInteractiveFlag, outDirFlag := "--terragrunt-non-interactive", "--terragrunt-out-dir"
+	version, err := semver.Parse(t.Version)
+	if err == nil && version.GTE(semver.MustParse("0.73.0")) {
+		nonInteractiveFlag, outDirFlag = "--non-interactive", "--out-dir"
+	}
+	options = append(options, nonInteractiveFlag)
+	if outDir != "" {
+		options = append(options, outDirFlag, outDir)
+	}
+	return options, nil
+}
+
+// PlanStack runs `run-all plan` on every module of the stack and writes one
+// plan artifact per module in outDir
+func (t *Terragrunt) PlanStack(outDir string) error {
+	options, err := t.getStackOptions("plan", outDir)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(t.ExecPath, options...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ApplyStack runs `run-all apply` with the plan artifacts of outDir, or
+// without plan artifacts if outDir is empty. Terragrunt applies the modules
+// in dependency order.
+func (t *Terragrunt) ApplyStack(outDir string) error {
+	options, err := t.getStackOptions("apply", outDir)
+	if err != nil {
+		return err
+	}
+	options = append(options, "-auto-approve")
+	cmd := exec.Command(t.ExecPath, options...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ShowModule shows the plan artifact of a module of the stack, module being
+// the path of the module relative to the working directory
+func (t *Terragrunt) ShowModule(module string, planArtifactPath string, mode string) ([]byte, error) {
+	moduleExec := *t
+	moduleExec.WorkingDir = filepath.Join(t.WorkingDir, module)
+	return moduleExec.Show(planArtifactPath, mode)
+}
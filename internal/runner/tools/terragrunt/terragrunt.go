@@ -5,6 +5,7 @@ import (
 	"os/exec"
 
 	"github.com/blang/semver/v4"
+	"github.com/padok-team/burrito/internal/runner/tools/base"
 	c "github.com/padok-team/burrito/internal/utils/cmd"
 )
 
@@ -20,6 +21,13 @@ func (t *Terragrunt) TenvName() string {
 }
 
 func (t *Terragrunt) getDefaultOptions(command string) ([]string, error) {
+	options := append([]string{command}, t.getPathOptions()...)
+	return append(options, "-no-color"), nil
+}
+
+// getPathOptions returns the flags setting the path of the wrapped binary and
+// the working directory
+func (t *Terragrunt) getPathOptions() []string {
 	// Parse the version to determine which flags to use
 	// Terragrunt 0.73.0 introduced new shortened flags:
 	// - --terragrunt-tfpath -> --tf-path
@@ -28,13 +36,11 @@ func (t *Terragrunt) getDefaultOptions(command string) ([]string, error) {
 	if err != nil {
 		// If version parsing fails, use legacy flags as fallback
 		return []string{
-			command,
 			"--terragrunt-tfpath",
 			t.ChildExecPath,
 			"--terragrunt-working-dir",
 			t.WorkingDir,
-			"-no-color",
-		}, nil
+		}
 	}
 
 	newFlagsVersion := semver.MustParse("0.73.0")
@@ -42,23 +48,19 @@ func (t *Terragrunt) getDefaultOptions(command string) ([]string, error) {
 	if version.GTE(newFlagsVersion) {
 		// Use new flags for version 0.73.0 and above
 		return []string{
-			command,
 			"--tf-path",
 			t.ChildExecPath,
 			"--working-dir",
 			t.WorkingDir,
-			"-no-color",
-		}, nil
+		}
 	} else {
 		// Use legacy flags for versions below 0.73.0
 		return []string{
-			command,
 			"--terragrunt-tfpath",
 			t.ChildExecPath,
 			"--terragrunt-working-dir",
 			t.WorkingDir,
-			"-no-color",
-		}, nil
+		}
 	}
 }
 
@@ -71,28 +73,78 @@ func (t *Terragrunt) Init(workingDir string) error {
 	cmd := exec.Command(t.ExecPath, options...)
 	c.Verbose(cmd)
 	cmd.Dir = t.WorkingDir
-	if err := cmd.Run(); err != nil {
+	if err := c.Run(cmd); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SelectWorkspace selects the given workspace, creating it if it does not
+// exist yet
+func (t *Terragrunt) SelectWorkspace(workspace string) error {
+	options := append([]string{"workspace"}, t.getPathOptions()...)
+	cmd := exec.Command(t.ExecPath, append(options, "select", workspace)...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err == nil {
+		return nil
+	}
+	cmd = exec.Command(t.ExecPath, append(options, "new", workspace)...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *Terragrunt) Plan(planArtifactPath string) error {
+func (t *Terragrunt) Plan(planArtifactPath string, targets []string, replace []string) error {
 	options, err := t.getDefaultOptions("plan")
 	if err != nil {
 		return err
 	}
 	options = append(options, "-out", planArtifactPath)
+	options = append(options, base.TargetArgs(targets, replace)...)
+	cmd := exec.Command(t.ExecPath, options...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *Terragrunt) PlanDestroy(planArtifactPath string) error {
+	options, err := t.getDefaultOptions("plan")
+	if err != nil {
+		return err
+	}
+	options = append(options, "-destroy", "-out", planArtifactPath)
+	cmd := exec.Command(t.ExecPath, options...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *Terragrunt) PlanRefreshOnly(planArtifactPath string) error {
+	options, err := t.getDefaultOptions("plan")
+	if err != nil {
+		return err
+	}
+	options = append(options, "-refresh-only", "-out", planArtifactPath)
 	cmd := exec.Command(t.ExecPath, options...)
 	c.Verbose(cmd)
 	cmd.Dir = t.WorkingDir
-	if err := cmd.Run(); err != nil {
+	if err := c.Run(cmd); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *Terragrunt) Apply(planArtifactPath string) error {
+func (t *Terragrunt) Apply(planArtifactPath string, targets []string, replace []string) error {
 	options, err := t.getDefaultOptions("apply")
 	if err != nil {
 		return err
@@ -100,12 +152,14 @@ func (t *Terragrunt) Apply(planArtifactPath string) error {
 	options = append(options, "-auto-approve")
 	if planArtifactPath != "" {
 		options = append(options, planArtifactPath)
+	} else {
+		options = append(options, base.TargetArgs(targets, replace)...)
 	}
 
 	cmd := exec.Command(t.ExecPath, options...)
 	c.Verbose(cmd)
 	cmd.Dir = t.WorkingDir
-	if err := cmd.Run(); err != nil {
+	if err := c.Run(cmd); err != nil {
 		return err
 	}
 	return nil
@@ -134,6 +188,67 @@ func (t *Terragrunt) Show(planArtifactPath, mode string) ([]byte, error) {
 	return output, nil
 }
 
+// Output returns the outputs of the layer in the JSON format of
+// `terraform output -json`
+func (t *Terragrunt) Output() ([]byte, error) {
+	options, err := t.getDefaultOptions("output")
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, "-json")
+	cmd := exec.Command(t.ExecPath, options...)
+	cmd.Dir = t.WorkingDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// Import imports the existing infrastructure object with the given ID in the
+// state, at the given resource address
+func (t *Terragrunt) Import(address string, id string) error {
+	options, err := t.getDefaultOptions("import")
+	if err != nil {
+		return err
+	}
+	return t.run(append(options, "-input=false", address, id))
+}
+
+// StateMove moves a resource of the state to another address
+func (t *Terragrunt) StateMove(source string, destination string) error {
+	return t.run(append(t.getStateOptions("mv"), source, destination))
+}
+
+// StateRemove removes the given resources from the state, without destroying
+// them
+func (t *Terragrunt) StateRemove(addresses []string) error {
+	return t.run(append(t.getStateOptions("rm"), addresses...))
+}
+
+// ForceUnlock releases the lock of the state with the given ID
+func (t *Terragrunt) ForceUnlock(lockID string) error {
+	options := append([]string{"force-unlock"}, t.getPathOptions()...)
+	return t.run(append(options, "-force", lockID))
+}
+
+// getStateOptions returns the options of a `state` subcommand, which does not
+// support the -no-color flag
+func (t *Terragrunt) getStateOptions(subcommand string) []string {
+	options := append([]string{"state"}, t.getPathOptions()...)
+	return append(options, subcommand)
+}
+
+func (t *Terragrunt) run(options []string) error {
+	cmd := exec.Command(t.ExecPath, options...)
+	c.Verbose(cmd)
+	cmd.Dir = t.WorkingDir
+	if err := c.Run(cmd); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (t *Terragrunt) GetExecPath() string {
 	return t.ExecPath
 }
@@ -187,6 +187,87 @@ func TestTerragrunt_getDefaultOptions(t *testing.T) {
 	}
 }
 
+func TestTerragrunt_getStackOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       string
+		command       string
+		expectedFlags []string
+	}{
+		{
+			name:    "Legacy flags for version 0.72.9",
+			version: "0.72.9",
+			command: "plan",
+			expectedFlags: []string{
+				"run-all",
+				"plan",
+				"--terragrunt-tfpath",
+				"/path/to/terraform",
+				"--terragrunt-working-dir",
+				"/path/to/working/dir",
+				"-no-color",
+				"--terragrunt-non-interactive",
+				"--terragrunt-out-dir",
+				"/path/to/out/dir",
+			},
+		},
+		{
+			name:    "New flags for version 0.73.0",
+			version: "0.73.0",
+			command: "apply",
+			expectedFlags: []string{
+				"run-all",
+				"apply",
+				"--tf-path",
+				"/path/to/terraform",
+				"--working-dir",
+				"/path/to/working/dir",
+				"-no-color",
+				"--non-interactive",
+				"--out-dir",
+				"/path/to/out/dir",
+			},
+		},
+		{
+			name:    "Invalid version fallback to legacy flags",
+			version: "invalid-version",
+			command: "plan",
+			expectedFlags: []string{
+				"run-all",
+				"plan",
+				"--terragrunt-tfpath",
+				"/path/to/terraform",
+				"--terragrunt-working-dir",
+				"/path/to/working/dir",
+				"-no-color",
+				"--terragrunt-non-interactive",
+				"--terragrunt-out-dir",
+				"/path/to/out/dir",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tg := &Terragrunt{
+				ExecPath:      "/path/to/terragrunt",
+				WorkingDir:    "/path/to/working/dir",
+				ChildExecPath: "/path/to/terraform",
+				Version:       tt.version,
+			}
+
+			options, err := tg.getStackOptions(tt.command, "/path/to/out/dir")
+			if err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+
+			if !reflect.DeepEqual(options, tt.expectedFlags) {
+				t.Errorf("Expected flags %v, but got %v", tt.expectedFlags, options)
+			}
+		})
+	}
+}
+
 func TestTerragrunt_TenvName(t *testing.T) {
 	tg := &Terragrunt{}
 	expected := "terragrunt"
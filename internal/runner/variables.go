@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"github.com/zclconf/go-cty/cty"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// The var files are copied before the variables file so that the
+	// variables take precedence, *.auto.tfvars files being loaded in
+	// lexical order
+	VarFilePrefix string = "burrito-varfile-"
+	VariablesFile string = "burrito-variables.auto.tfvars"
+)
+
+// variable is an input variable whose value has been resolved
+type variable struct {
+	name  string
+	value string
+	hcl   bool
+}
+
+// Write the var files and the variables of the layer in the working directory
+// as *.auto.tfvars files. Values are never logged as they may be sensitive.
+func (r *Runner) writeVariables() error {
+	for i, varFile := range configv1alpha1.GetVarFiles(r.Repository, r.Layer) {
+		content, err := r.getVarFileContent(varFile)
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%s%02d.auto.tfvars", VarFilePrefix, i)
+		err = os.WriteFile(filepath.Join(r.workingDir, name), content, 0600)
+		if err != nil {
+			return fmt.Errorf("could not write var file %s: %w", name, err)
+		}
+		log.Infof("wrote var file %s", name)
+	}
+	variables := []variable{}
+	for _, v := range configv1alpha1.GetVariables(r.Repository, r.Layer) {
+		value, err := r.getVariableValue(v)
+		if err != nil {
+			return err
+		}
+		variables = append(variables, variable{name: v.Name, value: value, hcl: v.HCL})
+	}
+	if len(variables) == 0 {
+		return nil
+	}
+	content, err := renderVariables(variables)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(r.workingDir, VariablesFile), content, 0600)
+	if err != nil {
+		return fmt.Errorf("could not write variables file: %w", err)
+	}
+	log.Infof("wrote %d variable(s) to %s", len(variables), VariablesFile)
+	return nil
+}
+
+func (r *Runner) getVarFileContent(varFile configv1alpha1.TerraformVarFile) ([]byte, error) {
+	if varFile.ConfigMapKeyRef != nil {
+		return r.getConfigMapValue(varFile.ConfigMapKeyRef)
+	}
+	path := filepath.Join(r.workingDir, varFile.Path)
+	if !strings.HasPrefix(path, filepath.Clean(r.repoDir)+string(os.PathSeparator)) {
+		return nil, fmt.Errorf("var file %s is outside of the repository", varFile.Path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read var file %s: %w", varFile.Path, err)
+	}
+	return content, nil
+}
+
+func (r *Runner) getVariableValue(v configv1alpha1.TerraformVariable) (string, error) {
+	if v.ValueFrom == nil {
+		return v.Value, nil
+	}
+	var value []byte
+	var err error
+	switch {
+	case v.ValueFrom.ConfigMapKeyRef != nil:
+		value, err = r.getConfigMapValue(v.ValueFrom.ConfigMapKeyRef)
+	case v.ValueFrom.SecretKeyRef != nil:
+		value, err = r.getSecretValue(v.ValueFrom.SecretKeyRef)
+	default:
+		err = errors.New("no source set in valueFrom")
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not get value of variable %s: %w", v.Name, err)
+	}
+	return string(value), nil
+}
+
+func (r *Runner) getConfigMapValue(selector *corev1.ConfigMapKeySelector) ([]byte, error) {
+	configMap := &corev1.ConfigMap{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Layer.Namespace, Name: selector.Name}, configMap)
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := configMap.Data[selector.Key]; ok {
+		return []byte(value), nil
+	}
+	if value, ok := configMap.BinaryData[selector.Key]; ok {
+		return value, nil
+	}
+	return nil, fmt.Errorf("key %s not found in ConfigMap %s", selector.Key, selector.Name)
+}
+
+func (r *Runner) getSecretValue(selector *corev1.SecretKeySelector) ([]byte, error) {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Layer.Namespace, Name: selector.Name}, secret)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found in Secret %s", selector.Key, selector.Name)
+	}
+	return value, nil
+}
+
+// renderVariables renders the variables as a tfvars file. String values are
+// escaped, HCL values are checked to be valid expressions. Errors never
+// contain the values.
+func renderVariables(variables []variable) ([]byte, error) {
+	var content strings.Builder
+	for _, v := range variables {
+		if !hclsyntax.ValidIdentifier(v.name) {
+			return nil, fmt.Errorf("variable name %s is not a valid identifier", v.name)
+		}
+		if !v.hcl {
+			fmt.Fprintf(&content, "%s = %s\n", v.name, hclwrite.TokensForValue(cty.StringVal(v.value)).Bytes())
+			continue
+		}
+		_, diags := hclsyntax.ParseExpression([]byte(v.value), v.name, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("value of variable %s is not a valid HCL expression", v.name)
+		}
+		fmt.Fprintf(&content, "%s = %s\n", v.name, strings.TrimSpace(v.value))
+	}
+	return []byte(content.String()), nil
+}
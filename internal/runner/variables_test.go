@@ -0,0 +1,40 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderVariables(t *testing.T) {
+	content, err := renderVariables([]variable{
+		{name: "region", value: "eu-west-3"},
+		{name: "password", value: "p@ss\"word${var.x}"},
+		{name: "instance_count", value: "3", hcl: true},
+		{name: "tags", value: `{ team = "platform" }`, hcl: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := `region = "eu-west-3"
+password = "p@ss\"word$${var.x}"
+instance_count = 3
+tags = { team = "platform" }
+`
+	if string(content) != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, content)
+	}
+}
+
+func TestRenderVariablesErrorsDoNotLeakValues(t *testing.T) {
+	_, err := renderVariables([]variable{{name: "token", value: "{ secret-value", hcl: true}})
+	if err == nil {
+		t.Fatalf("expected an error for an invalid HCL expression")
+	}
+	if strings.Contains(err.Error(), "secret-value") {
+		t.Fatalf("expected the error not to contain the value, got %s", err)
+	}
+	_, err = renderVariables([]variable{{name: "not valid", value: "value"}})
+	if err == nil {
+		t.Fatalf("expected an error for an invalid variable name")
+	}
+}
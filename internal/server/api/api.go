@@ -3,13 +3,15 @@ package api
 import (
 	"github.com/padok-team/burrito/internal/burrito/config"
 	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	logClient "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type API struct {
-	config    *config.Config
-	Client    client.Client
-	Datastore datastore.Client
+	config       *config.Config
+	Client       client.Client
+	Datastore    datastore.Client
+	K8SLogClient logClient.Interface
 }
 
 func New(c *config.Config) *API {
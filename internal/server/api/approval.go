@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	log "github.com/sirupsen/logrus"
+)
+
+type planReviewRequest struct {
+	Run     string `json:"run"`
+	Attempt string `json:"attempt"`
+}
+
+func (a *API) ApproveLayerHandler(c echo.Context) error {
+	return a.reviewPlan(c, true)
+}
+
+func (a *API) RejectLayerHandler(c echo.Context) error {
+	return a.reviewPlan(c, false)
+}
+
+// reviewPlan annotates the layer with the reviewed plan so that the layer
+// controller can apply or discard it. The review is refused if the plan
+// reviewed by the user is not the one currently pending approval, or the last
+// targeted plan of the layer.
+func (a *API) reviewPlan(c echo.Context, approved bool) error {
+	request := planReviewRequest{}
+	if err := c.Bind(&request); err != nil || request.Run == "" || request.Attempt == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "The run and attempt of the reviewed plan are required"})
+	}
+	layer, err := a.getLayer(c)
+	if err != nil {
+		return getLayerErrorResponse(c, err)
+	}
+	reviewedPlan := fmt.Sprintf("%s/%s", request.Run, request.Attempt)
+	if reviewedPlan == layer.Annotations[annotations.LastTargetedPlanRun] {
+		return a.reviewTargetedPlan(c, layer, reviewedPlan, approved)
+	}
+	annotation, decision := annotations.ApprovePlan, "approved"
+	if !approved {
+		annotation, decision = annotations.RejectPlan, "rejected"
+	}
+	if layer.Status.State != "ApprovalPending" {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer has no plan pending approval"})
+	}
+	if reviewedPlan != layer.Annotations[annotations.LastPlanRun] {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "The reviewed plan has been replaced by a newer plan"})
+	}
+	err = annotations.Add(context.Background(), a.Client, layer, map[string]string{
+		annotation: reviewedPlan,
+	})
+	if err != nil {
+		log.Errorf("could not update terraform layer annotations: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while updating the layer annotations"})
+	}
+	log.Infof("plan %s of layer %s/%s has been %s by %s", reviewedPlan, layer.Namespace, layer.Name, decision, getUserEmail(c))
+	return c.JSON(http.StatusOK, map[string]string{"status": fmt.Sprintf("Layer plan %s", decision)})
+}
+
+// reviewTargetedPlan approves the last targeted plan of the layer, which the
+// layer controller then applies, or discards it
+func (a *API) reviewTargetedPlan(c echo.Context, layer *configv1alpha1.TerraformLayer, reviewedPlan string, approved bool) error {
+	var err error
+	decision := "approved"
+	if approved {
+		err = annotations.Add(context.Background(), a.Client, layer, map[string]string{
+			annotations.ApproveTargetedPlan: reviewedPlan,
+		})
+	} else {
+		decision = "rejected"
+		err = annotations.RemoveAll(context.Background(), a.Client, layer, annotations.TargetedPlan...)
+	}
+	if err != nil {
+		log.Errorf("could not update terraform layer annotations: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while updating the layer annotations"})
+	}
+	log.Infof("targeted plan %s of layer %s/%s has been %s by %s", reviewedPlan, layer.Namespace, layer.Name, decision, getUserEmail(c))
+	return c.JSON(http.StatusOK, map[string]string{"status": fmt.Sprintf("Layer targeted plan %s", decision)})
+}
+
+func getUserEmail(c echo.Context) string {
+	if email, ok := c.Get("user_email").(string); ok && email != "" {
+		return email
+	}
+	return "unauthenticated"
+}
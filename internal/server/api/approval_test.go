@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestApproveLayerHandlerLayerNotFound(t *testing.T) {
+	a := newTestAPI(t)
+	c, rec := newRunRequest(`{"run": "run", "attempt": "0"}`, "default", "missing")
+
+	if err := a.ApproveLayerHandler(c); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestApproveLayerHandlerApprovesTargetedPlan(t *testing.T) {
+	layer := &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "layer",
+			Namespace: "default",
+			Annotations: map[string]string{
+				annotations.LastPlanRun:         "plan-run/0",
+				annotations.LastTargetedPlanRun: "targeted-run/0",
+			},
+		},
+		Status: configv1alpha1.TerraformLayerStatus{State: "Idle"},
+	}
+	a := newTestAPI(t, layer)
+	c, rec := newRunRequest(`{"run": "targeted-run", "attempt": "0"}`, "default", "layer")
+
+	if err := a.ApproveLayerHandler(c); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	updated := &configv1alpha1.TerraformLayer{}
+	if err := a.Client.Get(context.Background(), client.ObjectKeyFromObject(layer), updated); err != nil {
+		t.Fatalf("failed to get layer: %s", err)
+	}
+	if approved := updated.Annotations[annotations.ApproveTargetedPlan]; approved != "targeted-run/0" {
+		t.Fatalf("expected the targeted plan to be approved, got %q", approved)
+	}
+	if _, ok := updated.Annotations[annotations.ApprovePlan]; ok {
+		t.Fatalf("expected the plan of the whole layer not to be approved")
+	}
+}
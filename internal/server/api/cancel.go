@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CancelRunHandler annotates the run as cancelled. The runner of the run
+// interrupts its command, and the run controller marks the run as Cancelled
+// instead of retrying it.
+func (a *API) CancelRunHandler(c echo.Context) error {
+	run := &configv1alpha1.TerraformRun{}
+	err := a.Client.Get(context.Background(), client.ObjectKey{
+		Namespace: c.Param("namespace"),
+		Name:      c.Param("run"),
+	}, run)
+	if err != nil {
+		log.Errorf("could not get terraform run: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while getting the run"})
+	}
+	if !runStillRunning(*run) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Run has already finished"})
+	}
+	if _, ok := run.Annotations[annotations.CancelRun]; ok {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Run cancellation already requested"})
+	}
+	user := getUserEmail(c)
+	err = annotations.Add(context.Background(), a.Client, run, map[string]string{
+		annotations.CancelRun: user,
+	})
+	if err != nil {
+		log.Errorf("could not update terraform run annotations: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while updating the run annotations"})
+	}
+	log.Infof("run %s/%s has been cancelled by %s", run.Namespace, run.Name, user)
+	return c.JSON(http.StatusOK, map[string]string{"status": "Run cancellation requested"})
+}
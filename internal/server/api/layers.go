@@ -29,6 +29,11 @@ type layer struct {
 	IsPR             bool                   `json:"isPR"`
 	LatestRuns       []Run                  `json:"latestRuns"`
 	ManualSyncStatus utils.ManualSyncStatus `json:"manualSyncStatus"`
+	HasDrifted       bool                   `json:"hasDrifted"`
+	PinnedRevision   string                 `json:"pinnedRevision,omitempty"`
+	Suspended        bool                   `json:"suspended"`
+	SuspendedBy      string                 `json:"suspendedBy,omitempty"`
+	SuspendReason    string                 `json:"suspendReason,omitempty"`
 }
 
 type Run struct {
@@ -78,7 +83,7 @@ func (a *API) LayersHandler(c echo.Context) error {
 		if ok {
 			runAPI = Run{
 				Name:   run.Name,
-				Commit: "",
+				Commit: run.Spec.Layer.Revision,
 				Date:   run.CreationTimestamp.Format(time.RFC3339),
 				Action: run.Spec.Action,
 			}
@@ -100,6 +105,11 @@ func (a *API) LayersHandler(c echo.Context) error {
 			IsPR:             a.isLayerPR(l),
 			LatestRuns:       transformLatestRuns(l.Status.LatestRuns),
 			ManualSyncStatus: utils.GetManualSyncStatus(l),
+			HasDrifted:       l.Status.HasDrifted,
+			PinnedRevision:   l.Annotations[annotations.PinnedRevision],
+			Suspended:        isLayerSuspended(l),
+			SuspendedBy:      l.Annotations[annotations.SuspendedBy],
+			SuspendReason:    l.Annotations[annotations.SuspendReason],
 		})
 	}
 	return c.JSON(http.StatusOK, &layersResponse{
@@ -109,7 +119,7 @@ func (a *API) LayersHandler(c echo.Context) error {
 }
 
 func runStillRunning(run configv1alpha1.TerraformRun) bool {
-	if run.Status.State != "Failed" && run.Status.State != "Succeeded" {
+	if run.Status.State != "Failed" && run.Status.State != "Succeeded" && run.Status.State != "Cancelled" {
 		return true
 	}
 	return false
@@ -133,7 +143,7 @@ func (a *API) getLayerState(layer configv1alpha1.TerraformLayer) string {
 	switch {
 	case len(layer.Status.Conditions) == 0:
 		state = "disabled"
-	case layer.Status.State == "ApplyNeeded":
+	case layer.Status.State == "ApplyNeeded" || layer.Status.State == "ApprovalPending":
 		if layer.Status.LastResult == "Plan: 0 to create, 0 to update, 0 to delete" {
 			state = "success"
 		} else {
@@ -141,6 +151,10 @@ func (a *API) getLayerState(layer configv1alpha1.TerraformLayer) string {
 		}
 	case layer.Status.State == "PlanNeeded":
 		state = "warning"
+	case layer.Status.State == "Suspended":
+		state = "disabled"
+	case layer.Status.State == "PolicyDenied" || layer.Status.State == "DestructiveChangesDenied":
+		state = "error"
 	}
 	if layer.Annotations[annotations.LastPlanSum] == "" {
 		state = "error"
@@ -151,6 +165,12 @@ func (a *API) getLayerState(layer configv1alpha1.TerraformLayer) string {
 	return state
 }
 
+// isLayerSuspended returns whether the layer is suspended, either in its spec
+// or through its repository as reported by its status
+func isLayerSuspended(layer configv1alpha1.TerraformLayer) bool {
+	return layer.Spec.Suspend || layer.Status.State == "Suspended"
+}
+
 func (a *API) isLayerPR(layer configv1alpha1.TerraformLayer) bool {
 	if len(layer.OwnerReferences) == 0 {
 		return false
@@ -7,11 +7,15 @@ import (
 
 	"github.com/labstack/echo/v4"
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
 	log "github.com/sirupsen/logrus"
 )
 
 type repository struct {
-	Name string `json:"name"`
+	Name          string `json:"name"`
+	Suspended     bool   `json:"suspended"`
+	SuspendedBy   string `json:"suspendedBy,omitempty"`
+	SuspendReason string `json:"suspendReason,omitempty"`
 }
 
 type repositoriesResponse struct {
@@ -28,7 +32,10 @@ func (a *API) RepositoriesHandler(c echo.Context) error {
 	results := []repository{}
 	for _, r := range repositories.Items {
 		results = append(results, repository{
-			Name: fmt.Sprintf("%s/%s", r.Namespace, r.Name),
+			Name:          fmt.Sprintf("%s/%s", r.Namespace, r.Name),
+			Suspended:     r.Spec.Suspend,
+			SuspendedBy:   r.Annotations[annotations.SuspendedBy],
+			SuspendReason: r.Annotations[annotations.SuspendReason],
 		})
 	}
 	return c.JSON(http.StatusOK, &repositoriesResponse{
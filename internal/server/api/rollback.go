@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type rollbackRequest struct {
+	Revision string `json:"revision"`
+}
+
+// RollbackLayerHandler pins the layer to a revision it has successfully
+// applied before. The layer controller then plans this revision instead of the
+// last commit of the branch, and applies it through the usual approval or
+// auto-apply flow, until the pin is removed.
+func (a *API) RollbackLayerHandler(c echo.Context) error {
+	request := rollbackRequest{}
+	if err := c.Bind(&request); err != nil || request.Revision == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "The revision to roll back to is required"})
+	}
+	layer, err := a.getLayer(c)
+	if err != nil {
+		return getLayerErrorResponse(c, err)
+	}
+	if a.isLayerPR(*layer) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Pull request layers cannot be rolled back"})
+	}
+	if !a.hasApplied(layer, request.Revision) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "The revision has not been successfully applied by a run in the history of the layer"})
+	}
+	exists, err := a.Datastore.CheckGitBundle(layer.Spec.Repository.Namespace, layer.Spec.Repository.Name, layer.Spec.Branch, request.Revision)
+	if err != nil {
+		log.Errorf("could not check git bundle of revision %s: %s", request.Revision, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while checking the revision in the datastore"})
+	}
+	if !exists {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "The revision is no longer available in the datastore"})
+	}
+	err = annotations.Add(context.Background(), a.Client, layer, map[string]string{
+		annotations.PinnedRevision: request.Revision,
+		annotations.SyncNow:        "true",
+	})
+	if err != nil {
+		log.Errorf("could not update terraform layer annotations: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while updating the layer annotations"})
+	}
+	log.Infof("layer %s/%s has been rolled back to revision %s by %s", layer.Namespace, layer.Name, request.Revision, getUserEmail(c))
+	return c.JSON(http.StatusOK, map[string]string{"status": "Layer pinned to revision " + request.Revision})
+}
+
+// UnpinLayerHandler removes the pinned revision of the layer, which is planned
+// again on the last commit of its branch
+func (a *API) UnpinLayerHandler(c echo.Context) error {
+	layer, err := a.getLayer(c)
+	if err != nil {
+		return getLayerErrorResponse(c, err)
+	}
+	pinnedRevision, ok := layer.Annotations[annotations.PinnedRevision]
+	if !ok {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer has no pinned revision"})
+	}
+	err = annotations.Remove(context.Background(), a.Client, layer, annotations.PinnedRevision)
+	if err != nil {
+		log.Errorf("could not update terraform layer annotations: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while updating the layer annotations"})
+	}
+	// The last plan of the pinned revision is more recent than the last branch
+	// commit, a sync is needed to plan this commit again
+	err = annotations.Add(context.Background(), a.Client, layer, map[string]string{
+		annotations.SyncNow: "true",
+	})
+	if err != nil {
+		log.Errorf("could not update terraform layer annotations: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Layer unpinned but its sync could not be triggered"})
+	}
+	log.Infof("layer %s/%s has been unpinned from revision %s by %s", layer.Namespace, layer.Name, pinnedRevision, getUserEmail(c))
+	return c.JSON(http.StatusOK, map[string]string{"status": "Layer unpinned"})
+}
+
+func (a *API) getLayer(c echo.Context) (*configv1alpha1.TerraformLayer, error) {
+	layer := &configv1alpha1.TerraformLayer{}
+	err := a.Client.Get(context.Background(), client.ObjectKey{
+		Namespace: c.Param("namespace"),
+		Name:      c.Param("layer"),
+	}, layer)
+	if err != nil {
+		log.Errorf("could not get terraform layer: %s", err)
+	}
+	return layer, err
+}
+
+// getLayerErrorResponse returns the response to an error getting the layer of
+// the request
+func getLayerErrorResponse(c echo.Context, err error) error {
+	if errors.IsNotFound(err) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Layer not found"})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while getting the layer"})
+}
+
+// hasApplied returns whether a successful apply run of the run history of the
+// layer used the given revision
+func (a *API) hasApplied(layer *configv1alpha1.TerraformLayer, revision string) bool {
+	for _, r := range layer.Status.LatestRuns {
+		if r.Action != "apply" || r.Commit != revision {
+			continue
+		}
+		run := &configv1alpha1.TerraformRun{}
+		err := a.Client.Get(context.Background(), types.NamespacedName{Namespace: layer.Namespace, Name: r.Name}, run)
+		if err != nil {
+			log.Warningf("could not get run %s of layer %s/%s: %s", r.Name, layer.Namespace, layer.Name, err)
+			continue
+		}
+		if run.Status.State == "Succeeded" && len(run.Spec.Targets) == 0 && len(run.Spec.Replace) == 0 {
+			return true
+		}
+	}
+	return false
+}
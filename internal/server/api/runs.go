@@ -4,10 +4,18 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/controllers/terraformlayer"
+	"github.com/padok-team/burrito/internal/controllers/terraformrun"
+	"github.com/padok-team/burrito/internal/utils/syncwindow"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type GetAttemptsResponse struct {
@@ -36,3 +44,153 @@ func (a *API) GetAttemptsHandler(c echo.Context) error {
 	response := GetAttemptsResponse{Count: len(runObject.Status.Attempts)}
 	return c.JSON(http.StatusOK, &response)
 }
+
+type createRunRequest struct {
+	Action  string   `json:"action"`
+	Targets []string `json:"targets"`
+	Replace []string `json:"replace"`
+	Args    []string `json:"args"`
+}
+
+// CreateRunHandler creates a plan restricted to some resources of the layer,
+// or a state maintenance run. Such runs are not scheduled by the layer
+// controller, the user who triggered them is recorded on the run. A targeted
+// plan is kept apart from the last plan of the whole layer: it is applied by
+// the layer controller only once explicitly approved.
+func (a *API) CreateRunHandler(c echo.Context) error {
+	request := createRunRequest{}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid run request"})
+	}
+	action := terraformlayer.Action(request.Action)
+	if terraformrun.IsStateAction(request.Action) {
+		if message := validateStateRunRequest(request); message != "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": message})
+		}
+	} else {
+		if action == terraformlayer.ApplyAction {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Targeted runs must be planned, the plan is applied once approved"})
+		}
+		if action != terraformlayer.PlanAction {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "The action of the run must be plan, import, state-mv, state-rm or force-unlock"})
+		}
+		if len(request.Targets) == 0 && len(request.Replace) == 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "At least one target or resource to replace is required"})
+		}
+		if len(request.Args) > 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Arguments are only supported by state maintenance actions"})
+		}
+	}
+	layer, err := a.getLayer(c)
+	if err != nil {
+		return getLayerErrorResponse(c, err)
+	}
+	if !layer.DeletionTimestamp.IsZero() {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer is being deleted"})
+	}
+	if isLayerSuspended(*layer) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer is suspended"})
+	}
+	repository := &configv1alpha1.TerraformRepository{}
+	err = a.Client.Get(context.Background(), client.ObjectKey{
+		Namespace: layer.Spec.Repository.Namespace,
+		Name:      layer.Spec.Repository.Name,
+	}, repository)
+	if err != nil {
+		log.Errorf("could not get terraform repository: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while getting the repository"})
+	}
+	if configv1alpha1.GetTerragruntStackEnabled(repository, layer) && !terraformrun.IsStateAction(request.Action) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Targeted runs are not supported for terragrunt stacks"})
+	}
+	if blocked, reason := a.isRunBlocked(layer, repository, request.Action); blocked {
+		return c.JSON(http.StatusConflict, map[string]string{"error": fmt.Sprintf("Layer is %s, no %s action can be run", reason, request.Action)})
+	}
+	// A layer rolled back to a pinned revision must not run on its branch
+	revision, _ := terraformlayer.GetRevision(layer)
+	if revision == "" {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer has not been synced with its repository yet"})
+	}
+	user := getUserEmail(c)
+	run := getTargetedRun(layer, revision, action, request, user)
+	err = a.Client.Create(context.Background(), run)
+	if err != nil {
+		log.Errorf("could not create terraform run: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while creating the run"})
+	}
+	if terraformrun.IsStateAction(request.Action) {
+		log.Infof("%s run %s of layer %s/%s has been triggered by %s with arguments %v", action, run.Name, layer.Namespace, layer.Name, user, request.Args)
+	} else {
+		log.Infof("targeted %s run %s of layer %s/%s has been triggered by %s on targets %v, replacing %v", action, run.Name, layer.Namespace, layer.Name, user, request.Targets, request.Replace)
+	}
+	return c.JSON(http.StatusCreated, map[string]string{"status": "Run created", "run": run.Name})
+}
+
+// isRunBlocked checks the sync windows of the layer like the layer controller
+// does for the runs it schedules. State maintenance runs change the state, they
+// are subject to the apply windows.
+func (a *API) isRunBlocked(layer *configv1alpha1.TerraformLayer, repository *configv1alpha1.TerraformRepository, action string) (bool, string) {
+	windowAction := syncwindow.ApplyAction
+	if action == string(terraformlayer.PlanAction) {
+		windowAction = syncwindow.PlanAction
+	}
+	blocked, reason := syncwindow.IsSyncBlocked(append(repository.Spec.SyncWindows, a.config.Controller.DefaultSyncWindows...), windowAction, layer.Name)
+	if !blocked {
+		return false, ""
+	}
+	if reason == syncwindow.BlockReasonInsideDenyWindow {
+		return true, "in a deny window"
+	}
+	return true, "outside an allow window"
+}
+
+// validateStateRunRequest checks the arguments of a state maintenance run,
+// which cannot be flags of the command. Returns the error message of the
+// response, empty if the request is valid.
+func validateStateRunRequest(request createRunRequest) string {
+	if len(request.Targets) > 0 || len(request.Replace) > 0 {
+		return fmt.Sprintf("Targets and resources to replace are not supported by the %s action", request.Action)
+	}
+	count := terraformrun.StateActions[terraformrun.Action(request.Action)]
+	if (count < 0 && len(request.Args) == 0) || (count >= 0 && len(request.Args) != count) {
+		return fmt.Sprintf("Invalid number of arguments for the %s action", request.Action)
+	}
+	for _, arg := range request.Args {
+		if arg == "" || strings.HasPrefix(arg, "-") {
+			return fmt.Sprintf("Invalid argument %q for the %s action", arg, request.Action)
+		}
+	}
+	return ""
+}
+
+func getTargetedRun(layer *configv1alpha1.TerraformLayer, revision string, action terraformlayer.Action, request createRunRequest, user string) *configv1alpha1.TerraformRun {
+	return &configv1alpha1.TerraformRun{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-%s-", layer.Name, action),
+			Namespace:    layer.Namespace,
+			Labels:       terraformlayer.GetDefaultLabels(layer),
+			Annotations: map[string]string{
+				annotations.TriggeredBy: user,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: configv1alpha1.GroupVersion.String(),
+					Kind:       "TerraformLayer",
+					Name:       layer.Name,
+					UID:        layer.UID,
+				},
+			},
+		},
+		Spec: configv1alpha1.TerraformRunSpec{
+			Action: string(action),
+			Layer: configv1alpha1.TerraformRunLayer{
+				Name:      layer.Name,
+				Namespace: layer.Namespace,
+				Revision:  revision,
+			},
+			Targets: request.Targets,
+			Replace: request.Replace,
+			Args:    request.Args,
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	"github.com/padok-team/burrito/internal/burrito/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestAPI(t *testing.T, objects ...client.Object) *API {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add kubernetes types to scheme: %s", err)
+	}
+	if err := configv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add burrito types to scheme: %s", err)
+	}
+	a := New(config.TestConfig())
+	a.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	return a
+}
+
+func newRunRequest(body string, namespace string, layer string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("namespace", "layer")
+	c.SetParamValues(namespace, layer)
+	return c, rec
+}
+
+func TestCreateRunHandlerUsesPinnedRevision(t *testing.T) {
+	layer := &configv1alpha1.TerraformLayer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "layer",
+			Namespace: "default",
+			Annotations: map[string]string{
+				annotations.LastBranchCommit: "def456",
+				annotations.PinnedRevision:   "abc123",
+			},
+		},
+		Spec: configv1alpha1.TerraformLayerSpec{
+			Repository: configv1alpha1.TerraformLayerRepository{Name: "repo", Namespace: "default"},
+		},
+	}
+	repository := &configv1alpha1.TerraformRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "repo", Namespace: "default"},
+	}
+	a := newTestAPI(t, layer, repository)
+	c, rec := newRunRequest(`{"action": "plan", "targets": ["aws_instance.web"]}`, "default", "layer")
+
+	if err := a.CreateRunHandler(c); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	response := map[string]string{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not parse response: %s", err)
+	}
+	run := &configv1alpha1.TerraformRun{}
+	if err := a.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: response["run"]}, run); err != nil {
+		t.Fatalf("failed to get run: %s", err)
+	}
+	if run.Spec.Layer.Revision != "abc123" {
+		t.Fatalf("expected the run to use the pinned revision, got %s", run.Spec.Layer.Revision)
+	}
+}
+
+func TestCreateRunHandlerLayerNotFound(t *testing.T) {
+	a := newTestAPI(t)
+	c, rec := newRunRequest(`{"action": "plan", "targets": ["aws_instance.web"]}`, "default", "missing")
+
+	if err := a.CreateRunHandler(c); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	"github.com/padok-team/burrito/internal/utils/redact"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StreamPodPollInterval is the interval at which the state of a runner pod
+// which has not started yet is checked before streaming its logs
+const StreamPodPollInterval = 2 * time.Second
+
+// StreamLogsHandler streams the logs of a run attempt as Server-Sent Events.
+// While the runner pod of the attempt exists, its logs are followed through
+// the Kubernetes log API. Once the pod is gone, the copy of the logs stored in
+// the datastore is sent instead. Each log line is sent as a message event and
+// the stream is closed with an end event. The logs of the pod are redacted as
+// the ones stored in the datastore. A run of another layer, or an attempt
+// which is neither running nor stored, is not found.
+// logs/${namespace}/${layer}/${runId}/${attemptId}/stream
+func (a *API) StreamLogsHandler(c echo.Context) error {
+	namespace, layer, runName, attemptParam, err := getLogsArgs(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	number, err := strconv.Atoi(attemptParam)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "invalid attempt number")
+	}
+	ctx := c.Request().Context()
+	run := &configv1alpha1.TerraformRun{}
+	err = a.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: runName}, run)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Errorf("could not get terraform run: %s", err)
+		return c.String(http.StatusInternalServerError, "could not get the run")
+	}
+	podName := ""
+	if err == nil {
+		if run.Spec.Layer.Name != layer {
+			return c.String(http.StatusNotFound, "run not found for this layer")
+		}
+		for _, attempt := range run.Status.Attempts {
+			if attempt.Number == number {
+				podName = attempt.PodName
+			}
+		}
+	}
+	// Without a runner pod to follow, the stored logs are sent as they are
+	var content []string
+	if podName == "" {
+		content, err = a.Datastore.GetLogs(namespace, layer, runName, attemptParam)
+		if storageerrors.NotFound(err) {
+			return c.String(http.StatusNotFound, "no logs for this attempt")
+		}
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "could not get logs, there's an issue with the storage backend")
+		}
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	if podName != "" {
+		redactor, err := a.getRedactor(ctx, run.Spec.Layer.Namespace, run.Spec.Layer.Name)
+		if err != nil {
+			log.Errorf("could not get redaction of layer %s/%s: %s", namespace, layer, err)
+			writeEvent(res, "error", "could not redact the logs of the runner pod")
+			return nil
+		}
+		streamed, err := a.streamPodLogs(ctx, res, redactor, namespace, podName)
+		if err != nil {
+			log.Errorf("could not stream logs of pod %s/%s: %s", namespace, podName, err)
+		}
+		if !streamed && ctx.Err() == nil {
+			content, err = a.Datastore.GetLogs(namespace, layer, runName, attemptParam)
+			if err != nil {
+				writeEvent(res, "error", "could not get logs, there's an issue with the storage backend")
+				return nil
+			}
+		}
+	}
+	for _, line := range content {
+		writeEvent(res, "", line)
+	}
+	writeEvent(res, "end", "")
+	return nil
+}
+
+// streamPodLogs follows the logs of a runner pod until the pod terminates or
+// the client goes away. It returns false if the pod does not exist anymore,
+// in which case the logs should be read from the datastore.
+func (a *API) streamPodLogs(ctx context.Context, res *echo.Response, redactor *redact.Redactor, namespace, podName string) (bool, error) {
+	pod := &corev1.Pod{}
+	for {
+		err := a.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: podName}, pod)
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if pod.Status.Phase != corev1.PodPending {
+			break
+		}
+		// Keep the connection open while the pod is starting
+		fmt.Fprint(res, ": waiting for the runner pod to start\n\n")
+		res.Flush()
+		select {
+		case <-ctx.Done():
+			return true, nil
+		case <-time.After(StreamPodPollInterval):
+		}
+	}
+	logs, err := a.K8SLogClient.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{Follow: true}).Stream(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer logs.Close()
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		writeEvent(res, "", string(redactor.Redact(scanner.Bytes())))
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// getRedactor returns the redactor masking the sensitive values of the logs of
+// a layer
+func (a *API) getRedactor(ctx context.Context, namespace, layerName string) (*redact.Redactor, error) {
+	layer := &configv1alpha1.TerraformLayer{}
+	err := a.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: layerName}, layer)
+	if err != nil {
+		return nil, err
+	}
+	repo := &configv1alpha1.TerraformRepository{}
+	err = a.Client.Get(ctx, client.ObjectKey{Namespace: layer.Spec.Repository.Namespace, Name: layer.Spec.Repository.Name}, repo)
+	if err != nil {
+		return nil, err
+	}
+	return redact.ForLayer(ctx, a.Client, repo, layer)
+}
+
+// writeEvent sends a Server-Sent Event to the client. Messages without an
+// event name are dispatched as the default message event.
+func writeEvent(res *echo.Response, event string, data string) {
+	if event != "" {
+		fmt.Fprintf(res, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(res, "data: %s\n", line)
+	}
+	fmt.Fprint(res, "\n")
+	res.Flush()
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	datastore "github.com/padok-team/burrito/internal/datastore/client"
+	storageerrors "github.com/padok-team/burrito/internal/datastore/storage/error"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// logsDatastore serves the logs stored for the attempts of a run
+type logsDatastore struct {
+	*datastore.MockClient
+	logs map[string][]string
+}
+
+func (d *logsDatastore) GetLogs(namespace string, layer string, run string, attempt string) ([]string, error) {
+	content, ok := d.logs[fmt.Sprintf("%s/%s/%s/%s", namespace, layer, run, attempt)]
+	if !ok {
+		return nil, &storageerrors.StorageError{Err: fmt.Errorf("no logs for this attempt"), Nil: true}
+	}
+	return content, nil
+}
+
+func newStreamTestAPI(t *testing.T, objects ...client.Object) *API {
+	t.Helper()
+
+	objects = append(objects,
+		&configv1alpha1.TerraformLayer{
+			ObjectMeta: metav1.ObjectMeta{Name: "layer", Namespace: "default"},
+			Spec: configv1alpha1.TerraformLayerSpec{
+				Repository: configv1alpha1.TerraformLayerRepository{Name: "repo", Namespace: "default"},
+			},
+		},
+		&configv1alpha1.TerraformRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: "repo", Namespace: "default"},
+		},
+	)
+	a := newTestAPI(t, objects...)
+	a.Datastore = &logsDatastore{
+		MockClient: datastore.NewMockClient(),
+		logs:       map[string][]string{"default/layer/run/0": {"stored line"}},
+	}
+	// The fake clientset serves "fake logs" as the logs of any pod
+	a.K8SLogClient = fake.NewClientset()
+	return a
+}
+
+func streamTestRun(layer string) *configv1alpha1.TerraformRun {
+	return &configv1alpha1.TerraformRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"},
+		Spec: configv1alpha1.TerraformRunSpec{
+			Layer: configv1alpha1.TerraformRunLayer{Name: layer, Namespace: "default"},
+		},
+		Status: configv1alpha1.TerraformRunStatus{
+			Attempts: []configv1alpha1.Attempt{{PodName: "run-pod", Number: 0}},
+		},
+	}
+}
+
+func newStreamRequest(layer string, run string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("namespace", "layer", "run", "attempt")
+	c.SetParamValues("default", layer, run, "0")
+	return c, rec
+}
+
+func TestStreamLogsHandler(t *testing.T) {
+	runnerPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-pod", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	tests := []struct {
+		name    string
+		objects []client.Object
+		layer   string
+		run     string
+		code    int
+		want    string
+		notWant string
+	}{
+		{
+			name:    "live runner pod",
+			objects: []client.Object{streamTestRun("layer"), runnerPod},
+			layer:   "layer",
+			run:     "run",
+			code:    http.StatusOK,
+			want:    "data: fake logs\n\nevent: end\n",
+			notWant: "stored line",
+		},
+		{
+			name:    "runner pod gone",
+			objects: []client.Object{streamTestRun("layer")},
+			layer:   "layer",
+			run:     "run",
+			code:    http.StatusOK,
+			want:    "data: stored line\n\nevent: end\n",
+			notWant: "fake logs",
+		},
+		{
+			name:  "unknown run",
+			layer: "layer",
+			run:   "unknown",
+			code:  http.StatusNotFound,
+		},
+		{
+			name:    "run of another layer",
+			objects: []client.Object{streamTestRun("other"), runnerPod},
+			layer:   "layer",
+			run:     "run",
+			code:    http.StatusNotFound,
+			notWant: "fake logs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newStreamTestAPI(t, tt.objects...)
+			c, rec := newStreamRequest(tt.layer, tt.run)
+
+			if err := a.StreamLogsHandler(c); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if rec.Code != tt.code {
+				t.Fatalf("expected status %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+			body := rec.Body.String()
+			if !strings.Contains(body, tt.want) {
+				t.Fatalf("expected the stream to contain %q, got %q", tt.want, body)
+			}
+			if tt.notWant != "" && strings.Contains(body, tt.notWant) {
+				t.Fatalf("expected the stream not to contain %q, got %q", tt.notWant, body)
+			}
+		})
+	}
+}
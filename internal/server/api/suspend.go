@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	"github.com/padok-team/burrito/internal/annotations"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type suspendRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (a *API) SuspendLayerHandler(c echo.Context) error {
+	return a.updateLayerSuspension(c, true)
+}
+
+func (a *API) ResumeLayerHandler(c echo.Context) error {
+	return a.updateLayerSuspension(c, false)
+}
+
+func (a *API) SuspendRepositoryHandler(c echo.Context) error {
+	return a.updateRepositorySuspension(c, true)
+}
+
+func (a *API) ResumeRepositoryHandler(c echo.Context) error {
+	return a.updateRepositorySuspension(c, false)
+}
+
+func (a *API) updateLayerSuspension(c echo.Context, suspend bool) error {
+	layer, err := a.getLayer(c)
+	if err != nil {
+		return getLayerErrorResponse(c, err)
+	}
+	return a.updateSuspension(c, layer, &layer.Spec.Suspend, suspend)
+}
+
+func (a *API) updateRepositorySuspension(c echo.Context, suspend bool) error {
+	repository := &configv1alpha1.TerraformRepository{}
+	err := a.Client.Get(context.Background(), client.ObjectKey{
+		Namespace: c.Param("namespace"),
+		Name:      c.Param("repository"),
+	}, repository)
+	if err != nil {
+		log.Errorf("could not get terraform repository: %s", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while getting the repository"})
+	}
+	return a.updateSuspension(c, repository, &repository.Spec.Suspend, suspend)
+}
+
+// updateSuspension sets the suspend field of the spec of a layer or a
+// repository. The user who suspended it and the reason of the suspension are
+// recorded in its annotations, which are removed when it is resumed.
+func (a *API) updateSuspension(c echo.Context, obj client.Object, field *bool, suspend bool) error {
+	request := suspendRequest{}
+	if suspend {
+		if err := c.Bind(&request); err != nil || request.Reason == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "The reason of the suspension is required"})
+		}
+	}
+	if *field == suspend {
+		if suspend {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Already suspended"})
+		}
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Not suspended"})
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	*field = suspend
+	objAnnotations := obj.GetAnnotations()
+	if objAnnotations == nil {
+		objAnnotations = map[string]string{}
+	}
+	user := getUserEmail(c)
+	if suspend {
+		objAnnotations[annotations.SuspendedBy] = user
+		objAnnotations[annotations.SuspendReason] = request.Reason
+	} else {
+		delete(objAnnotations, annotations.SuspendedBy)
+		delete(objAnnotations, annotations.SuspendReason)
+	}
+	obj.SetAnnotations(objAnnotations)
+	err := a.Client.Patch(context.Background(), obj, patch)
+	if err != nil {
+		log.Errorf("could not update suspension of %s/%s: %s", obj.GetNamespace(), obj.GetName(), err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while updating the suspension"})
+	}
+	if suspend {
+		log.Infof("%s/%s has been suspended by %s: %s", obj.GetNamespace(), obj.GetName(), user, request.Reason)
+		return c.JSON(http.StatusOK, map[string]string{"status": "Suspended"})
+	}
+	log.Infof("%s/%s has been resumed by %s", obj.GetNamespace(), obj.GetName(), user)
+	return c.JSON(http.StatusOK, map[string]string{"status": "Resumed"})
+}
@@ -22,6 +22,9 @@ func (a *API) SyncLayerHandler(c echo.Context) error {
 		log.Errorf("could not get terraform layer: %s", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "An error occurred while getting the layer"})
 	}
+	if isLayerSuspended(*layer) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer is suspended"})
+	}
 	syncStatus := utils.GetManualSyncStatus(*layer)
 	if syncStatus == utils.ManualSyncAnnotated || syncStatus == utils.ManualSyncPending {
 		return c.JSON(http.StatusConflict, map[string]string{"error": "Layer sync already triggered"})
@@ -24,6 +24,7 @@ import (
 	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	logClient "k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -93,6 +94,11 @@ func (s *Server) Exec() {
 	}
 	s.client = *client
 	s.API.Client = s.client
+	clientset, err := logClient.NewForConfig(ctrl.GetConfigOrDie())
+	if err != nil {
+		log.Fatalf("error initializing log client: %s", err)
+	}
+	s.API.K8SLogClient = clientset
 	s.Webhook = webhook.New(s.config, *client)
 
 	// Initialize authentication handlers based on configuration
@@ -168,9 +174,20 @@ func (s *Server) Exec() {
 	api.Use(middleware.RequestLoggerWithConfig(utils.LoggerMiddlewareConfig))
 	api.GET("/layers", s.API.LayersHandler)
 	api.POST("/layers/:namespace/:layer/sync", s.API.SyncLayerHandler)
+	api.POST("/layers/:namespace/:layer/approve", s.API.ApproveLayerHandler)
+	api.POST("/layers/:namespace/:layer/reject", s.API.RejectLayerHandler)
+	api.POST("/layers/:namespace/:layer/runs", s.API.CreateRunHandler)
+	api.POST("/layers/:namespace/:layer/rollback", s.API.RollbackLayerHandler)
+	api.DELETE("/layers/:namespace/:layer/rollback", s.API.UnpinLayerHandler)
+	api.POST("/layers/:namespace/:layer/suspend", s.API.SuspendLayerHandler)
+	api.POST("/layers/:namespace/:layer/resume", s.API.ResumeLayerHandler)
 	api.GET("/repositories", s.API.RepositoriesHandler)
+	api.POST("/repositories/:namespace/:repository/suspend", s.API.SuspendRepositoryHandler)
+	api.POST("/repositories/:namespace/:repository/resume", s.API.ResumeRepositoryHandler)
 	api.GET("/logs/:namespace/:layer/:run/:attempt", s.API.GetLogsHandler)
+	api.GET("/logs/:namespace/:layer/:run/:attempt/stream", s.API.StreamLogsHandler)
 	api.GET("/run/:namespace/:layer/:run/attempts", s.API.GetAttemptsHandler)
+	api.POST("/runs/:namespace/:run/cancel", s.API.CancelRunHandler)
 
 	// Redirect root to layers if authenticated, otherwise to login
 	e.GET("/", func(c echo.Context) error {
@@ -1,16 +1,130 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"sync"
 
 	"github.com/spf13/cobra"
 )
 
+// StderrTailSize is the size of the end of the standard error of a command
+// kept in memory
+const StderrTailSize = 64 * 1024
+
+// Output receives the output of the commands run with Verbose in addition to
+// the standard outputs, for the runner to ship it to the datastore
+var Output io.Writer = io.Discard
+
+// TailBuffer is a writer keeping the last bytes written to it
+type TailBuffer struct {
+	mu   sync.Mutex
+	size int
+	buf  []byte
+}
+
+func (b *TailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.size {
+		b.buf = b.buf[len(b.buf)-b.size:]
+	}
+	return len(p), nil
+}
+
+func (b *TailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
 func Verbose(cmd *exec.Cmd) {
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = io.MultiWriter(os.Stdout, Output)
+	cmd.Stderr = io.MultiWriter(os.Stderr, Output)
+}
+
+// ErrInterrupted is returned when a command is run after its group has been
+// interrupted
+var ErrInterrupted = errors.New("command has been interrupted")
+
+// Running is the group of the commands run with Run
+var Running = &Group{}
+
+// Group tracks the commands being run, for them to be interrupted
+type Group struct {
+	mu          sync.Mutex
+	interrupted bool
+	cmds        map[*exec.Cmd]struct{}
+	// End of the standard error of the last command which failed
+	failedStderr string
+}
+
+// Run runs the command in the Running group
+func Run(cmd *exec.Cmd) error {
+	return Running.Run(cmd)
+}
+
+// Run starts the command and waits for it to exit. The command is not started
+// if the group has been interrupted. The end of the standard error of the
+// command is kept if it fails.
+func (g *Group) Run(cmd *exec.Cmd) error {
+	g.mu.Lock()
+	if g.interrupted {
+		g.mu.Unlock()
+		return ErrInterrupted
+	}
+	stderr := &TailBuffer{size: StderrTailSize}
+	if cmd.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, stderr)
+	} else {
+		cmd.Stderr = stderr
+	}
+	err := cmd.Start()
+	if err != nil {
+		g.mu.Unlock()
+		return err
+	}
+	if g.cmds == nil {
+		g.cmds = map[*exec.Cmd]struct{}{}
+	}
+	g.cmds[cmd] = struct{}{}
+	g.mu.Unlock()
+	err = cmd.Wait()
+	g.mu.Lock()
+	delete(g.cmds, cmd)
+	if err != nil {
+		g.failedStderr = stderr.String()
+	}
+	g.mu.Unlock()
+	return err
+}
+
+// FailedStderr returns the end of the standard error of the last command of
+// the group which failed
+func (g *Group) FailedStderr() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.failedStderr
+}
+
+// Interrupt sends a SIGINT to the running commands of the group, for them to
+// stop gracefully, and prevents other commands from being started. Only the
+// commands are signaled, not the process running them.
+func (g *Group) Interrupt() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.interrupted = true
+	var errs []error
+	for cmd := range g.cmds {
+		if err := cmd.Process.Signal(os.Interrupt); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func UnsupportedCommand(cmd *cobra.Command, args []string) {
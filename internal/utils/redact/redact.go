@@ -0,0 +1,162 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Mask replaces the redacted values
+const Mask = "[REDACTED]"
+
+// MinValueLength is the length under which sensitive values are not masked,
+// as masking them would mask most of the logs
+const MinValueLength = 4
+
+// Redactor masks sensitive values and the text matching patterns in logs and
+// plans before they are stored in the datastore
+type Redactor struct {
+	values   []string
+	patterns []*regexp.Regexp
+}
+
+// New returns a redactor masking the given values and the text matching the
+// given patterns. Each line of a multiline value is masked on its own, as it
+// may be printed line by line.
+func New(values []string, patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, v := range append([]string{value}, strings.Split(value, "\n")...) {
+			v = strings.TrimSpace(v)
+			if len(v) < MinValueLength || seen[v] {
+				continue
+			}
+			seen[v] = true
+			r.values = append(r.values, v)
+		}
+	}
+	// Longer values first, so that a value containing another one is fully
+	// masked
+	sort.SliceStable(r.values, func(i, j int) bool {
+		return len(r.values[i]) > len(r.values[j])
+	})
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns the content with the sensitive values and the text matching
+// the patterns masked
+func (r *Redactor) Redact(content []byte) []byte {
+	if r == nil || (len(r.values) == 0 && len(r.patterns) == 0) {
+		return content
+	}
+	s := string(content)
+	for _, value := range r.values {
+		s = strings.ReplaceAll(s, value, Mask)
+	}
+	for _, re := range r.patterns {
+		s = redactPattern(s, re)
+	}
+	return []byte(s)
+}
+
+// redactPattern masks the text matching the pattern, or the text matching its
+// groups if it has any
+func redactPattern(s string, re *regexp.Regexp) string {
+	if re.NumSubexp() == 0 {
+		return re.ReplaceAllLiteralString(s, Mask)
+	}
+	var b strings.Builder
+	last := 0
+	for _, match := range re.FindAllStringSubmatchIndex(s, -1) {
+		for g := 1; g <= re.NumSubexp(); g++ {
+			start, end := match[2*g], match[2*g+1]
+			// Skip unmatched groups and groups nested in a masked one
+			if start < 0 || start < last {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(Mask)
+			last = end
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// ForLayer returns the redactor of the runs of a layer: it masks the values of
+// the sensitive variables of the layer and of its repository, the values of
+// the environment variables of the runner read from a Secret, and the text
+// matching the redaction patterns of the layer and of its repository.
+func ForLayer(ctx context.Context, c client.Client, repository *configv1alpha1.TerraformRepository, layer *configv1alpha1.TerraformLayer) (*Redactor, error) {
+	values := []string{}
+	for _, v := range configv1alpha1.GetVariables(repository, layer) {
+		if !v.Sensitive && (v.ValueFrom == nil || v.ValueFrom.SecretKeyRef == nil) {
+			continue
+		}
+		value, err := getVariableValue(ctx, c, layer.Namespace, v)
+		if err != nil {
+			return nil, fmt.Errorf("could not get value of sensitive variable %s: %w", v.Name, err)
+		}
+		values = append(values, value)
+	}
+	for _, env := range configv1alpha1.GetOverrideRunnerSpec(repository, layer).Env {
+		if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil {
+			continue
+		}
+		selector := env.ValueFrom.SecretKeyRef
+		value, err := getSecretValue(ctx, c, layer.Namespace, selector.Name, selector.Key)
+		if errors.IsNotFound(err) && selector.Optional != nil && *selector.Optional {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not get value of environment variable %s: %w", env.Name, err)
+		}
+		values = append(values, value)
+	}
+	return New(values, configv1alpha1.GetRedactionPatterns(repository, layer))
+}
+
+func getVariableValue(ctx context.Context, c client.Client, namespace string, v configv1alpha1.TerraformVariable) (string, error) {
+	switch {
+	case v.ValueFrom == nil:
+		return v.Value, nil
+	case v.ValueFrom.SecretKeyRef != nil:
+		return getSecretValue(ctx, c, namespace, v.ValueFrom.SecretKeyRef.Name, v.ValueFrom.SecretKeyRef.Key)
+	case v.ValueFrom.ConfigMapKeyRef != nil:
+		configMap := &corev1.ConfigMap{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: v.ValueFrom.ConfigMapKeyRef.Name}, configMap)
+		if err != nil {
+			return "", err
+		}
+		if value, ok := configMap.Data[v.ValueFrom.ConfigMapKeyRef.Key]; ok {
+			return value, nil
+		}
+		return string(configMap.BinaryData[v.ValueFrom.ConfigMapKeyRef.Key]), nil
+	}
+	return "", nil
+}
+
+func getSecretValue(ctx context.Context, c client.Client, namespace string, name string, key string) (string, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret)
+	if err != nil {
+		return "", err
+	}
+	return string(secret.Data[key]), nil
+}
@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"path"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+)
+
+// How the destructive changes of a plan must be handled, from the least to
+// the most restrictive
+const (
+	DestructiveChangesNone             string = "None"
+	DestructiveChangesAllowed          string = "Allowed"
+	DestructiveChangesApprovalRequired string = "ApprovalRequired"
+	DestructiveChangesDenied           string = "Denied"
+)
+
+var destructiveChangesOrder = []string{
+	DestructiveChangesNone,
+	DestructiveChangesAllowed,
+	DestructiveChangesApprovalRequired,
+	DestructiveChangesDenied,
+}
+
+// CheckDestructiveChanges returns how the resources deleted or replaced by the
+// plan must be handled according to the destructive changes strategy and the
+// resource types overriding it, along with the addresses of the changes which
+// are not allowed
+func CheckDestructiveChanges(plan *tfjson.Plan, strategy string, types configv1alpha1.DestructiveResourceTypes) (string, []string) {
+	result := DestructiveChangesNone
+	blocking := []string{}
+	for _, res := range plan.ResourceChanges {
+		if res.Change == nil || !(res.Change.Actions.Delete() || res.Change.Actions.Replace()) {
+			continue
+		}
+		var check string
+		switch {
+		case matchesResourceType(res.Type, types.Deny):
+			check = DestructiveChangesDenied
+		case matchesResourceType(res.Type, types.Allow):
+			check = DestructiveChangesAllowed
+		case strategy == configv1alpha1.DestructiveChangesDeny:
+			check = DestructiveChangesDenied
+		case strategy == configv1alpha1.DestructiveChangesRequireApproval:
+			check = DestructiveChangesApprovalRequired
+		default:
+			check = DestructiveChangesAllowed
+		}
+		if check != DestructiveChangesAllowed {
+			blocking = append(blocking, res.Address)
+		}
+		result = MergeDestructiveChanges(result, check)
+	}
+	return result, blocking
+}
+
+// MergeDestructiveChanges returns the most restrictive of the two results
+func MergeDestructiveChanges(a, b string) string {
+	for i := len(destructiveChangesOrder) - 1; i >= 0; i-- {
+		if a == destructiveChangesOrder[i] || b == destructiveChangesOrder[i] {
+			return destructiveChangesOrder[i]
+		}
+	}
+	return DestructiveChangesNone
+}
+
+func matchesResourceType(resourceType string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, resourceType); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+)
+
+func TestCheckDestructiveChanges(t *testing.T) {
+	plan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionCreate}}},
+			{Address: "null_resource.trigger", Type: "null_resource", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}}},
+			{Address: "aws_db_instance.main", Type: "aws_db_instance", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete}}},
+		},
+	}
+	tests := []struct {
+		name     string
+		plan     *tfjson.Plan
+		strategy string
+		types    configv1alpha1.DestructiveResourceTypes
+		want     string
+		blocking []string
+	}{
+		{
+			name:     "no destructive changes",
+			plan:     &tfjson.Plan{ResourceChanges: plan.ResourceChanges[:1]},
+			strategy: configv1alpha1.DestructiveChangesDeny,
+			want:     DestructiveChangesNone,
+			blocking: []string{},
+		},
+		{
+			name:     "destructive changes allowed",
+			plan:     plan,
+			strategy: configv1alpha1.DestructiveChangesAllow,
+			want:     DestructiveChangesAllowed,
+			blocking: []string{},
+		},
+		{
+			name:     "destructive changes requiring an approval",
+			plan:     plan,
+			strategy: configv1alpha1.DestructiveChangesRequireApproval,
+			types:    configv1alpha1.DestructiveResourceTypes{Allow: []string{"null_resource"}},
+			want:     DestructiveChangesApprovalRequired,
+			blocking: []string{"aws_db_instance.main"},
+		},
+		{
+			name:     "denied resource type",
+			plan:     plan,
+			strategy: configv1alpha1.DestructiveChangesAllow,
+			types:    configv1alpha1.DestructiveResourceTypes{Deny: []string{"aws_db_*"}},
+			want:     DestructiveChangesDenied,
+			blocking: []string{"aws_db_instance.main"},
+		},
+		{
+			name:     "denied resource type takes precedence over allowed resource type",
+			plan:     plan,
+			strategy: configv1alpha1.DestructiveChangesRequireApproval,
+			types:    configv1alpha1.DestructiveResourceTypes{Allow: []string{"*"}, Deny: []string{"null_resource"}},
+			want:     DestructiveChangesDenied,
+			blocking: []string{"null_resource.trigger"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, blocking := CheckDestructiveChanges(tt.plan, tt.strategy, tt.types)
+			if got != tt.want || !reflect.DeepEqual(blocking, tt.blocking) {
+				t.Fatalf("expected (%s, %v), got (%s, %v)", tt.want, tt.blocking, got, blocking)
+			}
+		})
+	}
+}
+
+func TestMergeDestructiveChanges(t *testing.T) {
+	if got := MergeDestructiveChanges(DestructiveChangesApprovalRequired, DestructiveChangesAllowed); got != DestructiveChangesApprovalRequired {
+		t.Fatalf("expected %s, got %s", DestructiveChangesApprovalRequired, got)
+	}
+	if got := MergeDestructiveChanges(DestructiveChangesNone, DestructiveChangesDenied); got != DestructiveChangesDenied {
+		t.Fatalf("expected %s, got %s", DestructiveChangesDenied, got)
+	}
+}
@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"regexp"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+)
+
+// Patterns of the output of terraform, opentofu, terragrunt and the main
+// providers matching each class of failures, checked in order
+var failureClassPatterns = []struct {
+	class   string
+	pattern *regexp.Regexp
+}{
+	{
+		class:   configv1alpha1.FailureClassStateLocked,
+		pattern: regexp.MustCompile(`Error acquiring the state lock|Error locking state`),
+	},
+	{
+		class:   configv1alpha1.FailureClassThrottled,
+		pattern: regexp.MustCompile(`(?i)ThrottlingException|Throttling:|Rate exceeded|RequestLimitExceeded|TooManyRequests|429 Too Many Requests|rateLimitExceeded|SlowDown`),
+	},
+	{
+		class:   configv1alpha1.FailureClassCredentials,
+		pattern: regexp.MustCompile(`no valid credential sources|NoCredentialProviders|InvalidClientTokenId|ExpiredToken|UnrecognizedClientException|could not find default credentials|invalid_grant|AuthorizationFailed|401 Unauthorized`),
+	},
+	{
+		class:   configv1alpha1.FailureClassValidation,
+		pattern: regexp.MustCompile(`Error: (Unsupported (argument|block type|attribute)|Missing required (argument|provider)|Reference to undeclared|Invalid (reference|expression|block definition|resource type|value for variable)|Argument or block definition required|Unclosed configuration block|Duplicate [a-z ]+|No value for required variable)|There are some problems with the configuration`),
+	},
+}
+
+// ClassifyFailure returns the class of the failure of a command given the end
+// of its standard error, Unknown if no known error is found
+func ClassifyFailure(output string) string {
+	for _, p := range failureClassPatterns {
+		if p.pattern.MatchString(output) {
+			return p.class
+		}
+	}
+	return configv1alpha1.FailureClassUnknown
+}
@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"testing"
+
+	configv1alpha1 "github.com/padok-team/burrito/api/v1alpha1"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "state lock held",
+			output: "Error: Error acquiring the state lock\n\nError message: ConditionalCheckFailedException",
+			want:   configv1alpha1.FailureClassStateLocked,
+		},
+		{
+			name:   "provider throttling",
+			output: "Error: reading EC2 Instance: operation error EC2: DescribeInstances, api error RequestLimitExceeded: Request limit exceeded.",
+			want:   configv1alpha1.FailureClassThrottled,
+		},
+		{
+			name:   "expired credentials",
+			output: "Error: configuring Terraform AWS Provider: validating provider credentials: api error ExpiredToken: The security token included in the request is expired",
+			want:   configv1alpha1.FailureClassCredentials,
+		},
+		{
+			name:   "unsupported argument",
+			output: "Error: Unsupported argument\n\n  on main.tf line 3, in resource \"null_resource\" \"this\":\n   3:   foo = \"bar\"",
+			want:   configv1alpha1.FailureClassValidation,
+		},
+		{
+			name:   "unknown error",
+			output: "Error: creating S3 Bucket (logs): BucketAlreadyExists",
+			want:   configv1alpha1.FailureClassUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyFailure(tt.output); got != tt.want {
+				t.Errorf("ClassifyFailure() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
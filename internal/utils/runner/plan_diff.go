@@ -8,6 +8,32 @@ import (
 
 // Produces a diff summary from the given plan
 func GetDiff(plan *tfjson.Plan) (bool, string) {
+	create, update, delete := countChanges(plan)
+	diff := false
+	if create+delete+update > 0 {
+		diff = true
+	}
+	return diff, fmt.Sprintf("Plan: %d to create, %d to update, %d to delete", create, update, delete)
+}
+
+// Produces a diff summary aggregated over the plans of the modules of a
+// terragrunt stack
+func GetStackDiff(plans []*tfjson.Plan) (bool, string) {
+	create, update, delete := 0, 0, 0
+	for _, plan := range plans {
+		c, u, d := countChanges(plan)
+		create += c
+		update += u
+		delete += d
+	}
+	diff := false
+	if create+delete+update > 0 {
+		diff = true
+	}
+	return diff, fmt.Sprintf("Plan: %d to create, %d to update, %d to delete in %d module(s)", create, update, delete, len(plans))
+}
+
+func countChanges(plan *tfjson.Plan) (int, int, int) {
 	delete := 0
 	create := 0
 	update := 0
@@ -26,9 +52,24 @@ func GetDiff(plan *tfjson.Plan) (bool, string) {
 			delete++
 		}
 	}
-	diff := false
-	if create+delete+update > 0 {
-		diff = true
+	return create, update, delete
+}
+
+// Produces a drift summary from the given refresh-only plan
+func GetDrift(plan *tfjson.Plan) (bool, string) {
+	update := 0
+	delete := 0
+	for _, res := range plan.ResourceDrift {
+		if res.Change.Actions.Delete() {
+			delete++
+		}
+		if res.Change.Actions.Update() {
+			update++
+		}
 	}
-	return diff, fmt.Sprintf("Plan: %d to create, %d to update, %d to delete", create, update, delete)
+	drift := false
+	if update+delete > 0 {
+		drift = true
+	}
+	return drift, fmt.Sprintf("Drift: %d updated, %d deleted outside of Terraform", update, delete)
 }
@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestGetDrift(t *testing.T) {
+	tests := []struct {
+		name      string
+		drift     []*tfjson.ResourceChange
+		wantDrift bool
+		want      string
+	}{
+		{
+			name:      "no drift",
+			wantDrift: false,
+			want:      "Drift: 0 updated, 0 deleted outside of Terraform",
+		},
+		{
+			name: "resources changed outside of Terraform",
+			drift: []*tfjson.ResourceChange{
+				{Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionUpdate}}},
+				{Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete}}},
+				{Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionUpdate}}},
+			},
+			wantDrift: true,
+			want:      "Drift: 2 updated, 1 deleted outside of Terraform",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &tfjson.Plan{
+				ResourceDrift: tt.drift,
+				// Changes to the code must not be reported as drift
+				ResourceChanges: []*tfjson.ResourceChange{
+					{Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionCreate}}},
+				},
+			}
+			drift, summary := GetDrift(plan)
+			if drift != tt.wantDrift || summary != tt.want {
+				t.Fatalf("expected (%t, %q), got (%t, %q)", tt.wantDrift, tt.want, drift, summary)
+			}
+		})
+	}
+}
+
+func TestGetStackDiff(t *testing.T) {
+	plans := []*tfjson.Plan{
+		{
+			ResourceChanges: []*tfjson.ResourceChange{
+				{Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionCreate}}},
+				{Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}}},
+			},
+		},
+		{},
+		{
+			ResourceChanges: []*tfjson.ResourceChange{
+				{Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionUpdate}}},
+			},
+		},
+	}
+	diff, summary := GetStackDiff(plans)
+	want := "Plan: 2 to create, 1 to update, 1 to delete in 3 module(s)"
+	if !diff || summary != want {
+		t.Fatalf("expected (true, %q), got (%t, %q)", want, diff, summary)
+	}
+	diff, summary = GetStackDiff(plans[1:2])
+	want = "Plan: 0 to create, 0 to update, 0 to delete in 1 module(s)"
+	if diff || summary != want {
+		t.Fatalf("expected (false, %q), got (%t, %q)", want, diff, summary)
+	}
+}
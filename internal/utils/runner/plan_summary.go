@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"reflect"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const (
+	ActionCreate  string = "create"
+	ActionUpdate  string = "update"
+	ActionDelete  string = "delete"
+	ActionReplace string = "replace"
+	ActionImport  string = "import"
+	ActionMove    string = "move"
+)
+
+// PlanSummary is a machine-readable summary of the changes of a plan, stored
+// in the datastore with the summary format
+type PlanSummary struct {
+	Resources []ResourceSummary `json:"resources"`
+}
+
+// ResourceSummary describes the change of a resource. Values are never
+// included as they may be sensitive.
+type ResourceSummary struct {
+	// Path of the module of a terragrunt stack, empty for a layer
+	Module   string `json:"module,omitempty"`
+	Address  string `json:"address"`
+	Action   string `json:"action"`
+	Provider string `json:"provider"`
+	// Address of the resource before it has been moved
+	PreviousAddress string `json:"previousAddress,omitempty"`
+	// Whether the resource is imported, along with the changes of Action
+	Importing        bool `json:"importing,omitempty"`
+	SensitiveChanged bool `json:"sensitiveChanged"`
+}
+
+// GetSummary produces the summary of the resources changed, imported or moved
+// by the given plan
+func GetSummary(plan *tfjson.Plan) PlanSummary {
+	summary := PlanSummary{Resources: []ResourceSummary{}}
+	for _, res := range plan.ResourceChanges {
+		if res.Change == nil {
+			continue
+		}
+		action := getAction(res)
+		if action == "" {
+			continue
+		}
+		summary.Resources = append(summary.Resources, ResourceSummary{
+			Address:          res.Address,
+			Action:           action,
+			Provider:         res.ProviderName,
+			PreviousAddress:  res.PreviousAddress,
+			Importing:        res.Change.Importing != nil,
+			SensitiveChanged: sensitiveChanged(res.Change),
+		})
+	}
+	return summary
+}
+
+// GetStackSummary produces the summary of the plans of the modules of a
+// terragrunt stack, indexed by module path
+func GetStackSummary(modules []string, plans []*tfjson.Plan) PlanSummary {
+	summary := PlanSummary{Resources: []ResourceSummary{}}
+	for i, plan := range plans {
+		for _, res := range GetSummary(plan).Resources {
+			res.Module = modules[i]
+			summary.Resources = append(summary.Resources, res)
+		}
+	}
+	return summary
+}
+
+// getAction returns the main action of the change of a resource, or an empty
+// string if the resource is left untouched
+func getAction(res *tfjson.ResourceChange) string {
+	actions := res.Change.Actions
+	switch {
+	case actions.Replace():
+		return ActionReplace
+	case actions.Create():
+		return ActionCreate
+	case actions.Delete():
+		return ActionDelete
+	case actions.Update():
+		return ActionUpdate
+	case res.Change.Importing != nil:
+		return ActionImport
+	case res.PreviousAddress != "" && res.PreviousAddress != res.Address:
+		return ActionMove
+	}
+	return ""
+}
+
+// sensitiveChanged returns whether an attribute marked as sensitive before or
+// after the change has a different value after the change
+func sensitiveChanged(change *tfjson.Change) bool {
+	return sensitiveValuesChanged(change.BeforeSensitive, change.Before, change.After) ||
+		sensitiveValuesChanged(change.AfterSensitive, change.Before, change.After)
+}
+
+// sensitiveValuesChanged walks the sensitivity markers of a value, which are
+// either a boolean or a structure of the shape of the value
+func sensitiveValuesChanged(sensitive, before, after interface{}) bool {
+	switch s := sensitive.(type) {
+	case bool:
+		return s && !reflect.DeepEqual(before, after)
+	case map[string]interface{}:
+		b, _ := before.(map[string]interface{})
+		a, _ := after.(map[string]interface{})
+		for key, marker := range s {
+			if sensitiveValuesChanged(marker, b[key], a[key]) {
+				return true
+			}
+		}
+	case []interface{}:
+		b, _ := before.([]interface{})
+		a, _ := after.([]interface{})
+		for i, marker := range s {
+			if sensitiveValuesChanged(marker, elementAt(b, i), elementAt(a, i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func elementAt(values []interface{}, i int) interface{} {
+	if i < len(values) {
+		return values[i]
+	}
+	return nil
+}
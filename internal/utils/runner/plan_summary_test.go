@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestGetSummary(t *testing.T) {
+	plan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			{
+				Address:      "aws_s3_bucket.logs",
+				ProviderName: "registry.terraform.io/hashicorp/aws",
+				Change:       &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionCreate}},
+			},
+			{
+				Address:      "aws_db_instance.main",
+				ProviderName: "registry.terraform.io/hashicorp/aws",
+				Change: &tfjson.Change{
+					Actions:         tfjson.Actions{tfjson.ActionUpdate},
+					Before:          map[string]interface{}{"password": "old", "port": float64(5432)},
+					After:           map[string]interface{}{"password": "new", "port": float64(5432)},
+					BeforeSensitive: map[string]interface{}{"password": true},
+					AfterSensitive:  map[string]interface{}{"password": true},
+				},
+			},
+			{
+				Address:      "aws_iam_user.admin",
+				ProviderName: "registry.terraform.io/hashicorp/aws",
+				Change: &tfjson.Change{
+					Actions:        tfjson.Actions{tfjson.ActionUpdate},
+					Before:         map[string]interface{}{"tags": []interface{}{"a"}, "key": "secret"},
+					After:          map[string]interface{}{"tags": []interface{}{"b"}, "key": "secret"},
+					AfterSensitive: map[string]interface{}{"key": true},
+				},
+			},
+			{
+				Address:      "random_id.suffix",
+				ProviderName: "registry.terraform.io/hashicorp/random",
+				Change:       &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}},
+			},
+			{
+				Address:      "aws_vpc.main",
+				ProviderName: "registry.terraform.io/hashicorp/aws",
+				Change:       &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionNoop}, Importing: &tfjson.Importing{ID: "vpc-123"}},
+			},
+			{
+				Address:         "aws_subnet.private",
+				PreviousAddress: "aws_subnet.main",
+				ProviderName:    "registry.terraform.io/hashicorp/aws",
+				Change:          &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionNoop}},
+			},
+			{
+				Address:      "aws_subnet.public",
+				ProviderName: "registry.terraform.io/hashicorp/aws",
+				Change:       &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionNoop}},
+			},
+		},
+	}
+	want := PlanSummary{Resources: []ResourceSummary{
+		{Address: "aws_s3_bucket.logs", Action: ActionCreate, Provider: "registry.terraform.io/hashicorp/aws"},
+		{Address: "aws_db_instance.main", Action: ActionUpdate, Provider: "registry.terraform.io/hashicorp/aws", SensitiveChanged: true},
+		{Address: "aws_iam_user.admin", Action: ActionUpdate, Provider: "registry.terraform.io/hashicorp/aws"},
+		{Address: "random_id.suffix", Action: ActionReplace, Provider: "registry.terraform.io/hashicorp/random"},
+		{Address: "aws_vpc.main", Action: ActionImport, Provider: "registry.terraform.io/hashicorp/aws", Importing: true},
+		{Address: "aws_subnet.private", Action: ActionMove, Provider: "registry.terraform.io/hashicorp/aws", PreviousAddress: "aws_subnet.main"},
+	}}
+	got := GetSummary(plan)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestGetStackSummary(t *testing.T) {
+	plans := []*tfjson.Plan{
+		{ResourceChanges: []*tfjson.ResourceChange{
+			{Address: "aws_vpc.main", Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete}}},
+		}},
+		{},
+	}
+	got := GetStackSummary([]string{"network", "app"}, plans)
+	want := PlanSummary{Resources: []ResourceSummary{
+		{Module: "network", Address: "aws_vpc.main", Action: ActionDelete},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// SensitiveValue replaces the sensitive values stripped from the JSON plans
+const SensitiveValue = "(sensitive value)"
+
+// StripSensitiveValues replaces the values marked as sensitive in a JSON plan
+// with SensitiveValue, so that the plan can be stored for display: the
+// attributes of the resource changes and of the planned and prior values,
+// the outputs and the input variables declared as sensitive.
+func StripSensitiveValues(planJson []byte) ([]byte, error) {
+	plan := map[string]interface{}{}
+	// Numbers are kept as they are written in the plan
+	decoder := json.NewDecoder(bytes.NewReader(planJson))
+	decoder.UseNumber()
+	err := decoder.Decode(&plan)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"resource_changes", "resource_drift"} {
+		for _, rc := range asSlice(plan[key]) {
+			stripChange(asMap(asMap(rc)["change"]))
+		}
+	}
+	for _, oc := range asMap(plan["output_changes"]) {
+		stripChange(asMap(oc))
+	}
+	stripValues(asMap(plan["planned_values"]))
+	stripValues(asMap(asMap(plan["prior_state"])["values"]))
+	variables := asMap(plan["variables"])
+	declared := asMap(asMap(asMap(plan["configuration"])["root_module"])["variables"])
+	for name, declaration := range declared {
+		if sensitive, _ := asMap(declaration)["sensitive"].(bool); sensitive {
+			if variable := asMap(variables[name]); variable != nil {
+				variable["value"] = SensitiveValue
+			}
+		}
+	}
+	return json.Marshal(plan)
+}
+
+// stripChange masks the before and after values of a change with their
+// sensitivity masks
+func stripChange(change map[string]interface{}) {
+	if change == nil {
+		return
+	}
+	change["before"] = maskSensitive(change["before"], change["before_sensitive"])
+	change["after"] = maskSensitive(change["after"], change["after_sensitive"])
+}
+
+// stripValues masks the values of the resources and outputs of a module and
+// of its child modules
+func stripValues(values map[string]interface{}) {
+	if values == nil {
+		return
+	}
+	for _, output := range asMap(values["outputs"]) {
+		output := asMap(output)
+		if sensitive, _ := output["sensitive"].(bool); sensitive {
+			output["value"] = SensitiveValue
+		}
+	}
+	stripModule(asMap(values["root_module"]))
+}
+
+func stripModule(module map[string]interface{}) {
+	if module == nil {
+		return
+	}
+	for _, resource := range asSlice(module["resources"]) {
+		resource := asMap(resource)
+		if resource == nil {
+			continue
+		}
+		resource["values"] = maskSensitive(resource["values"], resource["sensitive_values"])
+	}
+	for _, child := range asSlice(module["child_modules"]) {
+		stripModule(asMap(child))
+	}
+}
+
+// maskSensitive replaces the parts of a value marked as sensitive by a
+// sensitivity mask, which is either true or a structure matching the value
+func maskSensitive(value interface{}, sensitive interface{}) interface{} {
+	switch s := sensitive.(type) {
+	case bool:
+		if s && value != nil {
+			return SensitiveValue
+		}
+	case map[string]interface{}:
+		v, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		for key, mask := range s {
+			if inner, ok := v[key]; ok {
+				v[key] = maskSensitive(inner, mask)
+			}
+		}
+	case []interface{}:
+		v, ok := value.([]interface{})
+		if !ok {
+			return value
+		}
+		for i, mask := range s {
+			if i < len(v) {
+				v[i] = maskSensitive(v[i], mask)
+			}
+		}
+	}
+	return value
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStripSensitiveValues(t *testing.T) {
+	plan := `{
+  "format_version": "1.2",
+  "variables": {
+    "db_password": {"value": "hunter2hunter2"},
+    "region": {"value": "eu-west-3"}
+  },
+  "planned_values": {
+    "outputs": {
+      "password": {"sensitive": true, "value": "hunter2hunter2"},
+      "endpoint": {"sensitive": false, "value": "db.example.com"}
+    },
+    "root_module": {
+      "resources": [{
+        "address": "aws_db_instance.main",
+        "values": {"password": "hunter2hunter2", "port": 5432, "tags": {"token": "abcd1234", "env": "prod"}},
+        "sensitive_values": {"password": true, "tags": {"token": true}}
+      }]
+    }
+  },
+  "resource_changes": [{
+    "address": "aws_db_instance.main",
+    "change": {
+      "actions": ["update"],
+      "before": {"password": "oldpassword", "port": 5432},
+      "after": {"password": "hunter2hunter2", "port": 5432},
+      "before_sensitive": {"password": true},
+      "after_sensitive": {"password": true}
+    }
+  }],
+  "output_changes": {
+    "password": {
+      "actions": ["update"],
+      "before": "oldpassword",
+      "after": "hunter2hunter2",
+      "before_sensitive": true,
+      "after_sensitive": true
+    }
+  },
+  "configuration": {
+    "root_module": {
+      "variables": {
+        "db_password": {"sensitive": true},
+        "region": {}
+      }
+    }
+  }
+}`
+	stripped, err := StripSensitiveValues([]byte(plan))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := map[string]interface{}{}
+	if err := json.Unmarshal(stripped, &got); err != nil {
+		t.Fatalf("stripped plan is not valid JSON: %s", err)
+	}
+	tests := []struct {
+		name string
+		path []interface{}
+		want interface{}
+	}{
+		{"sensitive variable", []interface{}{"variables", "db_password", "value"}, SensitiveValue},
+		{"variable", []interface{}{"variables", "region", "value"}, "eu-west-3"},
+		{"sensitive output", []interface{}{"planned_values", "outputs", "password", "value"}, SensitiveValue},
+		{"output", []interface{}{"planned_values", "outputs", "endpoint", "value"}, "db.example.com"},
+		{"sensitive attribute", []interface{}{"planned_values", "root_module", "resources", 0, "values", "password"}, SensitiveValue},
+		{"nested sensitive attribute", []interface{}{"planned_values", "root_module", "resources", 0, "values", "tags", "token"}, SensitiveValue},
+		{"nested attribute", []interface{}{"planned_values", "root_module", "resources", 0, "values", "tags", "env"}, "prod"},
+		{"number", []interface{}{"planned_values", "root_module", "resources", 0, "values", "port"}, float64(5432)},
+		{"sensitive value before change", []interface{}{"resource_changes", 0, "change", "before", "password"}, SensitiveValue},
+		{"sensitive value after change", []interface{}{"resource_changes", 0, "change", "after", "password"}, SensitiveValue},
+		{"sensitive output change", []interface{}{"output_changes", "password", "after"}, SensitiveValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{} = got
+			for _, key := range tt.path {
+				switch k := key.(type) {
+				case string:
+					value = value.(map[string]interface{})[k]
+				case int:
+					value = value.([]interface{})[k]
+				}
+			}
+			if value != tt.want {
+				t.Errorf("got %v, want %v", value, tt.want)
+			}
+		})
+	}
+}